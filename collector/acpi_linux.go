@@ -0,0 +1,212 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noacpi
+
+package collector
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const acpiSubsystem = "acpi"
+
+// acpiFan is an ACPI fan registered as thermal cooling device.
+type acpiFan struct {
+	name            string
+	state, maxState uint64
+	speedRPM        uint64
+	hasSpeed        bool
+}
+
+// cpuThrottles holds the thermal throttle events of a cpu, the package
+// count is the same for all cpus of a package.
+type cpuThrottles struct {
+	cpu, pkg      string
+	core, pkgWide uint64
+}
+
+type acpiCollector struct {
+	platformProfile typedDesc
+	fanState        typedDesc
+	fanMaxState     typedDesc
+	fanSpeed        typedDesc
+	coreThrottles   typedDesc
+	pkgThrottles    typedDesc
+}
+
+func init() {
+	Factories["acpi"] = NewACPICollector
+}
+
+// NewACPICollector returns a new Collector exposing the ACPI platform
+// profile, the state of ACPI fans and the thermal throttle events of the
+// cpus.
+func NewACPICollector() (Collector, error) {
+	return &acpiCollector{
+		platformProfile: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, acpiSubsystem, "platform_profile"),
+			"Current ACPI platform profile, value is 1 for the selected one.",
+			[]string{"profile"}, nil,
+		), prometheus.GaugeValue},
+		fanState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, acpiSubsystem, "fan_state"),
+			"Current cooling state of the ACPI fan, 0 is off.",
+			[]string{"fan"}, nil,
+		), prometheus.GaugeValue},
+		fanMaxState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, acpiSubsystem, "fan_max_state"),
+			"Highest cooling state of the ACPI fan.",
+			[]string{"fan"}, nil,
+		), prometheus.GaugeValue},
+		fanSpeed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, acpiSubsystem, "fan_speed_rpm"),
+			"Speed of the ACPI fan in revolutions per minute.",
+			[]string{"fan"}, nil,
+		), prometheus.GaugeValue},
+		coreThrottles: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "core_throttles_total"),
+			"Times the core of the cpu was throttled because of its temperature.",
+			[]string{"cpu"}, nil,
+		), prometheus.CounterValue},
+		pkgThrottles: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "package_throttles_total"),
+			"Times the package was throttled because of its temperature.",
+			[]string{"package"}, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *acpiCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	current, choices, err := readPlatformProfile(sysFilePath("firmware/acpi"))
+	if err != nil {
+		return err
+	}
+	for _, profile := range choices {
+		v := 0.0
+		if profile == current {
+			v = 1
+		}
+		ch <- c.platformProfile.mustNewConstMetric(v, profile)
+	}
+
+	fans, err := readACPIFans(sysFilePath("class/thermal"))
+	if err != nil {
+		return err
+	}
+	for _, fan := range fans {
+		ch <- c.fanState.mustNewConstMetric(float64(fan.state), fan.name)
+		ch <- c.fanMaxState.mustNewConstMetric(float64(fan.maxState), fan.name)
+		if fan.hasSpeed {
+			ch <- c.fanSpeed.mustNewConstMetric(float64(fan.speedRPM), fan.name)
+		}
+	}
+
+	throttles, err := readCPUThrottles(sysFilePath("devices/system/cpu"))
+	if err != nil {
+		return err
+	}
+	packages := map[string]bool{}
+	for _, t := range throttles {
+		ch <- c.coreThrottles.mustNewConstMetric(float64(t.core), t.cpu)
+		if !packages[t.pkg] {
+			packages[t.pkg] = true
+			ch <- c.pkgThrottles.mustNewConstMetric(float64(t.pkgWide), t.pkg)
+		}
+	}
+	return nil
+}
+
+// readPlatformProfile returns the selected platform profile and the ones
+// the firmware offers, none if it doesn't support platform profiles.
+func readPlatformProfile(root string) (string, []string, error) {
+	current, err := readStringFromFile(path.Join(root, "platform_profile"))
+	if os.IsNotExist(err) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	list, err := readStringFromFile(path.Join(root, "platform_profile_choices"))
+	if err != nil {
+		return "", nil, err
+	}
+	choices := strings.Fields(list)
+	for _, profile := range choices {
+		if profile == current {
+			return current, choices, nil
+		}
+	}
+	return current, append(choices, current), nil
+}
+
+// readACPIFans reads the cooling devices of type Fan. Fans implementing
+// ACPI 4.0 also report their speed.
+func readACPIFans(root string) ([]acpiFan, error) {
+	devices, err := filepath.Glob(path.Join(root, "cooling_device[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var fans []acpiFan
+	for _, dev := range devices {
+		if t, err := readStringFromFile(path.Join(dev, "type")); err != nil || t != "Fan" {
+			continue
+		}
+		fan := acpiFan{name: path.Base(dev)}
+		if fan.state, err = readUintFromFile(path.Join(dev, "cur_state")); err != nil {
+			return nil, err
+		}
+		if fan.maxState, err = readUintFromFile(path.Join(dev, "max_state")); err != nil {
+			return nil, err
+		}
+		if fan.speedRPM, err = readUintFromFile(path.Join(dev, "device", "fan_speed_rpm")); err == nil {
+			fan.hasSpeed = true
+		}
+		fans = append(fans, fan)
+	}
+	return fans, nil
+}
+
+// readCPUThrottles reads the thermal throttle counters of the cpus, which
+// only Intel cpus provide.
+func readCPUThrottles(root string) ([]cpuThrottles, error) {
+	dirs, err := filepath.Glob(path.Join(root, "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var throttles []cpuThrottles
+	for _, dir := range dirs {
+		core, err := readUintFromFile(path.Join(dir, "thermal_throttle", "core_throttle_count"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		pkgWide, err := readUintFromFile(path.Join(dir, "thermal_throttle", "package_throttle_count"))
+		if err != nil {
+			return nil, err
+		}
+		pkg, err := readStringFromFile(path.Join(dir, "topology", "physical_package_id"))
+		if err != nil {
+			return nil, err
+		}
+		throttles = append(throttles, cpuThrottles{cpu: path.Base(dir), pkg: pkg, core: core, pkgWide: pkgWide})
+	}
+	return throttles, nil
+}
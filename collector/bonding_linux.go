@@ -26,7 +26,7 @@ import (
 )
 
 type bondingCollector struct {
-	slaves, active typedDesc
+	slaves, active, miiUp typedDesc
 }
 
 func init() {
@@ -47,12 +47,18 @@ func NewBondingCollector() (Collector, error) {
 			"Number of active slaves per bonding interface.",
 			[]string{"master"}, nil,
 		), prometheus.GaugeValue},
+		miiUp: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "bonding", "slave_mii_up"),
+			"Whether the MII status of the bonding slave is up.",
+			[]string{"master", "slave"}, nil,
+		), prometheus.GaugeValue},
 	}, nil
 }
 
 // Update reads and exposes bonding states, implements Collector interface. Caution: This works only on linux.
 func (c *bondingCollector) Update(ch chan<- prometheus.Metric) (err error) {
-	bondingStats, err := readBondingStats(sysFilePath("class/net"))
+	root := sysFilePath("class/net")
+	bondingStats, err := readBondingStats(root)
 	if err != nil {
 		return err
 	}
@@ -60,6 +66,19 @@ func (c *bondingCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		ch <- c.slaves.mustNewConstMetric(float64(status[0]), master)
 		ch <- c.active.mustNewConstMetric(float64(status[1]), master)
 	}
+	miiStatus, err := readBondingMIIStatus(root)
+	if err != nil {
+		return err
+	}
+	for master, slaves := range miiStatus {
+		for slave, up := range slaves {
+			v := 0.0
+			if up {
+				v = 1
+			}
+			ch <- c.miiUp.mustNewConstMetric(v, master, slave)
+		}
+	}
 	return nil
 }
 
@@ -76,11 +95,7 @@ func readBondingStats(root string) (status map[string][2]int, err error) {
 		}
 		sstat := [2]int{0, 0}
 		for _, slave := range strings.Fields(string(slaves)) {
-			state, err := ioutil.ReadFile(path.Join(root, master, fmt.Sprintf("lower_%s", slave), "operstate"))
-			if os.IsNotExist(err) {
-				// some older? kernels use slave_ prefix
-				state, err = ioutil.ReadFile(path.Join(root, master, fmt.Sprintf("slave_%s", slave), "operstate"))
-			}
+			state, err := ioutil.ReadFile(path.Join(bondingSlaveDir(root, master, slave), "operstate"))
 			if err != nil {
 				return nil, err
 			}
@@ -93,3 +108,42 @@ func readBondingStats(root string) (status map[string][2]int, err error) {
 	}
 	return status, err
 }
+
+// readBondingMIIStatus returns whether the MII status of each slave is up, by
+// master and slave. Slaves of kernels without bonding_slave in sysfs are left
+// out.
+func readBondingMIIStatus(root string) (map[string]map[string]bool, error) {
+	masters, err := ioutil.ReadFile(path.Join(root, "bonding_masters"))
+	if err != nil {
+		return nil, err
+	}
+	status := map[string]map[string]bool{}
+	for _, master := range strings.Fields(string(masters)) {
+		slaves, err := ioutil.ReadFile(path.Join(root, master, "bonding", "slaves"))
+		if err != nil {
+			return nil, err
+		}
+		status[master] = map[string]bool{}
+		for _, slave := range strings.Fields(string(slaves)) {
+			mii, err := ioutil.ReadFile(path.Join(bondingSlaveDir(root, master, slave), "bonding_slave", "mii_status"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			status[master][slave] = strings.TrimSpace(string(mii)) == "up"
+		}
+	}
+	return status, nil
+}
+
+// bondingSlaveDir returns the directory of the slave below its master.
+func bondingSlaveDir(root, master, slave string) string {
+	dir := path.Join(root, master, fmt.Sprintf("lower_%s", slave))
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// some older? kernels use slave_ prefix
+		dir = path.Join(root, master, fmt.Sprintf("slave_%s", slave))
+	}
+	return dir
+}
@@ -33,4 +33,18 @@ func TestBonding(t *testing.T) {
 	if bondingStats["dmz"][0] != 2 || bondingStats["dmz"][1] != 2 {
 		t.Fatal("dmz in unexpected state")
 	}
+
+	miiStatus, err := readBondingMIIStatus("fixtures/sys/class/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(miiStatus["bond0"]) != 0 {
+		t.Fatal("bond0 has unexpected slaves")
+	}
+	if miiStatus["int"]["eth1"] || !miiStatus["int"]["eth5"] {
+		t.Fatal("int slaves in unexpected MII state")
+	}
+	if !miiStatus["dmz"]["eth0"] || miiStatus["dmz"]["eth4"] {
+		t.Fatal("dmz slaves in unexpected MII state")
+	}
 }
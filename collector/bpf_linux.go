@@ -0,0 +1,259 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobpf
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	bpfSubsystem = "bpf"
+
+	// Commands of the bpf(2) syscall.
+	bpfProgGetNextID = 11
+	bpfMapGetNextID  = 12
+	bpfProgGetFDByID = 13
+	bpfMapGetFDByID  = 14
+)
+
+var (
+	// Number of the bpf(2) syscall, which is missing from the syscall
+	// package on most architectures.
+	bpfSyscall = map[string]uintptr{
+		"386":      357,
+		"amd64":    321,
+		"arm":      386,
+		"arm64":    280,
+		"mips":     4355,
+		"mipsle":   4355,
+		"mips64":   5315,
+		"mips64le": 5315,
+		"ppc64":    361,
+		"ppc64le":  361,
+		"s390x":    351,
+	}[runtime.GOARCH]
+
+	// Names of enum bpf_prog_type and enum bpf_map_type in include/uapi/linux/bpf.h.
+	bpfProgTypes = []string{
+		"unspec", "socket_filter", "kprobe", "sched_cls", "sched_act",
+		"tracepoint", "xdp", "perf_event", "cgroup_skb", "cgroup_sock",
+		"lwt_in", "lwt_out", "lwt_xmit", "sock_ops", "sk_skb",
+		"cgroup_device", "sk_msg", "raw_tracepoint", "cgroup_sock_addr",
+		"lwt_seg6local", "lirc_mode2", "sk_reuseport", "flow_dissector",
+		"cgroup_sysctl", "raw_tracepoint_writable", "cgroup_sockopt",
+		"tracing", "struct_ops", "ext", "lsm", "sk_lookup", "syscall",
+		"netfilter",
+	}
+	bpfMapTypes = []string{
+		"unspec", "hash", "array", "prog_array", "perf_event_array",
+		"percpu_hash", "percpu_array", "stack_trace", "cgroup_array",
+		"lru_hash", "lru_percpu_hash", "lpm_trie", "array_of_maps",
+		"hash_of_maps", "devmap", "sockmap", "cpumap", "xskmap", "sockhash",
+		"cgroup_storage", "reuseport_sockarray", "percpu_cgroup_storage",
+		"queue", "stack", "sk_storage", "devmap_hash", "struct_ops",
+		"ringbuf", "inode_storage", "task_storage", "bloom_filter",
+		"user_ringbuf", "cgrp_storage", "arena",
+	}
+)
+
+type bpfCollector struct {
+	programs, programMemory typedDesc
+	maps, mapMemory         typedDesc
+	runCount, runTime       typedDesc
+}
+
+func init() {
+	Factories["bpf"] = NewBPFCollector
+}
+
+// NewBPFCollector returns a new Collector exposing loaded BPF programs and
+// maps.
+func NewBPFCollector() (Collector, error) {
+	if bpfSyscall == 0 {
+		return nil, fmt.Errorf("bpf syscall not supported on %s", runtime.GOARCH)
+	}
+	return &bpfCollector{
+		programs: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "programs"),
+			"Number of loaded BPF programs.",
+			[]string{"type"}, nil,
+		), prometheus.GaugeValue},
+		programMemory: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "programs_memlock_bytes"),
+			"Memory locked by loaded BPF programs.",
+			[]string{"type"}, nil,
+		), prometheus.GaugeValue},
+		maps: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "maps"),
+			"Number of BPF maps.",
+			[]string{"type"}, nil,
+		), prometheus.GaugeValue},
+		mapMemory: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "maps_memlock_bytes"),
+			"Memory locked by BPF maps.",
+			[]string{"type"}, nil,
+		), prometheus.GaugeValue},
+		runCount: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "program_runs_total"),
+			"Number of times the BPF program ran, only counted while kernel.bpf_stats_enabled is set.",
+			[]string{"id", "type", "tag"}, nil,
+		), prometheus.CounterValue},
+		runTime: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bpfSubsystem, "program_run_time_seconds_total"),
+			"Time spent running the BPF program, only counted while kernel.bpf_stats_enabled is set.",
+			[]string{"id", "type", "tag"}, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *bpfCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	progs, err := bpfObjects(bpfProgGetNextID, bpfProgGetFDByID)
+	if err != nil {
+		return fmt.Errorf("couldn't list BPF programs: %s", err)
+	}
+	maps, err := bpfObjects(bpfMapGetNextID, bpfMapGetFDByID)
+	if err != nil {
+		return fmt.Errorf("couldn't list BPF maps: %s", err)
+	}
+
+	statsEnabled, err := readUintFromFile(procFilePath("sys/kernel/bpf_stats_enabled"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	progCount, progMemory := map[string]float64{}, map[string]float64{}
+	for _, p := range progs {
+		typ := bpfTypeName(bpfProgTypes, p["prog_type"])
+		progCount[typ]++
+		progMemory[typ] += parseBPFValue(p["memlock"])
+		if statsEnabled == 1 {
+			ch <- c.runCount.mustNewConstMetric(parseBPFValue(p["run_cnt"]), p["prog_id"], typ, p["prog_tag"])
+			ch <- c.runTime.mustNewConstMetric(parseBPFValue(p["run_time_ns"])/1e9, p["prog_id"], typ, p["prog_tag"])
+		}
+	}
+	for typ, count := range progCount {
+		ch <- c.programs.mustNewConstMetric(count, typ)
+		ch <- c.programMemory.mustNewConstMetric(progMemory[typ], typ)
+	}
+
+	mapCount, mapMemory := map[string]float64{}, map[string]float64{}
+	for _, m := range maps {
+		typ := bpfTypeName(bpfMapTypes, m["map_type"])
+		mapCount[typ]++
+		mapMemory[typ] += parseBPFValue(m["memlock"])
+	}
+	for typ, count := range mapCount {
+		ch <- c.maps.mustNewConstMetric(count, typ)
+		ch <- c.mapMemory.mustNewConstMetric(mapMemory[typ], typ)
+	}
+	return nil
+}
+
+// bpfObjects iterates over all BPF programs or maps by id and returns the
+// fdinfo of each one.
+func bpfObjects(nextIDCmd, fdByIDCmd uintptr) ([]map[string]string, error) {
+	var (
+		objects []map[string]string
+		id      uint32
+	)
+	for {
+		// union bpf_attr for these commands starts with the id, followed
+		// by next_id and open_flags.
+		attr := [3]uint32{id}
+		if _, err := bpf(nextIDCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr)); err != nil {
+			if err == syscall.ENOENT {
+				return objects, nil
+			}
+			return nil, err
+		}
+		id = attr[1]
+
+		attr = [3]uint32{id}
+		fd, err := bpf(fdByIDCmd, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+		if err == syscall.ENOENT {
+			// Unloaded in the meantime.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The fd belongs to this process, so it is in the exporter's own
+		// /proc/self, whatever -collector.procfs is set to.
+		info, err := readBPFFdinfo(fmt.Sprintf("/proc/self/fdinfo/%d", fd))
+		syscall.Close(int(fd))
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, info)
+	}
+}
+
+func bpf(cmd uintptr, attr unsafe.Pointer, size uintptr) (uintptr, error) {
+	r, _, e := syscall.Syscall(bpfSyscall, cmd, uintptr(attr), size)
+	if e != 0 {
+		return 0, e
+	}
+	return r, nil
+}
+
+func readBPFFdinfo(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseBPFFdinfo(file)
+}
+
+func parseBPFFdinfo(r io.Reader) (map[string]string, error) {
+	info := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		info[parts[0]] = strings.TrimSpace(parts[1])
+	}
+	return info, scanner.Err()
+}
+
+func bpfTypeName(names []string, value string) string {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 || i >= len(names) {
+		return value
+	}
+	return names[i]
+}
+
+func parseBPFValue(value string) float64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,42 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestBPFFdinfo(t *testing.T) {
+	prog, err := readBPFFdinfo("fixtures/proc/self/fdinfo/5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "xdp", bpfTypeName(bpfProgTypes, prog["prog_type"]); want != got {
+		t.Errorf("want program type %s, got %s", want, got)
+	}
+	if want, got := 3000.0, parseBPFValue(prog["run_cnt"]); want != got {
+		t.Errorf("want run count %f, got %f", want, got)
+	}
+
+	m, err := readBPFFdinfo("fixtures/proc/self/fdinfo/6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "hash", bpfTypeName(bpfMapTypes, m["map_type"]); want != got {
+		t.Errorf("want map type %s, got %s", want, got)
+	}
+	if want, got := 86016.0, parseBPFValue(m["memlock"]); want != got {
+		t.Errorf("want memlock %f, got %f", want, got)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nobridge
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	bridgeSubsystem = "bridge"
+
+	// Size of struct __fdb_entry as read from the brforward sysfs file.
+	bridgeFdbEntrySize = 16
+)
+
+// STP port states as defined in include/uapi/linux/if_bridge.h.
+var bridgePortStates = []string{"disabled", "listening", "learning", "forwarding", "blocking"}
+
+type bridgePort struct {
+	name           string
+	state          uint64
+	designatedRoot string
+}
+
+type bridgeStats struct {
+	name              string
+	bridgeID          string
+	rootID            string
+	stpState          uint64
+	multicastSnooping uint64
+	fdbEntries        uint64
+	ports             []bridgePort
+}
+
+type bridgeCollector struct {
+	info, stpEnabled, multicastSnooping, fdbEntries typedDesc
+	ports, portState, portInfo                      typedDesc
+}
+
+func init() {
+	Factories["bridge"] = NewBridgeCollector
+}
+
+// NewBridgeCollector returns a newly allocated bridgeCollector.
+// It exposes STP, forwarding database and port state of linux bridges.
+func NewBridgeCollector() (Collector, error) {
+	return &bridgeCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "info"),
+			"Bridge identifier and designated root bridge, value is always 1.",
+			[]string{"bridge", "bridge_id", "root_id"}, nil,
+		), prometheus.GaugeValue},
+		stpEnabled: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "stp_enabled"),
+			"Whether the spanning tree protocol is enabled on the bridge.",
+			[]string{"bridge"}, nil,
+		), prometheus.GaugeValue},
+		multicastSnooping: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "multicast_snooping_enabled"),
+			"Whether IGMP/MLD snooping is enabled on the bridge.",
+			[]string{"bridge"}, nil,
+		), prometheus.GaugeValue},
+		fdbEntries: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "fdb_entries"),
+			"Number of entries in the bridge forwarding database.",
+			[]string{"bridge"}, nil,
+		), prometheus.GaugeValue},
+		ports: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "ports"),
+			"Number of ports attached to the bridge.",
+			[]string{"bridge"}, nil,
+		), prometheus.GaugeValue},
+		portState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "port_stp_state"),
+			"STP state of the bridge port.",
+			[]string{"bridge", "port", "state"}, nil,
+		), prometheus.GaugeValue},
+		portInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, bridgeSubsystem, "port_info"),
+			"Designated root bridge as seen by the bridge port, value is always 1.",
+			[]string{"bridge", "port", "designated_root"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+// Update reads and exposes bridge states, implements Collector interface.
+func (c *bridgeCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	bridges, err := readBridgeStats(sysFilePath("class/net"))
+	if err != nil {
+		return err
+	}
+	for _, br := range bridges {
+		ch <- c.info.mustNewConstMetric(1, br.name, br.bridgeID, br.rootID)
+		ch <- c.stpEnabled.mustNewConstMetric(float64(br.stpState), br.name)
+		ch <- c.multicastSnooping.mustNewConstMetric(float64(br.multicastSnooping), br.name)
+		ch <- c.fdbEntries.mustNewConstMetric(float64(br.fdbEntries), br.name)
+		ch <- c.ports.mustNewConstMetric(float64(len(br.ports)), br.name)
+		for _, port := range br.ports {
+			for i, state := range bridgePortStates {
+				v := 0.0
+				if uint64(i) == port.state {
+					v = 1.0
+				}
+				ch <- c.portState.mustNewConstMetric(v, br.name, port.name, state)
+			}
+			ch <- c.portInfo.mustNewConstMetric(1, br.name, port.name, port.designatedRoot)
+		}
+	}
+	return nil
+}
+
+func readBridgeStats(root string) ([]bridgeStats, error) {
+	ifaces, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var bridges []bridgeStats
+	for _, iface := range ifaces {
+		brPath := path.Join(root, iface.Name())
+		if _, err := os.Stat(path.Join(brPath, "bridge")); err != nil {
+			continue
+		}
+		br, err := readBridge(brPath)
+		if err != nil {
+			return nil, err
+		}
+		br.name = iface.Name()
+		bridges = append(bridges, br)
+	}
+	return bridges, nil
+}
+
+func readBridge(brPath string) (br bridgeStats, err error) {
+	if br.bridgeID, err = readStringFromFile(path.Join(brPath, "bridge", "bridge_id")); err != nil {
+		return br, err
+	}
+	if br.rootID, err = readStringFromFile(path.Join(brPath, "bridge", "root_id")); err != nil {
+		return br, err
+	}
+	if br.stpState, err = readUintFromFile(path.Join(brPath, "bridge", "stp_state")); err != nil {
+		return br, err
+	}
+	// Kernels built without CONFIG_BRIDGE_IGMP_SNOOPING lack this file.
+	br.multicastSnooping, err = readUintFromFile(path.Join(brPath, "bridge", "multicast_snooping"))
+	if err != nil && !os.IsNotExist(err) {
+		return br, err
+	}
+	fdb, err := ioutil.ReadFile(path.Join(brPath, "brforward"))
+	if err != nil {
+		return br, err
+	}
+	br.fdbEntries = uint64(len(fdb) / bridgeFdbEntrySize)
+
+	ports, err := ioutil.ReadDir(path.Join(brPath, "brif"))
+	if err != nil {
+		return br, err
+	}
+	for _, p := range ports {
+		port := bridgePort{name: p.Name()}
+		portPath := path.Join(brPath, "brif", p.Name())
+		if port.state, err = readUintFromFile(path.Join(portPath, "state")); err != nil {
+			return br, err
+		}
+		if port.designatedRoot, err = readStringFromFile(path.Join(portPath, "designated_root")); err != nil {
+			return br, err
+		}
+		br.ports = append(br.ports, port)
+	}
+	return br, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestBridge(t *testing.T) {
+	bridges, err := readBridgeStats("fixtures/sys/class/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(bridges); want != got {
+		t.Fatalf("want %d bridges, got %d", want, got)
+	}
+
+	br := bridges[0]
+	if want, got := "br0", br.name; want != got {
+		t.Errorf("want bridge %s, got %s", want, got)
+	}
+	if want, got := uint64(3), br.fdbEntries; want != got {
+		t.Errorf("want %d fdb entries, got %d", want, got)
+	}
+	if want, got := uint64(1), br.stpState; want != got {
+		t.Errorf("want stp state %d, got %d", want, got)
+	}
+	if want, got := 2, len(br.ports); want != got {
+		t.Fatalf("want %d ports, got %d", want, got)
+	}
+	if want, got := "forwarding", bridgePortStates[br.ports[0].state]; want != got {
+		t.Errorf("want port %s in state %s, got %s", br.ports[0].name, want, got)
+	}
+	if want, got := "blocking", bridgePortStates[br.ports[1].state]; want != got {
+		t.Errorf("want port %s in state %s, got %s", br.ports[1].name, want, got)
+	}
+}
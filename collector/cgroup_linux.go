@@ -0,0 +1,254 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocgroup
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const cgroupSubsystem = "cgroup"
+
+// The v1 memory controller reports the largest page aligned value below
+// 2^63 as limit of unlimited cgroups.
+const cgroupV1Unlimited = 1 << 62
+
+var (
+	cgroupMaxDepth      = flag.Int("collector.cgroup.max-depth", 2, "Depth of the cgroups below the root to include, 0 for the root cgroup only.")
+	cgroupPathWhitelist = flag.String("collector.cgroup.path-whitelist", ".+", "Regexp of cgroup paths, e.g. /system.slice/sshd.service, to include.")
+)
+
+var cgroupMetrics = []struct {
+	name, help string
+	valueType  prometheus.ValueType
+}{
+	{"cpu_usage_seconds_total", "CPU time consumed by the tasks of the cgroup.", prometheus.CounterValue},
+	{"cpu_periods_total", "Enforcement periods of the CPU bandwidth limit of the cgroup.", prometheus.CounterValue},
+	{"cpu_throttled_periods_total", "Enforcement periods in which the cgroup was throttled.", prometheus.CounterValue},
+	{"cpu_throttled_seconds_total", "Time the tasks of the cgroup were throttled.", prometheus.CounterValue},
+	{"memory_usage_bytes", "Memory charged to the cgroup.", prometheus.GaugeValue},
+	{"memory_max_bytes", "Memory limit of the cgroup, absent if unlimited.", prometheus.GaugeValue},
+}
+
+// cgroupFile maps a value of a cgroup file to a metric. key is the key of
+// flat keyed files like cpu.stat and empty for single value files, divisor
+// converts the value to seconds or bytes.
+type cgroupFile struct {
+	controller, file, key string
+	metric                string
+	divisor               float64
+}
+
+var (
+	cgroupV2Files = []cgroupFile{
+		{"", "cpu.stat", "usage_usec", "cpu_usage_seconds_total", 1e6},
+		{"", "cpu.stat", "nr_periods", "cpu_periods_total", 1},
+		{"", "cpu.stat", "nr_throttled", "cpu_throttled_periods_total", 1},
+		{"", "cpu.stat", "throttled_usec", "cpu_throttled_seconds_total", 1e6},
+		{"", "memory.current", "", "memory_usage_bytes", 1},
+		{"", "memory.max", "", "memory_max_bytes", 1},
+	}
+	cgroupV1Files = []cgroupFile{
+		{"cpuacct", "cpuacct.usage", "", "cpu_usage_seconds_total", 1e9},
+		{"cpu", "cpu.stat", "nr_periods", "cpu_periods_total", 1},
+		{"cpu", "cpu.stat", "nr_throttled", "cpu_throttled_periods_total", 1},
+		{"cpu", "cpu.stat", "throttled_time", "cpu_throttled_seconds_total", 1e9},
+		{"memory", "memory.usage_in_bytes", "", "memory_usage_bytes", 1},
+		{"memory", "memory.limit_in_bytes", "", "memory_max_bytes", 1},
+	}
+)
+
+type cgroupCollector struct {
+	maxDepth int
+	paths    *regexp.Regexp
+	descs    map[string]*typedDesc
+}
+
+func init() {
+	Factories["cgroup"] = NewCgroupCollector
+}
+
+// NewCgroupCollector returns a new Collector exposing the CPU and memory
+// usage and the CPU throttling of cgroups.
+func NewCgroupCollector() (Collector, error) {
+	paths, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", *cgroupPathWhitelist))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cgroup path whitelist: %s", err)
+	}
+	c := &cgroupCollector{
+		maxDepth: *cgroupMaxDepth,
+		paths:    paths,
+		descs:    map[string]*typedDesc{},
+	}
+	for _, m := range cgroupMetrics {
+		c.descs[m.name] = &typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cgroupSubsystem, m.name),
+			m.help, []string{"cgroup"}, nil,
+		), m.valueType}
+	}
+	return c, nil
+}
+
+func (c *cgroupCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cgroups, err := readCgroups(sysFilePath("fs/cgroup"), c.maxDepth, c.paths)
+	if err != nil {
+		return fmt.Errorf("couldn't read cgroups: %s", err)
+	}
+	for cgroup, values := range cgroups {
+		for name, v := range values {
+			ch <- c.descs[name].mustNewConstMetric(v, cgroup)
+		}
+	}
+	return nil
+}
+
+// readCgroups returns the metric values by cgroup path of the cgroups up to
+// maxDepth below root whose path matches paths. It reads the unified
+// hierarchy if mounted at root and the v1 cpuacct, cpu and memory
+// controllers otherwise.
+func readCgroups(root string, maxDepth int, paths *regexp.Regexp) (map[string]map[string]float64, error) {
+	files := cgroupV1Files
+	if _, err := os.Stat(path.Join(root, "cgroup.controllers")); err == nil {
+		files = cgroupV2Files
+	}
+	byController := map[string][]cgroupFile{}
+	for _, f := range files {
+		byController[f.controller] = append(byController[f.controller], f)
+	}
+
+	cgroups := map[string]map[string]float64{}
+	for controller, files := range byController {
+		// v1 controllers mounted together, like cpu,cpuacct, are symlinked.
+		dir, err := filepath.EvalSymlinks(path.Join(root, controller))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				// Cgroups come and go while walking them.
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			cgroup, depth := "/", 0
+			if rel != "." {
+				cgroup, depth = "/"+rel, strings.Count(rel, "/")+1
+			}
+			if depth > maxDepth {
+				return filepath.SkipDir
+			}
+			if !paths.MatchString(cgroup) {
+				return nil
+			}
+			values, err := readCgroupFiles(p, files)
+			if err != nil {
+				return fmt.Errorf("%s: %s", cgroup, err)
+			}
+			if len(values) == 0 {
+				return nil
+			}
+			if cgroups[cgroup] == nil {
+				cgroups[cgroup] = map[string]float64{}
+			}
+			for name, v := range values {
+				cgroups[cgroup][name] = v
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cgroups, nil
+}
+
+// readCgroupFiles reads the files of a cgroup directory, skipping missing
+// files and unlimited limits.
+func readCgroupFiles(dir string, files []cgroupFile) (map[string]float64, error) {
+	values := map[string]float64{}
+	parsed := map[string]map[string]uint64{}
+	for _, f := range files {
+		content, ok := parsed[f.file]
+		if !ok {
+			var err error
+			content, err = readCgroupFile(path.Join(dir, f.file))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+			parsed[f.file] = content
+		}
+		v, ok := content[f.key]
+		if !ok || (f.controller == "memory" && v >= cgroupV1Unlimited) {
+			continue
+		}
+		values[f.metric] = float64(v) / f.divisor
+	}
+	return values, nil
+}
+
+// readCgroupFile reads a flat keyed file or a single value file, whose
+// value is returned with an empty key. Values of "max" are left out.
+func readCgroupFile(file string) (map[string]uint64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	content := map[string]uint64{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		var key, value string
+		switch len(fields) {
+		case 1:
+			value = fields[0]
+		case 2:
+			key, value = fields[0], fields[1]
+		default:
+			return nil, fmt.Errorf("invalid line in %s: %q", file, scanner.Text())
+		}
+		if value == "max" {
+			continue
+		}
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in %s: %s", file, err)
+		}
+		content[key] = v
+	}
+	return content, scanner.Err()
+}
@@ -0,0 +1,55 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestReadCgroupsV1(t *testing.T) {
+	cgroups, err := readCgroups("fixtures/cgroup_v1", 2, regexp.MustCompile("^(?:/docker.*)$"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cgroups["/"]; ok {
+		t.Error("want root cgroup filtered out")
+	}
+	if want, got := 2, len(cgroups); want != got {
+		t.Fatalf("want %d cgroups, got %v", want, cgroups)
+	}
+	if _, ok := cgroups["/docker"]["memory_max_bytes"]; ok {
+		t.Error("want unlimited memory limit left out")
+	}
+	for name, want := range map[string]float64{
+		"cpu_usage_seconds_total":     1.5,
+		"cpu_periods_total":           500,
+		"cpu_throttled_periods_total": 20,
+		"cpu_throttled_seconds_total": 2.5,
+		"memory_usage_bytes":          104857600,
+		"memory_max_bytes":            268435456,
+	} {
+		if got := cgroups["/docker/abc"][name]; want != got {
+			t.Errorf("want %s %f, got %f", name, want, got)
+		}
+	}
+
+	cgroups, err = readCgroups("fixtures/cgroup_v1", 0, regexp.MustCompile(".+"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(cgroups); want != got {
+		t.Errorf("want only the root cgroup at depth 0, got %v", cgroups)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noclienttraffic
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	clientTrafficSubsystem = "clienttraffic"
+
+	// Client all traffic is accounted to once the cap is reached.
+	clientTrafficOther = "other"
+)
+
+var (
+	clientTrafficMaxClients = flag.Int("collector.clienttraffic.max-clients", 256, "Maximum number of clients to expose traffic for, traffic of further clients is accounted to client=\"other\".")
+	clientTrafficExpire     = flag.Int("collector.clienttraffic.expire-scrapes", 10, "Number of scrapes without connections after which a client is no longer exposed, freeing its place for new clients.")
+)
+
+// conntrackFlow holds the accounting of a single conntrack entry. The
+// original direction is sent by the client, the reply direction is received.
+type conntrackFlow struct {
+	client             string
+	txPackets, txBytes uint64
+	rxPackets, rxBytes uint64
+}
+
+type clientTraffic struct {
+	mac                string
+	txPackets, txBytes float64
+	rxPackets, rxBytes float64
+	// idle is the number of scrapes since the client last had a connection.
+	idle int
+}
+
+type clientTrafficCollector struct {
+	txPackets, txBytes typedDesc
+	rxPackets, rxBytes typedDesc
+
+	mtx     sync.Mutex
+	flows   map[string]conntrackFlow
+	clients map[string]*clientTraffic
+}
+
+func init() {
+	Factories["clienttraffic"] = NewClientTrafficCollector
+}
+
+// NewClientTrafficCollector returns a new Collector exposing traffic per
+// client based on conntrack accounting.
+func NewClientTrafficCollector() (Collector, error) {
+	labels := []string{"client", "mac"}
+	return &clientTrafficCollector{
+		txPackets: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, clientTrafficSubsystem, "transmit_packets_total"),
+			"Packets sent by the client in connections it initiated.",
+			labels, nil,
+		), prometheus.CounterValue},
+		txBytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, clientTrafficSubsystem, "transmit_bytes_total"),
+			"Bytes sent by the client in connections it initiated.",
+			labels, nil,
+		), prometheus.CounterValue},
+		rxPackets: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, clientTrafficSubsystem, "receive_packets_total"),
+			"Packets received by the client in connections it initiated.",
+			labels, nil,
+		), prometheus.CounterValue},
+		rxBytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, clientTrafficSubsystem, "receive_bytes_total"),
+			"Bytes received by the client in connections it initiated.",
+			labels, nil,
+		), prometheus.CounterValue},
+		flows:   map[string]conntrackFlow{},
+		clients: map[string]*clientTraffic{},
+	}, nil
+}
+
+func (c *clientTrafficCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	acct, err := readUintFromFile(procFilePath("sys/net/netfilter/nf_conntrack_acct"))
+	if err != nil {
+		return fmt.Errorf("couldn't get conntrack accounting state: %s", err)
+	}
+	if acct != 1 {
+		return fmt.Errorf("conntrack accounting is disabled, set net.netfilter.nf_conntrack_acct=1")
+	}
+
+	file, err := os.Open(procFilePath("net/nf_conntrack"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	flows, err := parseConntrackFlows(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse conntrack table: %s", err)
+	}
+
+	// Resolving MAC addresses is best effort, IPv6 neighbors are not
+	// listed in the ARP table.
+	macs := map[string]string{}
+	if arp, err := os.Open(procFilePath("net/arp")); err == nil {
+		macs, err = parseARPTable(arp)
+		arp.Close()
+		if err != nil {
+			return fmt.Errorf("couldn't parse ARP table: %s", err)
+		}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.account(flows, macs)
+	for client, t := range c.clients {
+		ch <- c.txPackets.mustNewConstMetric(t.txPackets, client, t.mac)
+		ch <- c.txBytes.mustNewConstMetric(t.txBytes, client, t.mac)
+		ch <- c.rxPackets.mustNewConstMetric(t.rxPackets, client, t.mac)
+		ch <- c.rxBytes.mustNewConstMetric(t.rxBytes, client, t.mac)
+	}
+	return nil
+}
+
+// account adds the traffic since the last scrape to the client totals.
+// Counters of a connection start at zero, so traffic of connections that
+// ended between two scrapes after the last one saw them is lost. Clients
+// without connections for -collector.clienttraffic.expire-scrapes scrapes
+// are dropped, their counters start over if they come back.
+func (c *clientTrafficCollector) account(flows map[string]conntrackFlow, macs map[string]string) {
+	for _, t := range c.clients {
+		t.idle++
+	}
+	for key, f := range flows {
+		client, mac := f.client, macs[f.client]
+		if _, ok := c.clients[client]; !ok && len(c.clients) >= *clientTrafficMaxClients {
+			client, mac = clientTrafficOther, ""
+		}
+		t, ok := c.clients[client]
+		if !ok {
+			t = &clientTraffic{mac: mac}
+			c.clients[client] = t
+		}
+		t.idle = 0
+		last := c.flows[key]
+		t.txPackets += flowDelta(last.txPackets, f.txPackets)
+		t.txBytes += flowDelta(last.txBytes, f.txBytes)
+		t.rxPackets += flowDelta(last.rxPackets, f.rxPackets)
+		t.rxBytes += flowDelta(last.rxBytes, f.rxBytes)
+	}
+	for client, t := range c.clients {
+		if t.idle >= *clientTrafficExpire {
+			delete(c.clients, client)
+		}
+	}
+	c.flows = flows
+}
+
+func flowDelta(last, cur uint64) float64 {
+	if cur < last {
+		// The tuple got reused by a new connection.
+		return float64(cur)
+	}
+	return float64(cur - last)
+}
+
+// parseConntrackFlows parses /proc/net/nf_conntrack, keying the flows by
+// their original tuple.
+func parseConntrackFlows(r io.Reader) (map[string]conntrackFlow, error) {
+	flows := map[string]conntrackFlow{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		var (
+			f   conntrackFlow
+			key = []string{fields[0], fields[2]}
+			dir = -1
+		)
+		for _, field := range fields[3:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			switch kv[0] {
+			case "src":
+				dir++
+				if dir == 0 {
+					f.client = kv[1]
+				}
+			case "packets", "bytes":
+				v, err := strconv.ParseUint(kv[1], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s in line: %s", kv[0], scanner.Text())
+				}
+				switch {
+				case dir == 0 && kv[0] == "packets":
+					f.txPackets = v
+				case dir == 0:
+					f.txBytes = v
+				case kv[0] == "packets":
+					f.rxPackets = v
+				default:
+					f.rxBytes = v
+				}
+				continue
+			}
+			if dir == 0 || kv[0] == "zone" {
+				key = append(key, field)
+			}
+		}
+		if f.client == "" {
+			return nil, fmt.Errorf("no source address in line: %s", scanner.Text())
+		}
+		flows[strings.Join(key, " ")] = f
+	}
+	return flows, scanner.Err()
+}
+
+// parseARPTable parses /proc/net/arp into a map of IP to MAC address,
+// skipping incomplete entries.
+func parseARPTable(r io.Reader) (map[string]string, error) {
+	macs := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Scan() // Skip header.
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		// ATF_COM is set once the entry is resolved.
+		flags, err := strconv.ParseUint(fields[2], 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags in line: %s", scanner.Text())
+		}
+		if flags&0x2 == 0 {
+			continue
+		}
+		macs[fields[0]] = fields[3]
+	}
+	return macs, scanner.Err()
+}
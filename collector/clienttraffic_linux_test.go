@@ -0,0 +1,92 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestClientTraffic(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/nf_conntrack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	flows, err := parseConntrackFlows(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 5, len(flows); want != got {
+		t.Fatalf("want %d flows, got %d", want, got)
+	}
+
+	arp, err := os.Open("fixtures/proc/net/arp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer arp.Close()
+	macs, err := parseARPTable(arp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(macs); want != got {
+		t.Fatalf("want %d ARP entries, got %d", want, got)
+	}
+
+	c := &clientTrafficCollector{flows: map[string]conntrackFlow{}, clients: map[string]*clientTraffic{}}
+	c.account(flows, macs)
+
+	want := map[string]clientTraffic{
+		"192.168.1.10": {mac: "aa:bb:cc:00:00:10", txPackets: 121, txBytes: 9060, rxPackets: 301, rxBytes: 420120},
+		"192.168.1.20": {mac: "aa:bb:cc:00:00:20", txPackets: 53, txBytes: 5252, rxPackets: 43, rxBytes: 6252},
+		"2001:db8::10": {txPackets: 10, txBytes: 1000, rxPackets: 20, rxBytes: 30000},
+	}
+	if want, got := len(want), len(c.clients); want != got {
+		t.Fatalf("want %d clients, got %d", want, got)
+	}
+	for client, w := range want {
+		if got := c.clients[client]; got == nil || *got != w {
+			t.Errorf("want %s %+v, got %+v", client, w, got)
+		}
+	}
+
+	// Only the growth of a flow is accounted on the next scrape.
+	next := map[string]conntrackFlow{}
+	for key, f := range flows {
+		if f.client == "2001:db8::10" {
+			f.rxBytes += 500
+		}
+		next[key] = f
+	}
+	c.account(next, macs)
+	if want, got := 30500.0, c.clients["2001:db8::10"].rxBytes; want != got {
+		t.Errorf("want %f received bytes, got %f", want, got)
+	}
+	if want, got := 420120.0, c.clients["192.168.1.10"].rxBytes; want != got {
+		t.Errorf("want %f received bytes, got %f", want, got)
+	}
+
+	// Clients without connections are dropped after expire-scrapes scrapes.
+	for i := 1; i < *clientTrafficExpire; i++ {
+		c.account(map[string]conntrackFlow{}, macs)
+	}
+	if want, got := 3, len(c.clients); want != got {
+		t.Fatalf("want %d clients, got %d", want, got)
+	}
+	c.account(map[string]conntrackFlow{}, macs)
+	if want, got := 0, len(c.clients); want != got {
+		t.Fatalf("want %d clients, got %d", want, got)
+	}
+}
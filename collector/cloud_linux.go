@@ -0,0 +1,276 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocloud
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const cloudSubsystem = "cloud"
+
+var (
+	cloudTimeout = flag.Duration("collector.cloud.timeout", 2*time.Second, "Timeout of requests to the cloud metadata service.")
+
+	// Base URL of the metadata services, overridden in tests.
+	cloudMetadataURL = "http://169.254.169.254"
+	cloudGCEURL      = "http://metadata.google.internal"
+)
+
+type cloudInstance struct {
+	provider, id, instanceType, region, zone string
+}
+
+type cloudCollector struct {
+	info   typedDesc
+	client *http.Client
+
+	mtx      sync.Mutex
+	instance *cloudInstance
+}
+
+func init() {
+	Factories["cloud"] = NewCloudCollector
+}
+
+// NewCloudCollector returns a new Collector exposing the instance metadata
+// of the cloud provider. The metadata is queried once, failed queries are
+// retried on scrape.
+func NewCloudCollector() (Collector, error) {
+	c := &cloudCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cloudSubsystem, "instance_info"),
+			"Instance metadata of the cloud provider, value is always 1.",
+			[]string{"provider", "instance_id", "instance_type", "region", "availability_zone"}, nil,
+		), prometheus.GaugeValue},
+		client: &http.Client{Timeout: *cloudTimeout},
+	}
+	if err := c.fetch(); err != nil {
+		log.Errorf("Couldn't get cloud instance metadata: %s", err)
+	}
+	return c, nil
+}
+
+func (c *cloudCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	if err := c.fetch(); err != nil {
+		return fmt.Errorf("couldn't get cloud instance metadata: %s", err)
+	}
+	i := c.instance
+	ch <- c.info.mustNewConstMetric(1, i.provider, i.id, i.instanceType, i.region, i.zone)
+	return nil
+}
+
+// fetch queries the metadata service unless that already succeeded.
+func (c *cloudCollector) fetch() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.instance != nil {
+		return nil
+	}
+	provider, err := detectCloudProvider(sysFilePath("class/dmi/id"))
+	if err != nil {
+		return err
+	}
+	var instance *cloudInstance
+	switch provider {
+	case "ec2":
+		instance, err = c.fetchEC2()
+	case "gce":
+		instance, err = c.fetchGCE()
+	case "azure":
+		instance, err = c.fetchAzure()
+	case "openstack":
+		instance, err = c.fetchOpenStack()
+	}
+	if err != nil {
+		return err
+	}
+	c.instance = instance
+	return nil
+}
+
+// detectCloudProvider detects the provider from the DMI system vendor and
+// product name.
+func detectCloudProvider(dmiPath string) (string, error) {
+	vendor, _ := readStringFromFile(path.Join(dmiPath, "sys_vendor"))
+	product, _ := readStringFromFile(path.Join(dmiPath, "product_name"))
+	bios, _ := readStringFromFile(path.Join(dmiPath, "bios_vendor"))
+	switch {
+	case vendor == "Amazon EC2" || strings.Contains(strings.ToLower(bios), "amazon"):
+		return "ec2", nil
+	case vendor == "Google" || product == "Google Compute Engine":
+		return "gce", nil
+	case vendor == "Microsoft Corporation" && product == "Virtual Machine":
+		return "azure", nil
+	case strings.HasPrefix(product, "OpenStack"):
+		return "openstack", nil
+	}
+	return "", fmt.Errorf("no cloud provider detected from vendor %q and product %q", vendor, product)
+}
+
+func (c *cloudCollector) get(url string, header map[string]string) (io.ReadCloser, error) {
+	return c.request("GET", url, header)
+}
+
+func (c *cloudCollector) request(method, url string, header map[string]string) (io.ReadCloser, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s returned %s", method, url, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// fetchEC2 uses an IMDSv2 session token, which is required on instances that
+// disabled IMDSv1.
+func (c *cloudCollector) fetchEC2() (*cloudInstance, error) {
+	body, err := c.request("PUT", cloudMetadataURL+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, err
+	}
+	token, err := ioutil.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body, err = c.get(cloudMetadataURL+"/latest/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": string(token)})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return parseEC2Identity(body)
+}
+
+func (c *cloudCollector) fetchGCE() (*cloudInstance, error) {
+	body, err := c.get(cloudGCEURL+"/computeMetadata/v1/instance/?recursive=true",
+		map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return parseGCEInstance(body)
+}
+
+func (c *cloudCollector) fetchAzure() (*cloudInstance, error) {
+	body, err := c.get(cloudMetadataURL+"/metadata/instance/compute?api-version=2021-02-01",
+		map[string]string{"Metadata": "true"})
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return parseAzureCompute(body)
+}
+
+func (c *cloudCollector) fetchOpenStack() (*cloudInstance, error) {
+	body, err := c.get(cloudMetadataURL+"/openstack/latest/meta_data.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	instance, err := parseOpenStackMetadata(body)
+	if err != nil {
+		return nil, err
+	}
+	// The flavor is only available from the EC2 compatible API.
+	if body, err := c.get(cloudMetadataURL+"/latest/meta-data/instance-type", nil); err == nil {
+		flavor, err := ioutil.ReadAll(body)
+		body.Close()
+		if err == nil {
+			instance.instanceType = strings.TrimSpace(string(flavor))
+		}
+	}
+	return instance, nil
+}
+
+func parseEC2Identity(r io.Reader) (*cloudInstance, error) {
+	var doc struct {
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &cloudInstance{"ec2", doc.InstanceID, doc.InstanceType, doc.Region, doc.AvailabilityZone}, nil
+}
+
+// parseGCEInstance parses the recursive instance metadata, machine type and
+// zone are given as projects/<project>/<kind>/<name>.
+func parseGCEInstance(r io.Reader) (*cloudInstance, error) {
+	var doc struct {
+		ID          json.Number `json:"id"`
+		MachineType string      `json:"machineType"`
+		Zone        string      `json:"zone"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	zone := path.Base(doc.Zone)
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+	return &cloudInstance{"gce", doc.ID.String(), path.Base(doc.MachineType), region, zone}, nil
+}
+
+func parseAzureCompute(r io.Reader) (*cloudInstance, error) {
+	var doc struct {
+		VMID     string `json:"vmId"`
+		VMSize   string `json:"vmSize"`
+		Location string `json:"location"`
+		Zone     string `json:"zone"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &cloudInstance{"azure", doc.VMID, doc.VMSize, doc.Location, doc.Zone}, nil
+}
+
+func parseOpenStackMetadata(r io.Reader) (*cloudInstance, error) {
+	var doc struct {
+		UUID             string `json:"uuid"`
+		AvailabilityZone string `json:"availability_zone"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &cloudInstance{provider: "openstack", id: doc.UUID, zone: doc.AvailabilityZone}, nil
+}
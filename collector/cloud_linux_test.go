@@ -0,0 +1,65 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestCloudEC2(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("token"))
+		case r.URL.Path == "/latest/dynamic/instance-identity/document" && r.Header.Get("X-aws-ec2-metadata-token") == "token":
+			http.ServeFile(w, r, "fixtures/cloud/ec2_identity.json")
+		default:
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	defer func(url string) { cloudMetadataURL = url }(cloudMetadataURL)
+	cloudMetadataURL = server.URL
+
+	c := &cloudCollector{client: http.DefaultClient}
+	instance, err := c.fetchEC2()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cloudInstance{"ec2", "i-0a1b2c3d4e5f67890", "m4.large", "eu-central-1", "eu-central-1a"}
+	if want != *instance {
+		t.Errorf("want %+v, got %+v", want, *instance)
+	}
+}
+
+func TestCloudGCE(t *testing.T) {
+	file, err := os.Open("fixtures/cloud/gce_instance.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	instance, err := parseGCEInstance(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cloudInstance{"gce", "4520031799277581759", "n1-standard-2", "europe-west1", "europe-west1-b"}
+	if want != *instance {
+		t.Errorf("want %+v, got %+v", want, *instance)
+	}
+}
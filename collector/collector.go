@@ -15,6 +15,9 @@
 package collector
 
 import (
+	"encoding/binary"
+	"unsafe"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 )
@@ -23,6 +26,19 @@ const Namespace = "node"
 
 var Factories = make(map[string]func() (Collector, error))
 
+// nativeEndian is the byte order of the host, used to decode the structures
+// returned by the kernel.
+var nativeEndian binary.ByteOrder
+
+func init() {
+	i := uint16(1)
+	if *(*byte)(unsafe.Pointer(&i)) == 1 {
+		nativeEndian = binary.LittleEndian
+	} else {
+		nativeEndian = binary.BigEndian
+	}
+}
+
 func warnDeprecated(collector string) {
 	log.Warnf("The %s collector is deprecated and will be removed in the future!", collector)
 }
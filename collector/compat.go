@@ -0,0 +1,176 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+)
+
+var compatibilityLevel = flag.String(
+	"metrics.compatibility-level", "0.13",
+	"Release whose metric names scrapes rely on. Metrics renamed in later releases are also exposed under their old name.")
+
+// metricRename is a metric renamed in a release. The old name is exposed
+// alongside the new one until the default compatibility level moves past
+// the release.
+type metricRename struct {
+	oldName, newName string
+	release          string
+}
+
+// metricRenames are the renamed metrics. Add an entry when renaming a
+// metric, instead of exposing both names in the collector.
+var metricRenames = []metricRename{
+	{"node_textfile_mtime", "node_textfile_mtime_seconds", "0.14"},
+}
+
+var descNameRE = regexp.MustCompile(`^Desc{fqName: "([^"]*)"`)
+
+// parseRelease parses a release like 0.13 or 0.13.0 into its numbers.
+func parseRelease(s string) ([]int, error) {
+	var release []int
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid release %q", s)
+		}
+		release = append(release, n)
+	}
+	return release, nil
+}
+
+// releaseBefore reports whether release a is older than release b, missing
+// trailing numbers count as 0.
+func releaseBefore(a, b []int) bool {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+// metricAliases returns the old names of the metrics renamed after the
+// compatibility level, by new name.
+func metricAliases(level string, renames []metricRename) (map[string]string, error) {
+	current, err := parseRelease(level)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse metrics.compatibility-level: %s", err)
+	}
+	aliases := map[string]string{}
+	for _, r := range renames {
+		release, err := parseRelease(r.release)
+		if err != nil {
+			return nil, err
+		}
+		if releaseBefore(current, release) {
+			aliases[r.newName] = r.oldName
+		}
+	}
+	return aliases, nil
+}
+
+// NewCompatibilityCollector wraps c to expose the metrics renamed after the
+// release given with -metrics.compatibility-level under their old name too.
+// It returns c itself if there are no such metrics.
+func NewCompatibilityCollector(c Collector) (Collector, error) {
+	aliases, err := metricAliases(*compatibilityLevel, metricRenames)
+	if err != nil {
+		return nil, err
+	}
+	if len(aliases) == 0 {
+		return c, nil
+	}
+	return &compatibilityCollector{Collector: c, aliases: aliases}, nil
+}
+
+type compatibilityCollector struct {
+	Collector
+	aliases map[string]string
+}
+
+func (c *compatibilityCollector) Update(ch chan<- prometheus.Metric) error {
+	metrics := make(chan prometheus.Metric)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for m := range metrics {
+			ch <- m
+			// The client library doesn't expose the name of a Desc.
+			match := descNameRE.FindStringSubmatch(m.Desc().String())
+			if match == nil {
+				continue
+			}
+			oldName, ok := c.aliases[match[1]]
+			if !ok {
+				continue
+			}
+			alias, err := newAliasMetric(oldName, match[1], m)
+			if err != nil {
+				log.Debugf("Couldn't expose %s as %s: %s", match[1], oldName, err)
+				continue
+			}
+			ch <- alias
+		}
+	}()
+	err := c.Collector.Update(metrics)
+	close(metrics)
+	<-done
+	return err
+}
+
+// newAliasMetric returns a copy of m named name.
+func newAliasMetric(name, newName string, m prometheus.Metric) (prometheus.Metric, error) {
+	var pb dto.Metric
+	if err := m.Write(&pb); err != nil {
+		return nil, err
+	}
+	var (
+		labelNames  = make([]string, 0, len(pb.Label))
+		labelValues = make([]string, 0, len(pb.Label))
+		valueType   prometheus.ValueType
+		value       float64
+	)
+	for _, l := range pb.Label {
+		labelNames = append(labelNames, l.GetName())
+		labelValues = append(labelValues, l.GetValue())
+	}
+	switch {
+	case pb.Gauge != nil:
+		valueType, value = prometheus.GaugeValue, pb.Gauge.GetValue()
+	case pb.Counter != nil:
+		valueType, value = prometheus.CounterValue, pb.Counter.GetValue()
+	case pb.Untyped != nil:
+		valueType, value = prometheus.UntypedValue, pb.Untyped.GetValue()
+	default:
+		return nil, fmt.Errorf("only gauges, counters and untyped metrics can be renamed")
+	}
+	desc := prometheus.NewDesc(name, fmt.Sprintf("Deprecated name of %s.", newName), labelNames, nil)
+	return prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+type renameTestCollector struct{}
+
+func (renameTestCollector) Update(ch chan<- prometheus.Metric) error {
+	ch <- prometheus.MustNewConstMetric(
+		prometheus.NewDesc("node_cpu_seconds_total", "Seconds the CPU spent in each mode.", []string{"cpu", "mode"}, nil),
+		prometheus.CounterValue, 42, "0", "idle",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		prometheus.NewDesc("node_load1", "1m load average.", nil, nil),
+		prometheus.GaugeValue, 0.5,
+	)
+	return nil
+}
+
+func TestMetricAliases(t *testing.T) {
+	renames := []metricRename{
+		{"node_cpu", "node_cpu_seconds_total", "0.14"},
+		{"node_boot_time", "node_boot_time_seconds", "0.15.1"},
+	}
+	for _, c := range []struct {
+		level   string
+		aliases int
+	}{
+		{"0.13", 2},
+		{"0.14.0", 1},
+		{"0.15", 1},
+		{"0.15.1", 0},
+		{"1.0", 0},
+	} {
+		aliases, err := metricAliases(c.level, renames)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, got := c.aliases, len(aliases); want != got {
+			t.Errorf("level %s: want %d aliases, got %d", c.level, want, got)
+		}
+	}
+	if _, err := metricAliases("latest", renames); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestCompatibilityCollector(t *testing.T) {
+	defer func(renames []metricRename, level string) {
+		metricRenames, *compatibilityLevel = renames, level
+	}(metricRenames, *compatibilityLevel)
+	metricRenames = []metricRename{{"node_cpu", "node_cpu_seconds_total", "0.14"}}
+
+	collect := func(level string) map[string]*dto.Metric {
+		*compatibilityLevel = level
+		c, err := NewCompatibilityCollector(renameTestCollector{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ch := make(chan prometheus.Metric)
+		go func() {
+			if err := c.Update(ch); err != nil {
+				t.Error(err)
+			}
+			close(ch)
+		}()
+		metrics := map[string]*dto.Metric{}
+		for m := range ch {
+			pb := &dto.Metric{}
+			if err := m.Write(pb); err != nil {
+				t.Fatal(err)
+			}
+			metrics[descNameRE.FindStringSubmatch(m.Desc().String())[1]] = pb
+		}
+		return metrics
+	}
+
+	metrics := collect("0.13")
+	if want, got := 3, len(metrics); want != got {
+		t.Fatalf("want %d metrics, got %d", want, got)
+	}
+	alias, ok := metrics["node_cpu"]
+	if !ok {
+		t.Fatal("want node_cpu alias")
+	}
+	if want, got := 42.0, alias.GetCounter().GetValue(); want != got {
+		t.Errorf("want alias value %f, got %f", want, got)
+	}
+	if want, got := 2, len(alias.GetLabel()); want != got {
+		t.Errorf("want %d alias labels, got %d", want, got)
+	}
+
+	if want, got := 2, len(collect("0.14")); want != got {
+		t.Errorf("want %d metrics without aliases, got %d", want, got)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocontainer
+
+package collector
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const containerSubsystem = "container"
+
+var (
+	// Files container runtimes create in the root of the container.
+	containerMarkers = map[string]string{
+		"/.dockerenv":        "docker",
+		"/run/.containerenv": "podman",
+	}
+
+	// Substrings of cgroup paths by runtime, in order of precedence.
+	containerCgroupRuntimes = []struct{ pattern, runtime string }{
+		{"libpod", "podman"},
+		{"docker", "docker"},
+		{"containerd", "containerd"},
+		{"crio", "cri-o"},
+		{"lxc", "lxc"},
+		{"machine.slice", "systemd-nspawn"},
+	}
+
+	containerNamespaces = []string{"pid", "net", "mnt"}
+)
+
+type containerCollector struct {
+	info, hostNamespace typedDesc
+}
+
+func init() {
+	Factories["container"] = NewContainerCollector
+}
+
+// NewContainerCollector returns a new Collector exposing whether the
+// exporter runs in a container and which host namespaces it can see.
+func NewContainerCollector() (Collector, error) {
+	return &containerCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, containerSubsystem, "info"),
+			"Container runtime and cgroup parent of the exporter if it runs in a container, value is always 1.",
+			[]string{"runtime", "cgroup_parent"}, nil,
+		), prometheus.GaugeValue},
+		hostNamespace: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, containerSubsystem, "host_namespace"),
+			"Whether the exporter sees the namespace of the host through the proc filesystem.",
+			[]string{"namespace"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *containerCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	// The exporter's own context is read from /proc, not the possibly
+	// remapped proc filesystem of the host.
+	file, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	runtime, parent, err := parseContainerCgroup(file)
+	if err != nil {
+		return err
+	}
+	if r := containerMarkerRuntime(); r != "" {
+		runtime = r
+	}
+	if runtime != "" {
+		ch <- c.info.mustNewConstMetric(1, runtime, parent)
+	}
+
+	for ns, host := range readHostNamespaces(procFilePath("")) {
+		v := 0.0
+		if host {
+			v = 1.0
+		}
+		ch <- c.hostNamespace.mustNewConstMetric(v, ns)
+	}
+	return nil
+}
+
+// containerMarkerRuntime detects the runtime from the container variable
+// set by LXC, podman and systemd-nspawn or files created by runtimes.
+func containerMarkerRuntime() string {
+	if r := os.Getenv("container"); r != "" {
+		return r
+	}
+	for file, r := range containerMarkers {
+		if _, err := os.Stat(file); err == nil {
+			return r
+		}
+	}
+	return ""
+}
+
+// parseContainerCgroup detects the runtime from the cgroup paths of a
+// /proc/<pid>/cgroup file and returns the parent of the unified or first
+// cgroup path.
+func parseContainerCgroup(r io.Reader) (runtime, parent string, err error) {
+	var cgroupPath string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" || cgroupPath == "" {
+			cgroupPath = parts[2]
+		}
+		if runtime != "" {
+			continue
+		}
+		for _, cr := range containerCgroupRuntimes {
+			if strings.Contains(parts[2], cr.pattern) {
+				runtime = cr.runtime
+				break
+			}
+		}
+	}
+	if cgroupPath != "" {
+		parent = path.Dir(cgroupPath)
+	}
+	return runtime, parent, scanner.Err()
+}
+
+// readHostNamespaces checks whether the namespaces of the exporter are
+// those of the host. Only the init PID namespace has kthreadd as PID 2, the
+// other namespaces are compared to the ones of PID 1 of the proc
+// filesystem, which requires it to show the host PID namespace.
+func readHostNamespaces(procPath string) map[string]bool {
+	namespaces := map[string]bool{}
+	comm, err := readStringFromFile(path.Join(procPath, "2/comm"))
+	hostPID := err == nil && comm == "kthreadd"
+	for _, ns := range containerNamespaces {
+		if ns == "pid" {
+			namespaces[ns] = hostPID
+			continue
+		}
+		own, err := os.Readlink(path.Join("/proc/self/ns", ns))
+		if err != nil {
+			continue
+		}
+		host, err := os.Readlink(path.Join(procPath, "1/ns", ns))
+		namespaces[ns] = hostPID && err == nil && own == host
+	}
+	return namespaces
+}
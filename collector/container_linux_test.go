@@ -0,0 +1,50 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestContainerCgroup(t *testing.T) {
+	file, err := os.Open("fixtures/container_cgroup.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	runtime, parent, err := parseContainerCgroup(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "containerd", runtime; want != got {
+		t.Errorf("want runtime %s, got %s", want, got)
+	}
+	if want, got := "/system.slice/containerd.service", parent; want != got {
+		t.Errorf("want cgroup parent %s, got %s", want, got)
+	}
+
+	runtime, parent, err = parseContainerCgroup(strings.NewReader("0::/user.slice/user-1000.slice/session-2.scope\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "", runtime; want != got {
+		t.Errorf("want no runtime, got %s", got)
+	}
+	if want, got := "/user.slice/user-1000.slice", parent; want != got {
+		t.Errorf("want cgroup parent %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpu
+
+package collector
+
+// kstatCPUTimes returns the time spent in each state per CPU from the
+// cpu:<id>:sys kstats of illumos, by CPU id.
+func kstatCPUTimes(r kstatReader) (map[int]cputime, error) {
+	stats, err := r.Kstats("cpu")
+	if err != nil {
+		return nil, err
+	}
+	cpus := map[int]cputime{}
+	for _, s := range stats {
+		if s.name != "sys" {
+			continue
+		}
+		cpus[s.instance] = cputime{
+			user: float64(s.values["cpu_nsec_user"]) / 1e9,
+			sys:  float64(s.values["cpu_nsec_kernel"]) / 1e9,
+			intr: float64(s.values["cpu_nsec_intr"]) / 1e9,
+			idle: float64(s.values["cpu_nsec_idle"]) / 1e9,
+		}
+	}
+	return cpus, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpu
+
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type statCollector struct {
+	cpu   typedDesc
+	kstat kstatReader
+}
+
+func init() {
+	Factories["cpu"] = NewStatCollector
+}
+
+// NewStatCollector returns a new Collector exposing CPU stats.
+func NewStatCollector() (Collector, error) {
+	return &statCollector{
+		cpu: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "seconds_total"),
+			"Seconds the CPU spent in each mode.",
+			[]string{"cpu", "mode"}, nil,
+		), prometheus.CounterValue},
+		kstat: newKstatReader(),
+	}, nil
+}
+
+// Update exposes the CPU times of the cpu:<id>:sys kstats.
+func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cpuTimes, err := kstatCPUTimes(c.kstat)
+	if err != nil {
+		return err
+	}
+	for cpu, t := range cpuTimes {
+		ch <- c.cpu.mustNewConstMetric(t.user, strconv.Itoa(cpu), "user")
+		ch <- c.cpu.mustNewConstMetric(t.sys, strconv.Itoa(cpu), "system")
+		ch <- c.cpu.mustNewConstMetric(t.intr, strconv.Itoa(cpu), "interrupt")
+		ch <- c.cpu.mustNewConstMetric(t.idle, strconv.Itoa(cpu), "idle")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpu
+
+package collector
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+)
+
+type cputime struct {
+	user float64
+	nice float64
+	sys  float64
+	intr float64
+	idle float64
+}
+
+// sysctlCPUTimes returns the time spent in each state per CPU from the
+// kern.cp_times sysctl of FreeBSD.
+func sysctlCPUTimes(s sysctlReader) ([]cputime, error) {
+	// CPUSTATES (number of CPUSTATES) is defined as 5U.
+	// Order: CP_USER | CP_NICE | CP_SYS | CP_INTR | CP_IDLE
+	const states = 5
+
+	// struct clockinfo from sys/time.h: hz, tick, spare, stathz and profhz.
+	clock, err := s.SysctlRaw("kern.clockrate")
+	if err != nil {
+		return nil, err
+	}
+	if len(clock) < 20 {
+		return nil, fmt.Errorf("sysctl kern.clockrate has %d bytes, expected 20", len(clock))
+	}
+	cpufreq := float64(int32(nativeEndian.Uint32(clock[12:])))
+	if cpufreq <= 0 {
+		cpufreq = float64(int32(nativeEndian.Uint32(clock[0:])))
+	}
+
+	// kern.cp_times provides hw.ncpu * CPUSTATES longs, each incremented
+	// at kern.clockrate.(stathz | hz). See sys/kern/kern_clock.c.
+	cpb, err := s.SysctlRaw("kern.cp_times")
+	if err != nil {
+		return nil, err
+	}
+	times := sysctlLongs(cpb)
+
+	cpus := make([]cputime, len(times)/states)
+	for i := range cpus {
+		t := times[i*states:]
+		cpus[i] = cputime{
+			user: float64(t[0]) / cpufreq,
+			nice: float64(t[1]) / cpufreq,
+			sys:  float64(t[2]) / cpufreq,
+			intr: float64(t[3]) / cpufreq,
+			idle: float64(t[4]) / cpufreq,
+		}
+	}
+	return cpus, nil
+}
+
+type cpuTopology struct {
+	pkg, core, thread int
+}
+
+// topologyGroup is a group of cpus sharing a cache or core in the
+// kern.sched.topology_spec sysctl of FreeBSD.
+type topologyGroup struct {
+	CPUs  string `xml:"cpu"`
+	Flags []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"flags>flag"`
+	Children []topologyGroup `xml:"children>group"`
+}
+
+func (g topologyGroup) smt() bool {
+	for _, f := range g.Flags {
+		if f.Name == "SMT" || f.Name == "THREAD" {
+			return true
+		}
+	}
+	return false
+}
+
+// sysctlCPUTopology returns the package, core and thread of each cpu from
+// the scheduler topology of FreeBSD. The children of the root group are
+// the packages unless they are already the threads of a single core.
+func sysctlCPUTopology(s sysctlReader) (map[int]cpuTopology, error) {
+	b, err := s.SysctlRaw("kern.sched.topology_spec")
+	if err != nil {
+		return nil, err
+	}
+	var spec struct {
+		Groups []topologyGroup `xml:"group"`
+	}
+	if err := xml.Unmarshal(bytes.TrimRight(b, "\x00"), &spec); err != nil {
+		return nil, fmt.Errorf("couldn't parse kern.sched.topology_spec: %s", err)
+	}
+	if len(spec.Groups) == 0 {
+		return nil, fmt.Errorf("no groups in kern.sched.topology_spec")
+	}
+
+	root := spec.Groups[0]
+	packages := []topologyGroup{root}
+	if len(root.Children) > 0 && !root.Children[0].smt() {
+		packages = root.Children
+	}
+	topology := map[int]cpuTopology{}
+	core := 0
+	var walk func(pkg int, g topologyGroup) error
+	walk = func(pkg int, g topologyGroup) error {
+		if len(g.Children) > 0 && !g.smt() {
+			for _, child := range g.Children {
+				if err := walk(pkg, child); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		cpus, err := parseCPUList(g.CPUs)
+		if err != nil {
+			return err
+		}
+		for i, cpu := range cpus {
+			if g.smt() {
+				topology[cpu] = cpuTopology{pkg: pkg, core: core, thread: i}
+				continue
+			}
+			topology[cpu] = cpuTopology{pkg: pkg, core: core}
+			core++
+		}
+		if g.smt() {
+			core++
+		}
+		return nil
+	}
+	for pkg, g := range packages {
+		if err := walk(pkg, g); err != nil {
+			return nil, err
+		}
+	}
+	return topology, nil
+}
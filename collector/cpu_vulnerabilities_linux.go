@@ -0,0 +1,106 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpu_vulnerabilities
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const cpuVulnerabilitiesSubsystem = "cpu_vulnerabilities"
+
+type cpuVulnerability struct {
+	codename, state, mitigation string
+}
+
+type cpuVulnerabilitiesCollector struct {
+	info typedDesc
+}
+
+func init() {
+	Factories["cpu_vulnerabilities"] = NewCPUVulnerabilitiesCollector
+}
+
+// NewCPUVulnerabilitiesCollector returns a new Collector exposing the state
+// of CPU vulnerabilities and their mitigations.
+func NewCPUVulnerabilitiesCollector() (Collector, error) {
+	return &cpuVulnerabilitiesCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuVulnerabilitiesSubsystem, "info"),
+			"State and mitigation of the CPU vulnerability, value is always 1.",
+			[]string{"codename", "state", "mitigation"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *cpuVulnerabilitiesCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	vulns, err := readCPUVulnerabilities(sysFilePath("devices/system/cpu/vulnerabilities"))
+	if err != nil {
+		return err
+	}
+	for _, v := range vulns {
+		ch <- c.info.mustNewConstMetric(1, v.codename, v.state, v.mitigation)
+	}
+	return nil
+}
+
+func readCPUVulnerabilities(root string) ([]cpuVulnerability, error) {
+	files, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		// Kernels before 4.15.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var vulns []cpuVulnerability
+	for _, f := range files {
+		status, err := readStringFromFile(path.Join(root, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		v := parseCPUVulnerability(status)
+		v.codename = f.Name()
+		vulns = append(vulns, v)
+	}
+	return vulns, nil
+}
+
+// parseCPUVulnerability parses a status like "Not affected", "Vulnerable",
+// "Vulnerable: <details>" or "Mitigation: <mitigation>", see
+// Documentation/ABI/testing/sysfs-devices-system-cpu. Prefixes like "KVM: "
+// are kept in the mitigation.
+func parseCPUVulnerability(status string) cpuVulnerability {
+	var v cpuVulnerability
+	switch {
+	case status == "Not affected":
+		v.state = "not_affected"
+	case strings.HasPrefix(status, "Vulnerable"):
+		v.state = "vulnerable"
+		v.mitigation = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(status, "Vulnerable"), ":"))
+	case strings.Contains(status, "Mitigation: "):
+		v.state = "mitigation"
+		v.mitigation = strings.Replace(status, "Mitigation: ", "", 1)
+	default:
+		v.state = "unknown"
+		v.mitigation = status
+	}
+	return v
+}
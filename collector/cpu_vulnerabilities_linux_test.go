@@ -0,0 +1,41 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestCPUVulnerabilities(t *testing.T) {
+	vulns, err := readCPUVulnerabilities("fixtures/sys/devices/system/cpu/vulnerabilities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cpuVulnerability{
+		{"gather_data_sampling", "unknown", "Unknown: Dependent on hypervisor status"},
+		{"itlb_multihit", "mitigation", "KVM: Split huge pages"},
+		{"mds", "vulnerable", "Clear CPU buffers attempted, no microcode; SMT vulnerable"},
+		{"meltdown", "mitigation", "PTI"},
+		{"spectre_v1", "mitigation", "usercopy/swapgs barriers and __user pointer sanitization"},
+		{"srbds", "not_affected", ""},
+	}
+	if want, got := len(want), len(vulns); want != got {
+		t.Fatalf("want %d vulnerabilities, got %d", want, got)
+	}
+	for i, w := range want {
+		if got := vulns[i]; w != got {
+			t.Errorf("want %+v, got %+v", w, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build experimental_windows,!nocpu
+
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	systemProcessorPerformanceInformation = 8
+	// Size of SYSTEM_PROCESSOR_PERFORMANCE_INFORMATION: the idle, kernel,
+	// user, DPC and interrupt times in 100ns and the interrupt count.
+	processorPerformanceInformationSize = 48
+	// Processors of one processor group are returned at most.
+	maxGroupProcessors = 64
+)
+
+type statCollector struct {
+	cpu, interrupts typedDesc
+}
+
+func init() {
+	Factories["cpu"] = NewStatCollector
+}
+
+// NewStatCollector returns a new Collector exposing CPU stats.
+func NewStatCollector() (Collector, error) {
+	return &statCollector{
+		cpu: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "seconds_total"),
+			"Seconds the CPU spent in each mode.",
+			[]string{"cpu", "mode"}, nil,
+		), prometheus.CounterValue},
+		interrupts: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "interrupts_total"),
+			"Interrupts serviced by the CPU.",
+			[]string{"cpu"}, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+// Update exposes the CPU times from NtQuerySystemInformation.
+func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	buf := make([]byte, processorPerformanceInformationSize*maxGroupProcessors)
+	var size uint32
+	r, _, _ := procNtQuerySystemInformation.Call(systemProcessorPerformanceInformation,
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)), uintptr(unsafe.Pointer(&size)))
+	if r != 0 {
+		return fmt.Errorf("NtQuerySystemInformation failed with 0x%x", r)
+	}
+	for cpu := 0; (cpu+1)*processorPerformanceInformationSize <= int(size); cpu++ {
+		info := buf[cpu*processorPerformanceInformationSize:]
+		time := func(i int) float64 {
+			return float64(binary.LittleEndian.Uint64(info[i*8:])) / 1e7
+		}
+		idle, kernel, user, dpc, intr := time(0), time(1), time(2), time(3), time(4)
+		// The kernel time includes the idle, DPC and interrupt time.
+		ch <- c.cpu.mustNewConstMetric(user, strconv.Itoa(cpu), "user")
+		ch <- c.cpu.mustNewConstMetric(kernel-idle-dpc-intr, strconv.Itoa(cpu), "system")
+		ch <- c.cpu.mustNewConstMetric(dpc, strconv.Itoa(cpu), "dpc")
+		ch <- c.cpu.mustNewConstMetric(intr, strconv.Itoa(cpu), "interrupt")
+		ch <- c.cpu.mustNewConstMetric(idle, strconv.Itoa(cpu), "idle")
+		ch <- c.interrupts.mustNewConstMetric(float64(binary.LittleEndian.Uint32(info[40:])), strconv.Itoa(cpu))
+	}
+	return nil
+}
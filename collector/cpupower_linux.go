@@ -0,0 +1,362 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nocpupower
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	cpuPowerSubsystem = "cpupower"
+
+	// IA32_MPERF and IA32_APERF, counting at the base frequency and the
+	// actual frequency while the core is in C0.
+	msrMPERF = 0xe7
+	msrAPERF = 0xe8
+)
+
+var (
+	cpuPowerMSR     = flag.Bool("collector.cpupower.msr", false, "Expose the APERF and MPERF counters of x86 cores for the average frequency, requires the msr module.")
+	cpuPowerMSRPath = flag.String("collector.cpupower.msr-path", "/dev/cpu", "Directory of the MSR devices of the cores.")
+)
+
+type cpuIdleState struct {
+	name        string
+	time, usage float64
+}
+
+type cpuPowerCore struct {
+	cpu string
+	// Frequencies in Hz, nil without cpufreq.
+	cur, min, max, base *float64
+	idle                []cpuIdleState
+}
+
+// cpuFreqPolicy is a cpufreq policy, shared by the cpus it affects.
+type cpuFreqPolicy struct {
+	policy, driver, governor, cpus string
+	// Limits of the governor in Hz.
+	min, max float64
+}
+
+type raplLimit struct {
+	domain, constraint string
+	watts              float64
+}
+
+type cpuPowerCollector struct {
+	cur, min, max, base   typedDesc
+	idleTime, idleUsage   typedDesc
+	aperf, mperf          typedDesc
+	turbo, maxPerf, limit typedDesc
+	policyInfo            typedDesc
+	policyMin, policyMax  typedDesc
+	boost                 typedDesc
+}
+
+func init() {
+	Factories["cpupower"] = NewCPUPowerCollector
+}
+
+// NewCPUPowerCollector returns a new Collector exposing frequencies and
+// idle states of the cores and the turbo and power limits of the packages.
+func NewCPUPowerCollector() (Collector, error) {
+	labels := []string{"cpu"}
+	return &cpuPowerCollector{
+		cur: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "frequency_hertz"),
+			"Current frequency of the core as seen by cpufreq.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		min: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "frequency_min_hertz"),
+			"Minimum frequency of the core.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		max: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "frequency_max_hertz"),
+			"Maximum frequency of the core including turbo.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		base: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "frequency_base_hertz"),
+			"Base frequency of the core, which MPERF counts at.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		idleTime: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "cstate_time_seconds_total"),
+			"Time the core spent in the idle state.",
+			[]string{"cpu", "state"}, nil,
+		), prometheus.CounterValue},
+		idleUsage: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "cstate_entries_total"),
+			"Number of times the core entered the idle state.",
+			[]string{"cpu", "state"}, nil,
+		), prometheus.CounterValue},
+		aperf: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "aperf_cycles_total"),
+			"Cycles of the core at its actual frequency while not idle.",
+			labels, nil,
+		), prometheus.CounterValue},
+		mperf: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "mperf_cycles_total"),
+			"Cycles of the core at its base frequency while not idle.",
+			labels, nil,
+		), prometheus.CounterValue},
+		turbo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "turbo_enabled"),
+			"Whether intel_pstate allows turbo frequencies.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		maxPerf: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "max_performance_ratio"),
+			"Maximum performance intel_pstate allows relative to the maximum frequency.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		limit: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "power_limit_watts"),
+			"Power limit of the RAPL domain.",
+			[]string{"domain", "constraint"}, nil,
+		), prometheus.GaugeValue},
+		policyInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "policy_info"),
+			"Scaling driver and governor of the cpufreq policy and the cpus it affects, value is always 1.",
+			[]string{"policy", "driver", "governor", "cpus"}, nil,
+		), prometheus.GaugeValue},
+		policyMin: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "policy_frequency_min_hertz"),
+			"Minimum frequency the governor of the cpufreq policy may select.",
+			[]string{"policy"}, nil,
+		), prometheus.GaugeValue},
+		policyMax: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "policy_frequency_max_hertz"),
+			"Maximum frequency the governor of the cpufreq policy may select.",
+			[]string{"policy"}, nil,
+		), prometheus.GaugeValue},
+		boost: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, cpuPowerSubsystem, "boost_enabled"),
+			"Whether cpufreq allows boost frequencies, for drivers other than intel_pstate.",
+			nil, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *cpuPowerCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cpuPath := sysFilePath("devices/system/cpu")
+	cores, err := readCPUPowerCores(cpuPath)
+	if err != nil {
+		return err
+	}
+	for _, core := range cores {
+		for desc, v := range map[*typedDesc]*float64{
+			&c.cur:  core.cur,
+			&c.min:  core.min,
+			&c.max:  core.max,
+			&c.base: core.base,
+		} {
+			if v != nil {
+				ch <- desc.mustNewConstMetric(*v, core.cpu)
+			}
+		}
+		for _, s := range core.idle {
+			ch <- c.idleTime.mustNewConstMetric(s.time, core.cpu, s.name)
+			ch <- c.idleUsage.mustNewConstMetric(s.usage, core.cpu, s.name)
+		}
+		if *cpuPowerMSR {
+			msr := path.Join(*cpuPowerMSRPath, core.cpu, "msr")
+			aperf, err := readMSR(msr, msrAPERF)
+			if err != nil {
+				return fmt.Errorf("couldn't read APERF of CPU %s: %s", core.cpu, err)
+			}
+			mperf, err := readMSR(msr, msrMPERF)
+			if err != nil {
+				return fmt.Errorf("couldn't read MPERF of CPU %s: %s", core.cpu, err)
+			}
+			ch <- c.aperf.mustNewConstMetric(float64(aperf), core.cpu)
+			ch <- c.mperf.mustNewConstMetric(float64(mperf), core.cpu)
+		}
+	}
+
+	policies, err := readCPUFreqPolicies(path.Join(cpuPath, "cpufreq"))
+	if err != nil {
+		return err
+	}
+	for _, p := range policies {
+		ch <- c.policyInfo.mustNewConstMetric(1, p.policy, p.driver, p.governor, p.cpus)
+		ch <- c.policyMin.mustNewConstMetric(p.min, p.policy)
+		ch <- c.policyMax.mustNewConstMetric(p.max, p.policy)
+	}
+	if boost, err := readUintFromFile(path.Join(cpuPath, "cpufreq", "boost")); err == nil {
+		ch <- c.boost.mustNewConstMetric(float64(boost))
+	}
+
+	pstatePath := path.Join(cpuPath, "intel_pstate")
+	if noTurbo, err := readUintFromFile(path.Join(pstatePath, "no_turbo")); err == nil {
+		turbo := 1.0
+		if noTurbo == 1 {
+			turbo = 0
+		}
+		ch <- c.turbo.mustNewConstMetric(turbo)
+	}
+	if pct, err := readUintFromFile(path.Join(pstatePath, "max_perf_pct")); err == nil {
+		ch <- c.maxPerf.mustNewConstMetric(float64(pct) / 100)
+	}
+
+	limits, err := readRAPLLimits(sysFilePath("class/powercap"))
+	if err != nil {
+		return err
+	}
+	for _, l := range limits {
+		ch <- c.limit.mustNewConstMetric(l.watts, l.domain, l.constraint)
+	}
+	return nil
+}
+
+func readCPUPowerCores(cpuPath string) ([]cpuPowerCore, error) {
+	dirs, err := filepath.Glob(path.Join(cpuPath, "cpu[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var cores []cpuPowerCore
+	for _, dir := range dirs {
+		core := cpuPowerCore{cpu: strings.TrimPrefix(path.Base(dir), "cpu")}
+		// Frequencies are in kHz.
+		for file, v := range map[string]**float64{
+			"scaling_cur_freq": &core.cur,
+			"cpuinfo_min_freq": &core.min,
+			"cpuinfo_max_freq": &core.max,
+			"base_frequency":   &core.base,
+		} {
+			if khz, err := readUintFromFile(path.Join(dir, "cpufreq", file)); err == nil {
+				hz := float64(khz) * 1000
+				*v = &hz
+			}
+		}
+
+		states, err := filepath.Glob(path.Join(dir, "cpuidle", "state[0-9]*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, state := range states {
+			name, err := readStringFromFile(path.Join(state, "name"))
+			if err != nil {
+				return nil, err
+			}
+			usecs, err := readUintFromFile(path.Join(state, "time"))
+			if err != nil {
+				return nil, err
+			}
+			usage, err := readUintFromFile(path.Join(state, "usage"))
+			if err != nil {
+				return nil, err
+			}
+			core.idle = append(core.idle, cpuIdleState{name: name, time: float64(usecs) / 1e6, usage: float64(usage)})
+		}
+		cores = append(cores, core)
+	}
+	return cores, nil
+}
+
+// readCPUFreqPolicies reads the cpufreq policies, which are named after
+// their first cpu like policy0.
+func readCPUFreqPolicies(cpufreqPath string) ([]cpuFreqPolicy, error) {
+	dirs, err := filepath.Glob(path.Join(cpufreqPath, "policy[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var policies []cpuFreqPolicy
+	for _, dir := range dirs {
+		p := cpuFreqPolicy{policy: strings.TrimPrefix(path.Base(dir), "policy")}
+		for file, v := range map[string]*string{
+			"scaling_driver":   &p.driver,
+			"scaling_governor": &p.governor,
+			"affected_cpus":    &p.cpus,
+		} {
+			if *v, err = readStringFromFile(path.Join(dir, file)); err != nil {
+				return nil, err
+			}
+		}
+		// Frequencies are in kHz.
+		for file, v := range map[string]*float64{
+			"scaling_min_freq": &p.min,
+			"scaling_max_freq": &p.max,
+		} {
+			khz, err := readUintFromFile(path.Join(dir, file))
+			if err != nil {
+				return nil, err
+			}
+			*v = float64(khz) * 1000
+		}
+		policies = append(policies, p)
+	}
+	return policies, nil
+}
+
+// readRAPLLimits reads the power limits of the RAPL domains of the
+// powercap class, named after the domain like package-0 or dram.
+func readRAPLLimits(powercapPath string) ([]raplLimit, error) {
+	zones, err := filepath.Glob(path.Join(powercapPath, "intel-rapl:*"))
+	if err != nil {
+		return nil, err
+	}
+	var limits []raplLimit
+	for _, zone := range zones {
+		domain, err := readStringFromFile(path.Join(zone, "name"))
+		if err != nil {
+			return nil, err
+		}
+		constraints, err := filepath.Glob(path.Join(zone, "constraint_*_name"))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range constraints {
+			name, err := readStringFromFile(file)
+			if err != nil {
+				return nil, err
+			}
+			uw, err := readUintFromFile(strings.TrimSuffix(file, "name") + "power_limit_uw")
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			limits = append(limits, raplLimit{domain: domain, constraint: name, watts: float64(uw) / 1e6})
+		}
+	}
+	return limits, nil
+}
+
+func readMSR(device string, msr int64) (uint64, error) {
+	file, err := os.Open(device)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 8)
+	if _, err := file.ReadAt(buf, msr); err != nil {
+		return 0, err
+	}
+	return nativeEndian.Uint64(buf), nil
+}
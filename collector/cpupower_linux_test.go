@@ -0,0 +1,83 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCPUPowerCores(t *testing.T) {
+	cores, err := readCPUPowerCores("fixtures/sys/devices/system/cpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(cores); want != got {
+		t.Fatalf("want %d cores, got %d", want, got)
+	}
+	core := cores[0]
+	if want, got := "0", core.cpu; want != got {
+		t.Errorf("want CPU %s, got %s", want, got)
+	}
+	for _, f := range []struct {
+		name string
+		want float64
+		got  *float64
+	}{
+		{"current", 3400123000, core.cur},
+		{"min", 800000000, core.min},
+		{"max", 4200000000, core.max},
+		{"base", 2100000000, core.base},
+	} {
+		if f.got == nil || f.want != *f.got {
+			t.Errorf("want %s frequency %f, got %v", f.name, f.want, f.got)
+		}
+	}
+	want := []cpuIdleState{
+		{name: "POLL", time: 0.0015, usage: 42},
+		{name: "C6", time: 81.234567, usage: 12345},
+	}
+	if !reflect.DeepEqual(want, core.idle) {
+		t.Errorf("want idle states %+v, got %+v", want, core.idle)
+	}
+}
+
+func TestRAPLLimits(t *testing.T) {
+	limits, err := readRAPLLimits("fixtures/sys/class/powercap")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []raplLimit{
+		{"package-0", "long_term", 15},
+		{"package-0", "short_term", 25},
+		{"core", "long_term", 0},
+	}
+	if !reflect.DeepEqual(want, limits) {
+		t.Errorf("want %+v, got %+v", want, limits)
+	}
+}
+
+func TestCPUFreqPolicies(t *testing.T) {
+	policies, err := readCPUFreqPolicies("fixtures/sys/devices/system/cpu/cpufreq")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []cpuFreqPolicy{
+		{policy: "0", driver: "intel_pstate", governor: "powersave", cpus: "0", min: 800000000, max: 4200000000},
+		{policy: "1", driver: "intel_pstate", governor: "performance", cpus: "1", min: 1200000000, max: 3000000000},
+	}
+	if !reflect.DeepEqual(want, policies) {
+		t.Errorf("want %+v, got %+v", want, policies)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodevstat
+
+package collector
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Layout of struct devstat from sys/devicestat.h of FreeBSD on 64-bit
+// platforms.
+const (
+	devstatVersion = 6
+	devstatSize    = 288
+
+	devstatNameOffset      = 44
+	devstatNameLen         = 16
+	devstatUnitOffset      = 60
+	devstatBytesOffset     = 64
+	devstatOpsOffset       = 96
+	devstatDurationOffset  = 128
+	devstatBusyTimeOffset  = 192
+	devstatBlockSizeOffset = 224
+)
+
+// Indexes of the statistics per devstat_trans_flags.
+const (
+	devstatNoData = iota
+	devstatRead
+	devstatWrite
+	devstatFree
+	devstatNTransFlags
+)
+
+type devstatStats struct {
+	device    string
+	bytes     [devstatNTransFlags]uint64
+	transfers [devstatNTransFlags]uint64
+	duration  [devstatNTransFlags]float64
+	busyTime  float64
+	blocks    uint64
+}
+
+// sysctlDevstat returns the statistics of all devices from the
+// kern.devstat.all sysctl, the generation of the device list as a long
+// followed by a struct devstat per device.
+func sysctlDevstat(s sysctlReader) ([]devstatStats, error) {
+	if strconv.IntSize != 64 {
+		return nil, errors.New("devstat is only supported on 64-bit platforms")
+	}
+	version, err := sysctlUint32(s, "kern.devstat.version")
+	if err != nil {
+		return nil, err
+	}
+	if version != devstatVersion {
+		return nil, fmt.Errorf("unsupported devstat version %d", version)
+	}
+	b, err := s.SysctlRaw("kern.devstat.all")
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 8 || (len(b)-8)%devstatSize != 0 {
+		return nil, fmt.Errorf("kern.devstat.all has unexpected size %d", len(b))
+	}
+
+	var stats []devstatStats
+	for b = b[8:]; len(b) > 0; b = b[devstatSize:] {
+		name := b[devstatNameOffset : devstatNameOffset+devstatNameLen]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		d := devstatStats{
+			device: fmt.Sprintf("%s%d", name, int32(nativeEndian.Uint32(b[devstatUnitOffset:]))),
+		}
+		for i := 0; i < devstatNTransFlags; i++ {
+			d.bytes[i] = nativeEndian.Uint64(b[devstatBytesOffset+8*i:])
+			d.transfers[i] = nativeEndian.Uint64(b[devstatOpsOffset+8*i:])
+			d.duration[i] = bintimeSeconds(b[devstatDurationOffset+16*i:])
+		}
+		d.busyTime = bintimeSeconds(b[devstatBusyTimeOffset:])
+
+		// Blocks are computed as by devstat_compute_statistics(3).
+		blockSize := uint64(nativeEndian.Uint32(b[devstatBlockSizeOffset:]))
+		if blockSize == 0 {
+			blockSize = 512
+		}
+		d.blocks = (d.bytes[devstatRead] + d.bytes[devstatWrite] + d.bytes[devstatFree]) / blockSize
+		stats = append(stats, d)
+	}
+	return stats, nil
+}
+
+// bintimeSeconds decodes a struct bintime, seconds followed by the binary
+// fraction of a second.
+func bintimeSeconds(b []byte) float64 {
+	return float64(int64(nativeEndian.Uint64(b))) + float64(nativeEndian.Uint64(b[8:]))/(1<<64)
+}
@@ -20,8 +20,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -41,6 +44,15 @@ var (
 type diskstatsCollector struct {
 	ignoredDevicesPattern *regexp.Regexp
 	descs                 []typedDesc
+	queueInfoDesc         *prometheus.Desc
+}
+
+// diskQueue holds the request queue settings of a block device from
+// /sys/block/<device>/queue, see Documentation/block/queue-sysfs.txt.
+type diskQueue struct {
+	scheduler, nrRequests, readAheadKB, rotational string
+	// queueDepth is only known for SCSI devices.
+	queueDepth string
 }
 
 func init() {
@@ -50,10 +62,18 @@ func init() {
 // Takes a prometheus registry and returns a new Collector exposing
 // disk device stats.
 func NewDiskstatsCollector() (Collector, error) {
-	var diskLabelNames = []string{"device"}
+	// The parent of partitions and device mapper targets and the names of
+	// the latter are resolved from sysfs, dm-3 alone means little.
+	var diskLabelNames = []string{"device", "parent", "dm_name", "dm_uuid"}
 
 	return &diskstatsCollector{
 		ignoredDevicesPattern: regexp.MustCompile(*ignoredDevices),
+		queueInfoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, diskSubsystem, "queue_info"),
+			"Active I/O scheduler and request queue settings of the device, value is always 1.",
+			[]string{"device", "scheduler", "nr_requests", "read_ahead_kb", "rotational", "queue_depth"},
+			nil,
+		),
 		// Docs from https://www.kernel.org/doc/Documentation/iostats.txt
 		descs: []typedDesc{
 			{
@@ -170,6 +190,10 @@ func (c *diskstatsCollector) Update(ch chan<- prometheus.Metric) error {
 	if err != nil {
 		return fmt.Errorf("couldn't get diskstats: %s", err)
 	}
+	deviceLabels, err := readDiskDeviceLabels(sysFilePath("block"))
+	if err != nil {
+		return fmt.Errorf("couldn't get block device details: %s", err)
+	}
 
 	for dev, stats := range diskStats {
 		if c.ignoredDevicesPattern.MatchString(dev) {
@@ -186,12 +210,136 @@ func (c *diskstatsCollector) Update(ch chan<- prometheus.Metric) error {
 			if err != nil {
 				return fmt.Errorf("invalid value %s in diskstats: %s", value, err)
 			}
-			ch <- c.descs[i].mustNewConstMetric(v, dev)
+			l := deviceLabels[dev]
+			ch <- c.descs[i].mustNewConstMetric(v, dev, l.parent, l.dmName, l.dmUUID)
+		}
+
+		q, err := readDiskQueue(sysFilePath("block"), dev)
+		if err != nil {
+			return fmt.Errorf("couldn't get queue settings of %s: %s", dev, err)
+		}
+		if q != nil {
+			ch <- prometheus.MustNewConstMetric(c.queueInfoDesc, prometheus.GaugeValue, 1,
+				dev, q.scheduler, q.nrRequests, q.readAheadKB, q.rotational, q.queueDepth)
+		}
+	}
+	return nil
+}
+
+// diskDeviceLabels describe where a block device comes from.
+type diskDeviceLabels struct {
+	// parent is the disk of a partition or the comma separated devices
+	// below a device mapper target.
+	parent         string
+	dmName, dmUUID string
+}
+
+// readDiskDeviceLabels returns the labels of the devices below root, by
+// device name as in /proc/diskstats.
+func readDiskDeviceLabels(root string) (map[string]diskDeviceLabels, error) {
+	labels := map[string]diskDeviceLabels{}
+	disks, err := ioutil.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Debugf("No block devices in %s", root)
+			return labels, nil
+		}
+		return nil, err
+	}
+	// Entries are symlinks to the devices, only their names are used.
+	for _, disk := range disks {
+		dev := strings.Replace(disk.Name(), "!", "/", -1)
+		if err := readDiskLabels(filepath.Join(root, disk.Name()), dev, labels); err != nil {
+			// Devices can be removed while walking them, their statistics
+			// are still exposed.
+			log.Debugf("Couldn't get labels of block device %s: %s", dev, err)
+		}
+	}
+	return labels, nil
+}
+
+// readDiskLabels adds the labels of the block device dev in the sysfs
+// directory dir and of its partitions to labels.
+func readDiskLabels(dir, dev string, labels map[string]diskDeviceLabels) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(dir, e.Name(), "partition")); err == nil {
+			labels[strings.Replace(e.Name(), "!", "/", -1)] = diskDeviceLabels{parent: dev}
 		}
 	}
+
+	name, err := readStringFromFile(filepath.Join(dir, "dm", "name"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	l := diskDeviceLabels{dmName: name}
+	if l.dmUUID, err = readStringFromFile(filepath.Join(dir, "dm", "uuid")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	slaves, err := ioutil.ReadDir(filepath.Join(dir, "slaves"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	parents := make([]string, 0, len(slaves))
+	for _, slave := range slaves {
+		parents = append(parents, strings.Replace(slave.Name(), "!", "/", -1))
+	}
+	sort.Strings(parents)
+	l.parent = strings.Join(parents, ",")
+	labels[dev] = l
 	return nil
 }
 
+// readDiskQueue returns the queue settings of dev, or nil for devices without
+// a request queue such as partitions.
+func readDiskQueue(root, dev string) (*diskQueue, error) {
+	// Slashes in device names are replaced by ! in sysfs, e.g. cciss!c0d0.
+	dir := filepath.Join(root, strings.Replace(dev, "/", "!", -1))
+	queue := filepath.Join(dir, "queue")
+	if _, err := os.Stat(queue); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	q := &diskQueue{}
+	for file, value := range map[string]*string{
+		"scheduler":     &q.scheduler,
+		"nr_requests":   &q.nrRequests,
+		"read_ahead_kb": &q.readAheadKB,
+		"rotational":    &q.rotational,
+	} {
+		v, err := readStringFromFile(filepath.Join(queue, file))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		*value = v
+	}
+	q.scheduler = activeScheduler(q.scheduler)
+
+	depth, err := readStringFromFile(filepath.Join(dir, "device", "queue_depth"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	q.queueDepth = depth
+	return q, nil
+}
+
+// activeScheduler returns the scheduler in brackets of a queue/scheduler
+// file, e.g. cfq of "noop deadline [cfq]". Devices without a choice list
+// just the one in use, e.g. "none".
+func activeScheduler(schedulers string) string {
+	start, end := strings.Index(schedulers, "["), strings.Index(schedulers, "]")
+	if start < 0 || end < start {
+		return strings.TrimSpace(schedulers)
+	}
+	return schedulers[start+1 : end]
+}
+
 func getDiskStats() (map[string]map[int]string, error) {
 	file, err := os.Open(procFilePath("diskstats"))
 	if err != nil {
@@ -14,7 +14,9 @@
 package collector
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -46,3 +48,60 @@ func TestDiskStats(t *testing.T) {
 		t.Errorf("want diskstats sda write bytes %s, got %s", want, got)
 	}
 }
+
+func TestDiskQueue(t *testing.T) {
+	for dev, want := range map[string]*diskQueue{
+		"sda":     {scheduler: "cfq", nrRequests: "128", readAheadKB: "128", rotational: "1", queueDepth: "31"},
+		"nvme0n1": {scheduler: "none", nrRequests: "1023", readAheadKB: "128", rotational: "0"},
+		"dm-0":    {scheduler: "none", nrRequests: "128", readAheadKB: "4096", rotational: "0"},
+		"sda1":    nil,
+	} {
+		got, err := readDiskQueue("fixtures/sys/block", dev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if (want == nil) != (got == nil) || want != nil && *want != *got {
+			t.Errorf("want %s queue %+v, got %+v", dev, want, got)
+		}
+	}
+}
+
+func TestDiskDeviceLabels(t *testing.T) {
+	labels, err := readDiskDeviceLabels("fixtures/sys/block")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for dev, want := range map[string]diskDeviceLabels{
+		"sda3":      {parent: "sda"},
+		"nvme0n1p2": {parent: "nvme0n1"},
+		"mmcblk0p1": {parent: "mmcblk0"},
+		"dm-0":      {parent: "sda3", dmName: "vg0-root", dmUUID: "LVM-Jf8D5Nb2cXwqIcvU0aZ9bXmVZeQgdIFUeLm7wGZl1H9M4Ka1XuYPw2gnWAbKLy0C"},
+		"dm-2":      {parent: "nvme0n1p2", dmName: "luks-home", dmUUID: "CRYPT-LUKS2-5b3a8c0e1f2d4e6a9b7c8d0e1f2a3b4c-luks-home"},
+		"sda":       {},
+	} {
+		if got := labels[dev]; want != got {
+			t.Errorf("want %s labels %+v, got %+v", dev, want, got)
+		}
+	}
+}
+
+func TestDiskDeviceLabelsRemovedDevice(t *testing.T) {
+	dir, err := ioutil.TempDir("", "block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// A device removed after listing /sys/block.
+	if err := os.Symlink(filepath.Join(dir, "missing"), filepath.Join(dir, "sdz")); err != nil {
+		t.Fatal(err)
+	}
+
+	labels, err := readDiskDeviceLabels(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 0, len(labels); want != got {
+		t.Errorf("want %d labels, got %d", want, got)
+	}
+}
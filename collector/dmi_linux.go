@@ -0,0 +1,109 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodmi
+
+package collector
+
+import (
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// dmiFields are the files of /sys/class/dmi/id exposed as labels, by label
+// name. The serial numbers are only readable by root and are left empty
+// otherwise.
+var dmiFields = []struct{ label, file string }{
+	{"system_vendor", "sys_vendor"},
+	{"product_name", "product_name"},
+	{"product_version", "product_version"},
+	{"product_serial", "product_serial"},
+	{"board_vendor", "board_vendor"},
+	{"board_name", "board_name"},
+	{"board_version", "board_version"},
+	{"bios_vendor", "bios_vendor"},
+	{"bios_version", "bios_version"},
+	{"bios_date", "bios_date"},
+	{"chassis_vendor", "chassis_vendor"},
+	{"chassis_type", "chassis_type"},
+	{"chassis_serial", "chassis_serial"},
+}
+
+// dmiChassisTypes are the names of the SMBIOS chassis types.
+var dmiChassisTypes = []string{
+	1: "Other", 2: "Unknown", 3: "Desktop", 4: "Low Profile Desktop",
+	5: "Pizza Box", 6: "Mini Tower", 7: "Tower", 8: "Portable", 9: "Laptop",
+	10: "Notebook", 11: "Hand Held", 12: "Docking Station", 13: "All In One",
+	14: "Sub Notebook", 15: "Space-saving", 16: "Lunch Box",
+	17: "Main Server Chassis", 18: "Expansion Chassis", 19: "Sub Chassis",
+	20: "Bus Expansion Chassis", 21: "Peripheral Chassis", 22: "RAID Chassis",
+	23: "Rack Mount Chassis", 24: "Sealed-case PC", 25: "Multi-system",
+	26: "CompactPCI", 27: "AdvancedTCA", 28: "Blade", 29: "Blade Enclosure",
+	30: "Tablet", 31: "Convertible", 32: "Detachable", 33: "IoT Gateway",
+	34: "Embedded PC", 35: "Mini PC", 36: "Stick PC",
+}
+
+type dmiCollector struct {
+	info typedDesc
+}
+
+func init() {
+	Factories["dmi"] = NewDMICollector
+}
+
+// NewDMICollector returns a new Collector exposing the hardware inventory
+// of the SMBIOS/DMI tables.
+func NewDMICollector() (Collector, error) {
+	labels := make([]string, len(dmiFields))
+	for i, f := range dmiFields {
+		labels[i] = f.label
+	}
+	return &dmiCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "dmi", "info"),
+			"Hardware inventory from the SMBIOS/DMI tables, value is always 1.",
+			labels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *dmiCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	values, err := readDMIInfo(sysFilePath("class/dmi/id"))
+	if err != nil {
+		return err
+	}
+	ch <- c.info.mustNewConstMetric(1, values...)
+	return nil
+}
+
+// readDMIInfo returns the values of dmiFields, empty for fields the
+// firmware doesn't provide or that are not readable.
+func readDMIInfo(root string) ([]string, error) {
+	if _, err := os.Stat(root); err != nil {
+		return nil, err
+	}
+	values := make([]string, len(dmiFields))
+	for i, f := range dmiFields {
+		v, _ := readStringFromFile(path.Join(root, f.file))
+		if f.file == "chassis_type" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 && n < len(dmiChassisTypes) {
+				v = dmiChassisTypes[n]
+			}
+		}
+		values[i] = v
+	}
+	return values, nil
+}
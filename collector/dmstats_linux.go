@@ -0,0 +1,308 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodmstats
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	dmstatsSubsystem = "dmstats"
+
+	// Number of counters printed by @stats_print per area, see
+	// Documentation/admin-guide/device-mapper/statistics.rst.
+	dmstatsCounters = 13
+
+	dmstatsSectorSize = 512
+)
+
+var (
+	dmstatsCommand = flag.String("collector.dmstats.command", "dmsetup", "Command to run dmsetup.")
+	dmstatsRegions = flag.Bool("collector.dmstats.regions", false, "Expose statistics of every dm-stats region instead of the sum per device.")
+)
+
+// dmstatsCounter describes one of the counters of an area. Times are in
+// milliseconds, or nanoseconds for regions with precise timestamps.
+type dmstatsCounter struct {
+	name, help string
+	valueType  prometheus.ValueType
+	sectors    bool
+	time       bool
+}
+
+// Counters in the order of @stats_print, the busy time of reads and writes
+// that follow are not exposed.
+var dmstatsCounterDefs = []dmstatsCounter{
+	{"reads_completed_total", "Reads completed in the region.", prometheus.CounterValue, false, false},
+	{"reads_merged_total", "Adjacent reads merged in the region.", prometheus.CounterValue, false, false},
+	{"read_bytes_total", "Bytes read from the region.", prometheus.CounterValue, true, false},
+	{"read_time_seconds_total", "Time spent on reads from the region.", prometheus.CounterValue, false, true},
+	{"writes_completed_total", "Writes completed in the region.", prometheus.CounterValue, false, false},
+	{"writes_merged_total", "Adjacent writes merged in the region.", prometheus.CounterValue, false, false},
+	{"written_bytes_total", "Bytes written to the region.", prometheus.CounterValue, true, false},
+	{"write_time_seconds_total", "Time spent on writes to the region.", prometheus.CounterValue, false, true},
+	{"io_now", "I/Os in progress in the region.", prometheus.GaugeValue, false, false},
+	{"io_time_seconds_total", "Time the region had I/Os in progress.", prometheus.CounterValue, false, true},
+	{"io_time_weighted_seconds_total", "Time the region had I/Os in progress weighted by their number.", prometheus.CounterValue, false, true},
+}
+
+type dmstatsRegion struct {
+	id       string
+	precise  bool
+	bounds   []float64
+	counters [dmstatsCounters]float64
+	buckets  []uint64
+}
+
+// scale returns the factor converting the time counters of the region to
+// seconds.
+func (r *dmstatsRegion) scale() float64 {
+	if r.precise {
+		return 1e-9
+	}
+	return 1e-3
+}
+
+// add sums the counters of o into r, which must use the same timestamps.
+// Histograms are only summed if their bounds match.
+func (r *dmstatsRegion) add(o *dmstatsRegion) {
+	for i := range r.counters {
+		r.counters[i] += o.counters[i]
+	}
+	if len(r.bounds) != len(o.bounds) || len(r.buckets) != len(o.buckets) {
+		r.bounds, r.buckets = nil, nil
+		return
+	}
+	for i := range r.bounds {
+		if r.bounds[i] != o.bounds[i] {
+			r.bounds, r.buckets = nil, nil
+			return
+		}
+	}
+	for i := range r.buckets {
+		r.buckets[i] += o.buckets[i]
+	}
+}
+
+type dmstatsCollector struct {
+	cli     string
+	descs   []*prometheus.Desc
+	latency *prometheus.Desc
+}
+
+func init() {
+	Factories["dmstats"] = NewDMStatsCollector
+}
+
+// NewDMStatsCollector returns a new Collector exposing I/O statistics of
+// device-mapper statistics regions.
+func NewDMStatsCollector() (Collector, error) {
+	labels := []string{"device"}
+	if *dmstatsRegions {
+		labels = append(labels, "region")
+	}
+	c := &dmstatsCollector{
+		cli: *dmstatsCommand,
+		latency: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dmstatsSubsystem, "io_latency_seconds"),
+			"Latency of I/Os in regions with a histogram.",
+			labels, nil,
+		),
+	}
+	for _, def := range dmstatsCounterDefs {
+		c.descs = append(c.descs, prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, dmstatsSubsystem, def.name),
+			def.help, labels, nil,
+		))
+	}
+	return c, nil
+}
+
+func (c *dmstatsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	names, err := filepath.Glob(sysFilePath("block/dm-*/dm/name"))
+	if err != nil {
+		return err
+	}
+	for _, file := range names {
+		device, err := readStringFromFile(file)
+		if err != nil {
+			return err
+		}
+		regions, err := c.deviceRegions(device)
+		if err != nil {
+			return fmt.Errorf("couldn't get dm-stats regions of %s: %s", device, err)
+		}
+		if len(regions) == 0 {
+			continue
+		}
+		if !*dmstatsRegions {
+			total := regions[0]
+			for _, r := range regions[1:] {
+				if r.precise != total.precise {
+					log.Debugf("Skipping region %s of %s, it differs in timestamp precision", r.id, device)
+					continue
+				}
+				total.add(r)
+			}
+			regions = []*dmstatsRegion{total}
+		}
+		for _, r := range regions {
+			labels := []string{device}
+			if *dmstatsRegions {
+				labels = append(labels, r.id)
+			}
+			c.collectRegion(ch, r, labels)
+		}
+	}
+	return nil
+}
+
+func (c *dmstatsCollector) collectRegion(ch chan<- prometheus.Metric, r *dmstatsRegion, labels []string) {
+	for i, def := range dmstatsCounterDefs {
+		v := r.counters[i]
+		switch {
+		case def.sectors:
+			v *= dmstatsSectorSize
+		case def.time:
+			v *= r.scale()
+		}
+		ch <- prometheus.MustNewConstMetric(c.descs[i], def.valueType, v, labels...)
+	}
+	if len(r.buckets) == 0 {
+		return
+	}
+	// The kernel counts I/Os below each bound plus those above the last,
+	// Prometheus buckets are cumulative.
+	var (
+		count   uint64
+		buckets = map[float64]uint64{}
+	)
+	for i, bound := range r.bounds {
+		count += r.buckets[i]
+		buckets[bound*r.scale()] = count
+	}
+	count += r.buckets[len(r.buckets)-1]
+	sum := (r.counters[3] + r.counters[7]) * r.scale()
+	ch <- prometheus.MustNewConstHistogram(c.latency, count, sum, buckets, labels...)
+}
+
+// deviceRegions returns the regions of the device with their counters
+// summed over all areas.
+func (c *dmstatsCollector) deviceRegions(device string) ([]*dmstatsRegion, error) {
+	out, err := exec.Command(c.cli, "message", device, "0", "@stats_list").Output()
+	if err != nil {
+		return nil, err
+	}
+	regions, err := parseDMStatsList(bytes.NewReader(out))
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range regions {
+		out, err := exec.Command(c.cli, "message", device, "0", "@stats_print", r.id).Output()
+		if err != nil {
+			return nil, err
+		}
+		if err := parseDMStatsPrint(bytes.NewReader(out), r); err != nil {
+			return nil, err
+		}
+	}
+	return regions, nil
+}
+
+// parseDMStatsList parses the output of the @stats_list message:
+// <region_id>: <start>+<length> <step> <program_id> <aux_data>, optionally
+// followed by precise_timestamps and histogram:<bound>,...
+func parseDMStatsList(r io.Reader) ([]*dmstatsRegion, error) {
+	var regions []*dmstatsRegion
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 5 || !strings.HasSuffix(fields[0], ":") {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		region := &dmstatsRegion{id: strings.TrimSuffix(fields[0], ":")}
+		for _, opt := range fields[5:] {
+			switch {
+			case opt == "precise_timestamps":
+				region.precise = true
+			case strings.HasPrefix(opt, "histogram:"):
+				for _, b := range strings.Split(strings.TrimPrefix(opt, "histogram:"), ",") {
+					bound, err := strconv.ParseFloat(b, 64)
+					if err != nil {
+						return nil, fmt.Errorf("invalid histogram bound in line: %s", scanner.Text())
+					}
+					region.bounds = append(region.bounds, bound)
+				}
+			}
+		}
+		regions = append(regions, region)
+	}
+	return regions, scanner.Err()
+}
+
+// parseDMStatsPrint parses the output of the @stats_print message into the
+// region, summing the counters and histograms of its areas.
+func parseDMStatsPrint(r io.Reader, region *dmstatsRegion) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 1+dmstatsCounters {
+			return fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		for i := 0; i < dmstatsCounters; i++ {
+			v, err := strconv.ParseFloat(fields[1+i], 64)
+			if err != nil {
+				return fmt.Errorf("invalid counter in line: %s", scanner.Text())
+			}
+			region.counters[i] += v
+		}
+		if len(fields) <= 1+dmstatsCounters {
+			continue
+		}
+		buckets := strings.Split(fields[1+dmstatsCounters], ":")
+		if len(buckets) != len(region.bounds)+1 {
+			return fmt.Errorf("histogram doesn't match bounds in line: %s", scanner.Text())
+		}
+		if region.buckets == nil {
+			region.buckets = make([]uint64, len(buckets))
+		}
+		for i, b := range buckets {
+			v, err := strconv.ParseUint(b, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid histogram in line: %s", scanner.Text())
+			}
+			region.buckets[i] += v
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDMStats(t *testing.T) {
+	list, err := os.Open("fixtures/dmstats/stats_list.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer list.Close()
+
+	regions, err := parseDMStatsList(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(regions); want != got {
+		t.Fatalf("want %d regions, got %d", want, got)
+	}
+	if regions[0].precise || regions[0].bounds != nil {
+		t.Errorf("want region 0 without options, got %+v", regions[0])
+	}
+	r := regions[1]
+	if !r.precise {
+		t.Error("want region 1 with precise timestamps")
+	}
+	if want, got := []float64{1e6, 5e6, 2e7}, r.bounds; !reflect.DeepEqual(want, got) {
+		t.Errorf("want bounds %v, got %v", want, got)
+	}
+
+	print, err := os.Open("fixtures/dmstats/stats_print_1.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer print.Close()
+
+	if err := parseDMStatsPrint(print, r); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 110.0, r.counters[0]; want != got {
+		t.Errorf("want %f reads, got %f", want, got)
+	}
+	if want, got := 3520.0, r.counters[6]; want != got {
+		t.Errorf("want %f sectors written, got %f", want, got)
+	}
+	if want, got := []uint64{25, 60, 35, 10}, r.buckets; !reflect.DeepEqual(want, got) {
+		t.Errorf("want buckets %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nodns
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	dnsNames    = flag.String("collector.dns.names", "", "Comma-separated list of hostnames to resolve for dns collector.")
+	dnsInterval = flag.Duration("collector.dns.interval", time.Minute, "Minimum interval between lookups of the dns collector.")
+)
+
+type dnsResult struct {
+	lookups, failures float64
+	duration          float64
+	success           float64
+}
+
+type dnsCollector struct {
+	names    []string
+	interval time.Duration
+	lookup   func(string) ([]string, error)
+
+	duration, success, lookups, failures typedDesc
+
+	mtx     sync.Mutex
+	last    time.Time
+	results map[string]*dnsResult
+}
+
+func init() {
+	Factories["dns"] = NewDNSCollector
+}
+
+// NewDNSCollector returns a new Collector resolving the configured hostnames
+// with the system resolver and exposing lookup latency and failures.
+func NewDNSCollector() (Collector, error) {
+	if *dnsNames == "" {
+		return nil, fmt.Errorf("no hostnames specified, see -collector.dns.names")
+	}
+	return newDNSCollector(strings.Split(*dnsNames, ","), *dnsInterval, net.LookupHost), nil
+}
+
+func newDNSCollector(names []string, interval time.Duration, lookup func(string) ([]string, error)) *dnsCollector {
+	const subsystem = "dns"
+	labels := []string{"name"}
+
+	results := make(map[string]*dnsResult, len(names))
+	for _, name := range names {
+		results[name] = &dnsResult{}
+	}
+	return &dnsCollector{
+		names:    names,
+		interval: interval,
+		lookup:   lookup,
+		results:  results,
+		duration: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "lookup_duration_seconds"),
+			"Duration of the last lookup of the hostname.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		success: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "lookup_success"),
+			"Whether the last lookup of the hostname succeeded.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		lookups: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "lookups_total"),
+			"Number of lookups of the hostname.",
+			labels, nil,
+		), prometheus.CounterValue},
+		failures: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "lookup_failures_total"),
+			"Number of failed lookups of the hostname.",
+			labels, nil,
+		), prometheus.CounterValue},
+	}
+}
+
+func (c *dnsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	// Lookups are only repeated once the interval has passed, so frequent
+	// scrapes don't hammer the resolver.
+	if time.Since(c.last) >= c.interval {
+		c.probe()
+		c.last = time.Now()
+	}
+
+	for _, name := range c.names {
+		r := c.results[name]
+		ch <- c.duration.mustNewConstMetric(r.duration, name)
+		ch <- c.success.mustNewConstMetric(r.success, name)
+		ch <- c.lookups.mustNewConstMetric(r.lookups, name)
+		ch <- c.failures.mustNewConstMetric(r.failures, name)
+	}
+	return nil
+}
+
+func (c *dnsCollector) probe() {
+	for _, name := range c.names {
+		r := c.results[name]
+		begin := time.Now()
+		_, err := c.lookup(name)
+		r.duration = time.Since(begin).Seconds()
+		r.lookups++
+		if err != nil {
+			log.Debugf("Lookup of %s failed: %s", name, err)
+			r.failures++
+			r.success = 0
+			continue
+		}
+		r.success = 1
+	}
+}
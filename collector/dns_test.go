@@ -0,0 +1,57 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDNSCollector(t *testing.T) {
+	calls := 0
+	lookup := func(name string) ([]string, error) {
+		calls++
+		if name == "broken.example.com" {
+			return nil, errors.New("no such host")
+		}
+		return []string{"192.0.2.1"}, nil
+	}
+	c := newDNSCollector([]string{"ok.example.com", "broken.example.com"}, time.Hour, lookup)
+
+	for i := 0; i < 2; i++ {
+		ch := make(chan prometheus.Metric, 16)
+		if err := c.Update(ch); err != nil {
+			t.Fatal(err)
+		}
+		close(ch)
+		if want, got := 8, len(ch); want != got {
+			t.Fatalf("want %d metrics, got %d", want, got)
+		}
+	}
+
+	// The second update happened within the interval and must not trigger
+	// new lookups.
+	if want, got := 2, calls; want != got {
+		t.Errorf("want %d lookups, got %d", want, got)
+	}
+	if want, got := 1.0, c.results["ok.example.com"].success; want != got {
+		t.Errorf("want success %f, got %f", want, got)
+	}
+	if want, got := 1.0, c.results["broken.example.com"].failures; want != got {
+		t.Errorf("want %f failures, got %f", want, got)
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 type entropyCollector struct {
 	entropy_avail *prometheus.Desc
+	poolSize      *prometheus.Desc
 }
 
 func init() {
@@ -38,6 +39,11 @@ func NewEntropyCollector() (Collector, error) {
 			"Bits of available entropy.",
 			nil, nil,
 		),
+		poolSize: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "entropy", "pool_size_bits"),
+			"Bits of entropy the pool can hold.",
+			nil, nil,
+		),
 	}, nil
 }
 
@@ -49,5 +55,12 @@ func (c *entropyCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	ch <- prometheus.MustNewConstMetric(
 		c.entropy_avail, prometheus.GaugeValue, float64(value))
 
+	poolSize, err := readUintFromFile(procFilePath("sys/kernel/random/poolsize"))
+	if err != nil {
+		return fmt.Errorf("couldn't get poolsize: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.poolSize, prometheus.GaugeValue, float64(poolSize))
+
 	return nil
 }
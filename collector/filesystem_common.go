@@ -12,13 +12,14 @@
 // limitations under the License.
 
 // +build !nofilesystem
-// +build linux freebsd openbsd darwin,amd64 dragonfly
+// +build linux freebsd openbsd darwin,amd64 dragonfly windows,experimental_windows
 
 package collector
 
 import (
 	"flag"
 	"regexp"
+	"strconv"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -28,6 +29,7 @@ import (
 // * defIgnoredFSTypes
 // * filesystemLabelNames
 // * filesystemCollector.GetStats
+// * filesystemCollector.updateMemoryBacked
 
 var (
 	ignoredMountPoints = flag.String(
@@ -40,6 +42,19 @@ var (
 		defIgnoredFSTypes,
 		"Regexp of filesystem types to ignore for filesystem collector.")
 
+	labelMemoryBacked = flag.Bool(
+		"collector.filesystem.label-memory-backed",
+		false,
+		"Add a memory_backed label to the filesystem metrics and expose the shared memory charged to cgroups, as tmpfs usage is memory usage.")
+
+	// memoryBackedFSTypes hold their files in the page cache and swap
+	// instead of on a device.
+	memoryBackedFSTypes = map[string]bool{
+		"tmpfs":    true,
+		"ramfs":    true,
+		"devtmpfs": true,
+	}
+
 	filesystemLabelNames = []string{"device", "mountpoint", "fstype"}
 )
 
@@ -48,7 +63,9 @@ type filesystemCollector struct {
 	ignoredFSTypesPattern     *regexp.Regexp
 	sizeDesc, freeDesc, availDesc,
 	filesDesc, filesFreeDesc, roDesc *prometheus.Desc
-	devErrors *prometheus.CounterVec
+	mountInfoDesc   *prometheus.Desc
+	cgroupShmemDesc *prometheus.Desc
+	devErrors       *prometheus.CounterVec
 }
 
 type filesystemLabels struct {
@@ -58,6 +75,12 @@ type filesystemLabels struct {
 type filesystemStats struct {
 	labels                                  filesystemLabels
 	size, free, avail, files, filesFree, ro float64
+	// Only set on platforms exposing mount details.
+	mountInfo *filesystemMountInfo
+}
+
+type filesystemMountInfo struct {
+	options, superOptions, propagation string
 }
 
 func init() {
@@ -70,41 +93,57 @@ func NewFilesystemCollector() (Collector, error) {
 	subsystem := "filesystem"
 	mountPointPattern := regexp.MustCompile(*ignoredMountPoints)
 	filesystemsTypesPattern := regexp.MustCompile(*ignoredFSTypes)
+	labelNames := filesystemLabelNames
+	if *labelMemoryBacked {
+		labelNames = append(append([]string{}, filesystemLabelNames...), "memory_backed")
+	}
 
 	sizeDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, subsystem, "size"),
 		"Filesystem size in bytes.",
-		filesystemLabelNames, nil,
+		labelNames, nil,
 	)
 
 	freeDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, subsystem, "free"),
 		"Filesystem free space in bytes.",
-		filesystemLabelNames, nil,
+		labelNames, nil,
 	)
 
 	availDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, subsystem, "avail"),
 		"Filesystem space available to non-root users in bytes.",
-		filesystemLabelNames, nil,
+		labelNames, nil,
 	)
 
 	filesDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, subsystem, "files"),
 		"Filesystem total file nodes.",
-		filesystemLabelNames, nil,
+		labelNames, nil,
 	)
 
 	filesFreeDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, subsystem, "files_free"),
 		"Filesystem total free file nodes.",
-		filesystemLabelNames, nil,
+		labelNames, nil,
 	)
 
 	roDesc := prometheus.NewDesc(
 		prometheus.BuildFQName(Namespace, subsystem, "readonly"),
 		"Filesystem read-only status.",
-		filesystemLabelNames, nil,
+		labelNames, nil,
+	)
+
+	mountInfoDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "mount_info"),
+		"Filesystem mount options, superblock options and propagation type, value is always 1.",
+		[]string{"device", "mountpoint", "fstype", "options", "super_options", "propagation"}, nil,
+	)
+
+	cgroupShmemDesc := prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, "cgroup_shmem_bytes"),
+		"Shared memory charged to the cgroup in bytes, including the files of memory backed filesystems.",
+		[]string{"cgroup"}, nil,
 	)
 
 	devErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
@@ -121,6 +160,8 @@ func NewFilesystemCollector() (Collector, error) {
 		filesDesc:                 filesDesc,
 		filesFreeDesc:             filesFreeDesc,
 		roDesc:                    roDesc,
+		mountInfoDesc:             mountInfoDesc,
+		cgroupShmemDesc:           cgroupShmemDesc,
 		devErrors:                 devErrors,
 	}, nil
 }
@@ -138,31 +179,46 @@ func (c *filesystemCollector) Update(ch chan<- prometheus.Metric) (err error) {
 		}
 		seen[s.labels] = true
 
+		labelValues := []string{s.labels.device, s.labels.mountPoint, s.labels.fsType}
+		if *labelMemoryBacked {
+			labelValues = append(labelValues, strconv.FormatBool(memoryBackedFSTypes[s.labels.fsType]))
+		}
+
 		ch <- prometheus.MustNewConstMetric(
 			c.sizeDesc, prometheus.GaugeValue,
-			s.size, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+			s.size, labelValues...,
 		)
 		ch <- prometheus.MustNewConstMetric(
 			c.freeDesc, prometheus.GaugeValue,
-			s.free, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+			s.free, labelValues...,
 		)
 		ch <- prometheus.MustNewConstMetric(
 			c.availDesc, prometheus.GaugeValue,
-			s.avail, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+			s.avail, labelValues...,
 		)
 		ch <- prometheus.MustNewConstMetric(
 			c.filesDesc, prometheus.GaugeValue,
-			s.files, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+			s.files, labelValues...,
 		)
 		ch <- prometheus.MustNewConstMetric(
 			c.filesFreeDesc, prometheus.GaugeValue,
-			s.filesFree, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+			s.filesFree, labelValues...,
 		)
 		ch <- prometheus.MustNewConstMetric(
 			c.roDesc, prometheus.GaugeValue,
-			s.ro, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+			s.ro, labelValues...,
 		)
+		if s.mountInfo != nil {
+			ch <- prometheus.MustNewConstMetric(
+				c.mountInfoDesc, prometheus.GaugeValue,
+				1, s.labels.device, s.labels.mountPoint, s.labels.fsType,
+				s.mountInfo.options, s.mountInfo.superOptions, s.mountInfo.propagation,
+			)
+		}
 	}
 	c.devErrors.Collect(ch)
+	if *labelMemoryBacked {
+		return c.updateMemoryBacked(ch)
+	}
 	return nil
 }
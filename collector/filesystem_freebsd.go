@@ -19,6 +19,7 @@ import (
 	"bytes"
 	"unsafe"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 	"golang.org/x/sys/unix"
 )
@@ -89,3 +90,9 @@ func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
 	}
 	return stats, nil
 }
+
+// updateMemoryBacked does nothing, there are no cgroups to charge memory
+// backed filesystems to.
+func (c *filesystemCollector) updateMemoryBacked(ch chan<- prometheus.Metric) error {
+	return nil
+}
@@ -17,10 +17,16 @@ package collector
 
 import (
 	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
 )
 
@@ -36,6 +42,10 @@ func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
 	if err != nil {
 		return nil, err
 	}
+	mountInfo, err := readMountInfo(procFilePath("self/mountinfo"))
+	if err != nil {
+		log.Debugf("Couldn't read mountinfo: %s", err)
+	}
 	stats = []filesystemStats{}
 	for _, labels := range mps {
 		if c.ignoredMountPointsPattern.MatchString(labels.mountPoint) {
@@ -69,6 +79,7 @@ func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
 			files:     float64(buf.Files),
 			filesFree: float64(buf.Ffree),
 			ro:        ro,
+			mountInfo: mountInfo[labels.mountPoint],
 		})
 	}
 	return stats, nil
@@ -90,3 +101,121 @@ func mountPointDetails() ([]filesystemLabels, error) {
 	}
 	return filesystems, scanner.Err()
 }
+
+func readMountInfo(path string) (map[string]*filesystemMountInfo, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseMountInfo(file)
+}
+
+// parseMountInfo parses /proc/<pid>/mountinfo, see proc(5), into the mount
+// details by mount point. For stacked mounts the last, visible one is
+// returned.
+func parseMountInfo(r io.Reader) (map[string]*filesystemMountInfo, error) {
+	mounts := map[string]*filesystemMountInfo{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		// Optional fields are terminated by a single hyphen.
+		sep := -1
+		for i := 6; i < len(parts); i++ {
+			if parts[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || len(parts) < sep+4 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		info := &filesystemMountInfo{
+			options:      parts[5],
+			superOptions: parts[sep+3],
+			propagation:  "private",
+		}
+		if sep > 6 {
+			info.propagation = strings.Join(parts[6:sep], ",")
+		}
+		mounts[parts[4]] = info
+	}
+	return mounts, scanner.Err()
+}
+
+// updateMemoryBacked exposes the shared memory charged to each top level
+// cgroup. The pages of tmpfs and ramfs files are charged to the cgroup of the
+// process that first touched them, which is not tied to a mount point, so
+// this is as far as their usage can be attributed.
+func (c *filesystemCollector) updateMemoryBacked(ch chan<- prometheus.Metric) error {
+	shmem, err := readCgroupShmem(sysFilePath("fs/cgroup"))
+	if err != nil {
+		return fmt.Errorf("couldn't get cgroup shared memory: %s", err)
+	}
+	for cgroup, bytes := range shmem {
+		ch <- prometheus.MustNewConstMetric(
+			c.cgroupShmemDesc, prometheus.GaugeValue,
+			float64(bytes), cgroup,
+		)
+	}
+	return nil
+}
+
+// readCgroupShmem returns the shared memory of the top level cgroups below
+// root, from the v1 memory controller if mounted and the unified hierarchy
+// otherwise. Hosts without a memory controller have no cgroups to return.
+func readCgroupShmem(root string) (map[string]uint64, error) {
+	dir, key := filepath.Join(root, "memory"), "total_shmem"
+	if _, err := os.Stat(dir); err != nil {
+		dir, key = root, "shmem"
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Debugf("No cgroup hierarchy at %s", root)
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	shmem := map[string]uint64{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		file, err := os.Open(filepath.Join(dir, e.Name(), "memory.stat"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		bytes, ok, err := parseMemoryStat(file, key)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid memory.stat of %s: %s", e.Name(), err)
+		}
+		if ok {
+			shmem["/"+e.Name()] = bytes
+		}
+	}
+	return shmem, nil
+}
+
+// parseMemoryStat returns the value of key in a cgroup memory.stat file.
+func parseMemoryStat(r io.Reader, key string) (uint64, bool, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 || parts[0] != key {
+			continue
+		}
+		value, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return value, true, nil
+	}
+	return 0, false, scanner.Err()
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestMountInfo(t *testing.T) {
+	mounts, err := readMountInfo("fixtures/proc/self/mountinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]filesystemMountInfo{
+		"/sys":                     {options: "rw,nosuid,nodev,noexec,relatime", superOptions: "rw", propagation: "shared:7"},
+		"/proc":                    {options: "rw,nosuid,nodev,noexec,relatime", superOptions: "rw", propagation: "shared:12"},
+		"/":                        {options: "rw,relatime", superOptions: "rw,errors=remount-ro,data=ordered", propagation: "shared:1"},
+		"/boot":                    {options: "rw,relatime", superOptions: "ro", propagation: "shared:29"},
+		"/srv":                     {options: "rw,nodev", superOptions: "rw,size=1024k", propagation: "master:5,unbindable"},
+		"/mnt/remote":              {options: "rw,nosuid,nodev,relatime", superOptions: "rw,user_id=0,group_id=0", propagation: "shared:40"},
+		"/sys/fs/fuse/connections": {options: "rw,relatime", superOptions: "rw", propagation: "shared:41"},
+	}
+	if want, got := len(want), len(mounts); want != got {
+		t.Fatalf("want %d mount points, got %d", want, got)
+	}
+	for mountPoint, w := range want {
+		if got := mounts[mountPoint]; got == nil || *got != w {
+			t.Errorf("want %s %+v, got %+v", mountPoint, w, got)
+		}
+	}
+}
+
+func TestCgroupShmem(t *testing.T) {
+	shmem, err := readCgroupShmem("fixtures/sys/fs/cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]uint64{
+		"/init.scope":   0,
+		"/system.slice": 67108864,
+		"/user.slice":   314572800,
+	}
+	if want, got := len(want), len(shmem); want != got {
+		t.Fatalf("want %d cgroups, got %d", want, got)
+	}
+	for cgroup, w := range want {
+		if got, ok := shmem[cgroup]; !ok || got != w {
+			t.Errorf("want %s shmem %d, got %d", cgroup, w, got)
+		}
+	}
+
+	shmem, err = readCgroupShmem("fixtures/sys/fs/missing")
+	if err != nil || len(shmem) != 0 {
+		t.Errorf("want no cgroups without a hierarchy, got %v, %v", shmem, err)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build experimental_windows,!nofilesystem
+
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"golang.org/x/sys/windows"
+)
+
+const (
+	defIgnoredMountPoints = "^$"
+	defIgnoredFSTypes     = "^$"
+
+	fileReadOnlyVolume = 0x80000
+)
+
+// Drive types of GetDriveType with filesystems worth exposing. Removable
+// and CD-ROM drives often have no medium.
+var filesystemDriveTypes = map[uintptr]bool{
+	3: true, // DRIVE_FIXED
+	4: true, // DRIVE_REMOTE
+	6: true, // DRIVE_RAMDISK
+}
+
+// Expose the fullness of the logical disks.
+func (c *filesystemCollector) GetStats() (stats []filesystemStats, err error) {
+	buf := make([]uint16, 254)
+	n, _, err := procGetLogicalDriveStringsW.Call(uintptr(len(buf)), uintptr(unsafe.Pointer(&buf[0])))
+	if n == 0 || int(n) > len(buf) {
+		return nil, fmt.Errorf("GetLogicalDriveStrings failed: %s", err)
+	}
+
+	stats = []filesystemStats{}
+	// The drives are a list of NUL terminated strings like C:\.
+	for _, root := range strings.Split(windows.UTF16ToString(buf[:n]), "\x00") {
+		if root == "" {
+			continue
+		}
+		if c.ignoredMountPointsPattern.MatchString(root) {
+			log.Debugf("Ignoring mount point: %s", root)
+			continue
+		}
+		rootPtr, err := windows.UTF16PtrFromString(root)
+		if err != nil {
+			return nil, err
+		}
+		if t, _, _ := procGetDriveTypeW.Call(uintptr(unsafe.Pointer(rootPtr))); !filesystemDriveTypes[t] {
+			log.Debugf("Ignoring drive %s of type %d", root, t)
+			continue
+		}
+
+		var (
+			flags  uint32
+			fsName = make([]uint16, windows.MAX_PATH+1)
+		)
+		if r, _, err := procGetVolumeInformationW.Call(uintptr(unsafe.Pointer(rootPtr)), 0, 0, 0, 0,
+			uintptr(unsafe.Pointer(&flags)), uintptr(unsafe.Pointer(&fsName[0])), uintptr(len(fsName))); r == 0 {
+			log.Debugf("GetVolumeInformation on %s failed: %s", root, err)
+			continue
+		}
+		fstype := windows.UTF16ToString(fsName)
+		if c.ignoredFSTypesPattern.MatchString(fstype) {
+			log.Debugf("Ignoring fs type: %s", fstype)
+			continue
+		}
+
+		var avail, size, free uint64
+		if r, _, err := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(rootPtr)),
+			uintptr(unsafe.Pointer(&avail)), uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&free))); r == 0 {
+			log.Debugf("GetDiskFreeSpaceEx on %s failed: %s", root, err)
+			continue
+		}
+
+		var ro float64
+		if flags&fileReadOnlyVolume != 0 {
+			ro = 1
+		}
+		// Windows has no file nodes to count, files are left at 0.
+		stats = append(stats, filesystemStats{
+			labels: filesystemLabels{
+				device:     strings.TrimSuffix(root, `\`),
+				mountPoint: root,
+				fsType:     fstype,
+			},
+			size:  float64(size),
+			free:  float64(free),
+			avail: float64(avail),
+			ro:    ro,
+		})
+	}
+	return stats, nil
+}
+
+// updateMemoryBacked does nothing, there are no cgroups to charge memory
+// backed filesystems to.
+func (c *filesystemCollector) updateMemoryBacked(ch chan<- prometheus.Metric) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestFixtures(t *testing.T) {
+	fixture := freebsdSysctl(t)
+	defer func(f func() sysctlReader) { newSysctlReader = f }(newSysctlReader)
+	newSysctlReader = func() sysctlReader { return fixture }
+	defer func(f func(string) (uint64, error)) { netDevCurCap = f }(netDevCurCap)
+	netDevCurCap = func(device string) (uint64, error) {
+		// RXCSUM, TXCSUM and LRO.
+		return 0x1 | 0x2 | 0x400, nil
+	}
+
+	runFixtureTests(t, []fixtureTest{
+		{name: "cpu", golden: "freebsd/cpu.prom"},
+		{name: "devstat", golden: "freebsd/devstat.prom"},
+		{name: "loadavg", golden: "freebsd/loadavg.prom"},
+		{name: "meminfo", golden: "freebsd/meminfo.prom"},
+		{name: "netdev", golden: "freebsd/netdev.prom"},
+		{name: "netstat", golden: "freebsd/netstat.prom"},
+		{name: "zfs", golden: "freebsd/zfs.prom"},
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestFixtures(t *testing.T) {
+	runFixtureTests(t, []fixtureTest{
+		{name: "acpi"},
+		{name: "bonding"},
+		{name: "bridge"},
+		{name: "cgroup"},
+		{name: "conntrack"},
+		{name: "cpu_vulnerabilities"},
+		{name: "cpupower"},
+		{name: "diskstats"},
+		{name: "dmi"},
+		{name: "drbd"},
+		{name: "entropy"},
+		{name: "filefd"},
+		{name: "fwupd", flags: map[string]string{"collector.fwupd.command": "fixtures/fwupdmgr"}},
+		{name: "hwmon"},
+		{name: "infiniband"},
+		{name: "interrupts"},
+		{name: "ipvs"},
+		{name: "iscsi"},
+		{name: "kernel", flags: map[string]string{"collector.kernel.sysctls": "net.core.somaxconn,fs.file-max,net.ipv4.tcp_congestion_control,net.ipv4.tcp_rmem,net.missing", "collector.kernel.modules-limit": "5"}},
+		{name: "ksmd"},
+		{name: "loadavg"},
+		{name: "mdadm"},
+		{name: "meminfo"},
+		{name: "meminfo_numa"},
+		{name: "mountstats"},
+		{name: "netdev"},
+		{name: "netinfo"},
+		{name: "netstat"},
+		{name: "nfs"},
+		{name: "nvme"},
+		{name: "nvmeof"},
+		{name: "pcie"},
+		{name: "powersupply"},
+		{name: "schedstat"},
+		{name: "sockstat"},
+		{name: "softnet"},
+		{name: "sriov", flags: map[string]string{"collector.sriov.ip-command": "fixtures/ip"}},
+		{name: "stat"},
+		{name: "swaps"},
+		{name: "tpm", flags: map[string]string{"collector.tpm.getcap-command": "fixtures/tpm2_getcap"}},
+		{name: "usb"},
+		{name: "vmstat"},
+		{name: "writeback"},
+		{name: "zfs"},
+		{name: "zram"},
+		{name: "gpu"},
+		{name: "hwrng"},
+		{name: "iouring"},
+		{name: "procfd"},
+		{name: "loop"},
+		{name: "megacli", flags: map[string]string{"collector.megacli.command": "fixtures/megacli"}},
+	})
+}
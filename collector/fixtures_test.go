@@ -0,0 +1,169 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+var updateGolden = flag.Bool("update", false, "Update the golden files of the fixture tests.")
+
+// fixtureTest runs a collector against the fixture trees and compares its
+// full output against fixtures/golden/<name>.prom.
+type fixtureTest struct {
+	name string
+	// flags are set before the collector is created and reset afterwards.
+	flags map[string]string
+	// golden is the golden file below fixtures/golden, <name>.prom if empty.
+	golden string
+}
+
+func runFixtureTests(t *testing.T, tests []fixtureTest) {
+	for _, test := range tests {
+		flags := map[string]string{
+			"collector.procfs": "fixtures/proc",
+			"collector.sysfs":  "fixtures/sys",
+		}
+		for k, v := range test.flags {
+			flags[k] = v
+		}
+		reset := map[string]string{}
+		for k, v := range flags {
+			reset[k] = flag.Lookup(k).Value.String()
+			if err := flag.Set(k, v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		got, err := collectFixture(test.name)
+		for k, v := range reset {
+			flag.Set(k, v)
+		}
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		golden := filepath.Join("fixtures", "golden", test.name+".prom")
+		if test.golden != "" {
+			golden = filepath.Join("fixtures", "golden", test.golden)
+		}
+		if *updateGolden {
+			if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if !bytes.Equal(want, got) {
+			t.Errorf("%s: output differs from %s, run with -update to regenerate:\n%s",
+				test.name, golden, lineDiff(string(want), string(got)))
+		}
+	}
+}
+
+// collectFixture creates the named collector and returns the output of a
+// single Update in the text exposition format.
+func collectFixture(name string) ([]byte, error) {
+	fn, ok := Factories[name]
+	if !ok {
+		return nil, fmt.Errorf("collector '%s' not available", name)
+	}
+	c, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan prometheus.Metric)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.Update(ch)
+		close(ch)
+	}()
+	var metrics fixtureMetrics
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if len(metrics) == 0 {
+		return buf.Bytes(), nil
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(metrics); err != nil {
+		return nil, err
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	for _, mf := range mfs {
+		if _, err := expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+type fixtureMetrics []prometheus.Metric
+
+func (f fixtureMetrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range f {
+		ch <- m.Desc()
+	}
+}
+
+func (f fixtureMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range f {
+		ch <- m
+	}
+}
+
+// lineDiff returns the lines only in want (-) and only in got (+).
+func lineDiff(want, got string) string {
+	seen := map[string]int{}
+	for _, l := range strings.Split(got, "\n") {
+		seen[l]++
+	}
+	var diff []string
+	for _, l := range strings.Split(want, "\n") {
+		if seen[l] > 0 {
+			seen[l]--
+			continue
+		}
+		diff = append(diff, "-"+l)
+	}
+	for _, l := range strings.Split(got, "\n") {
+		if seen[l] > 0 {
+			seen[l]--
+			diff = append(diff, "+"+l)
+		}
+	}
+	return strings.Join(diff, "\n")
+}
@@ -0,0 +1,203 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofuse
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	fuseSubsystem = "fuse"
+)
+
+var (
+	fuseProbeTimeout = flag.Duration("collector.fuse.probe-timeout", time.Second, "Time a FUSE mount has to answer statfs to be considered responsive.")
+)
+
+type fuseMount struct {
+	mountPoint, fsType string
+	// Minor device number of the mount, which names its connection in
+	// /sys/fs/fuse/connections.
+	minor string
+}
+
+type fuseCollector struct {
+	responsive, probeDuration         typedDesc
+	waiting, threshold, maxBackground typedDesc
+	congested                         typedDesc
+
+	mtx sync.Mutex
+	// Mount points with a statfs that didn't return yet. A hung daemon
+	// blocks the probe forever, so it isn't repeated until it returns.
+	pending map[string]bool
+}
+
+func init() {
+	Factories["fuse"] = NewFUSECollector
+}
+
+// NewFUSECollector returns a new Collector exposing the request queue and
+// responsiveness of FUSE mounts.
+func NewFUSECollector() (Collector, error) {
+	labels := []string{"mountpoint", "fstype"}
+	return &fuseCollector{
+		responsive: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fuseSubsystem, "responsive"),
+			"Whether the FUSE daemon answered statfs within the probe timeout.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		probeDuration: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fuseSubsystem, "probe_duration_seconds"),
+			"Time the FUSE daemon took to answer statfs.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		waiting: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fuseSubsystem, "requests_waiting"),
+			"Requests waiting to be answered by the FUSE daemon.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		threshold: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fuseSubsystem, "congestion_threshold"),
+			"Number of background requests at which the FUSE connection is considered congested.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		maxBackground: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fuseSubsystem, "max_background"),
+			"Maximum number of outstanding background requests of the FUSE connection.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		congested: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fuseSubsystem, "congested"),
+			"Whether the waiting requests reach the congestion threshold.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		pending: map[string]bool{},
+	}, nil
+}
+
+func (c *fuseCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("self/mountinfo"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	mounts, err := parseFUSEMounts(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse mountinfo: %s", err)
+	}
+
+	for _, m := range mounts {
+		connPath := sysFilePath(path.Join("fs/fuse/connections", m.minor))
+		waiting, err := readUintFromFile(path.Join(connPath, "waiting"))
+		if err != nil {
+			return fmt.Errorf("couldn't get FUSE connection of %s: %s", m.mountPoint, err)
+		}
+		threshold, err := readUintFromFile(path.Join(connPath, "congestion_threshold"))
+		if err != nil {
+			return err
+		}
+		maxBackground, err := readUintFromFile(path.Join(connPath, "max_background"))
+		if err != nil {
+			return err
+		}
+		congested := 0.0
+		if threshold > 0 && waiting >= threshold {
+			congested = 1
+		}
+		ch <- c.waiting.mustNewConstMetric(float64(waiting), m.mountPoint, m.fsType)
+		ch <- c.threshold.mustNewConstMetric(float64(threshold), m.mountPoint, m.fsType)
+		ch <- c.maxBackground.mustNewConstMetric(float64(maxBackground), m.mountPoint, m.fsType)
+		ch <- c.congested.mustNewConstMetric(congested, m.mountPoint, m.fsType)
+
+		responsive, duration := c.probe(m.mountPoint)
+		ch <- c.responsive.mustNewConstMetric(responsive, m.mountPoint, m.fsType)
+		if responsive == 1 {
+			ch <- c.probeDuration.mustNewConstMetric(duration.Seconds(), m.mountPoint, m.fsType)
+		}
+	}
+	return nil
+}
+
+// probe runs statfs on the mount point, returning 1 and its duration if it
+// returns within the timeout. Errors of statfs count as responsive, as the
+// daemon answered.
+func (c *fuseCollector) probe(mountPoint string) (float64, time.Duration) {
+	c.mtx.Lock()
+	if c.pending[mountPoint] {
+		c.mtx.Unlock()
+		return 0, 0
+	}
+	c.pending[mountPoint] = true
+	c.mtx.Unlock()
+
+	done := make(chan time.Duration, 1)
+	go func() {
+		start := time.Now()
+		syscall.Statfs(mountPoint, new(syscall.Statfs_t))
+		done <- time.Since(start)
+
+		c.mtx.Lock()
+		delete(c.pending, mountPoint)
+		c.mtx.Unlock()
+	}()
+
+	select {
+	case d := <-done:
+		return 1, d
+	case <-time.After(*fuseProbeTimeout):
+		return 0, 0
+	}
+}
+
+// parseFUSEMounts returns the FUSE mounts of a mountinfo file, see proc(5).
+func parseFUSEMounts(r io.Reader) ([]fuseMount, error) {
+	var mounts []fuseMount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		sep := -1
+		for i := 6; i < len(parts); i++ {
+			if parts[i] == "-" {
+				sep = i
+				break
+			}
+		}
+		if sep < 0 || len(parts) < sep+4 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		fsType := parts[sep+1]
+		if fsType != "fuse" && fsType != "fuseblk" && !strings.HasPrefix(fsType, "fuse.") {
+			continue
+		}
+		dev := strings.SplitN(parts[2], ":", 2)
+		if len(dev) != 2 {
+			return nil, fmt.Errorf("invalid device in line: %s", scanner.Text())
+		}
+		mounts = append(mounts, fuseMount{mountPoint: parts[4], fsType: fsType, minor: dev[1]})
+	}
+	return mounts, scanner.Err()
+}
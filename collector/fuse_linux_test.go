@@ -0,0 +1,39 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFUSEMounts(t *testing.T) {
+	file, err := os.Open("fixtures/proc/self/mountinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mounts, err := parseFUSEMounts(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(mounts); want != got {
+		t.Fatalf("want %d FUSE mounts, got %d", want, got)
+	}
+	want := fuseMount{mountPoint: "/mnt/remote", fsType: "fuse.sshfs", minor: "52"}
+	if got := mounts[0]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
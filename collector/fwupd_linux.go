@@ -0,0 +1,144 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nofwupd
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const fwupdSubsystem = "fwupd"
+
+var fwupdCommand = flag.String("collector.fwupd.command", "fwupdmgr", "Command to run fwupdmgr of fwupd.")
+
+// fwupdUpdateStates are the states of the last firmware update of a device.
+var fwupdUpdateStates = []string{"pending", "success", "failed", "failed-transient", "needs-reboot"}
+
+type fwupdDevice struct {
+	Name        string
+	DeviceID    string `json:"DeviceId"`
+	Vendor      string
+	Version     string
+	Flags       []string
+	UpdateState string
+	Releases    []struct {
+		Version string
+	}
+}
+
+type fwupdCollector struct {
+	cli              string
+	info             typedDesc
+	updatesAvailable typedDesc
+	updateState      typedDesc
+}
+
+func init() {
+	Factories["fwupd"] = NewFwupdCollector
+}
+
+// NewFwupdCollector returns a new Collector exposing the devices fwupd can
+// update, their available firmware updates and the result of their last
+// update.
+func NewFwupdCollector() (Collector, error) {
+	return &fwupdCollector{
+		cli: *fwupdCommand,
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fwupdSubsystem, "device_info"),
+			"Firmware of an updatable device known to fwupd, value is always 1.",
+			[]string{"device_id", "name", "vendor", "version"}, nil,
+		), prometheus.GaugeValue},
+		updatesAvailable: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fwupdSubsystem, "updates_available"),
+			"Number of firmware releases newer than the installed one available for the device.",
+			[]string{"device_id"}, nil,
+		), prometheus.GaugeValue},
+		updateState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, fwupdSubsystem, "update_state"),
+			"State of the last firmware update of the device, 1 for the current state.",
+			[]string{"device_id", "state"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *fwupdCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := c.devices("get-devices")
+	if err != nil {
+		return err
+	}
+	updates, err := c.devices("get-updates")
+	if err != nil {
+		return err
+	}
+	available := map[string]int{}
+	for _, d := range updates {
+		available[d.DeviceID] = len(d.Releases)
+	}
+
+	for _, d := range devices {
+		if !d.updatable() {
+			continue
+		}
+		ch <- c.info.mustNewConstMetric(1, d.DeviceID, d.Name, d.Vendor, d.Version)
+		ch <- c.updatesAvailable.mustNewConstMetric(float64(available[d.DeviceID]), d.DeviceID)
+		if d.UpdateState == "" || d.UpdateState == "unknown" {
+			continue
+		}
+		for _, s := range fwupdUpdateStates {
+			v := 0.0
+			if s == d.UpdateState {
+				v = 1
+			}
+			ch <- c.updateState.mustNewConstMetric(v, d.DeviceID, s)
+		}
+	}
+	return nil
+}
+
+func (d fwupdDevice) updatable() bool {
+	for _, f := range d.Flags {
+		if f == "updatable" {
+			return true
+		}
+	}
+	return false
+}
+
+// devices runs the fwupdmgr command with --json and returns its devices.
+// fwupdmgr exits with status 2 if there is nothing to report.
+func (c *fwupdCollector) devices(command string) ([]fwupdDevice, error) {
+	out, err := exec.Command(c.cli, command, "--json").Output()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == 2 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't run %s %s: %s", c.cli, command, err)
+	}
+	var result struct {
+		Devices []fwupdDevice
+	}
+	if err := json.Unmarshal(out, &result); err != nil {
+		return nil, fmt.Errorf("couldn't parse output of %s %s: %s", c.cli, command, err)
+	}
+	return result.Devices, nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noglusterfs
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	glusterfsSubsystem = "glusterfs"
+
+	// Extended attribute making the io-stats translator of the client dump
+	// its statistics to the file given as value.
+	glusterfsDumpXattr = "trusted.io-stats-dump"
+)
+
+var (
+	glusterfsDumpDir = flag.String("collector.glusterfs.dump-dir", os.TempDir(), "Directory to have GlusterFS clients dump their io-stats to.")
+)
+
+type glusterfsFop struct {
+	calls      float64
+	avgLatency float64
+	maxLatency float64
+}
+
+type glusterfsStats struct {
+	bytesRead, bytesWritten float64
+	fops                    map[string]glusterfsFop
+}
+
+type glusterfsCollector struct {
+	bytesRead, bytesWritten typedDesc
+	calls, latency, maxLat  typedDesc
+}
+
+func init() {
+	Factories["glusterfs"] = NewGlusterFSCollector
+}
+
+// NewGlusterFSCollector returns a new Collector exposing the io-stats of
+// mounted GlusterFS volumes as seen by their clients.
+func NewGlusterFSCollector() (Collector, error) {
+	labels := []string{"volume", "mountpoint"}
+	fopLabels := []string{"volume", "mountpoint", "fop"}
+	return &glusterfsCollector{
+		bytesRead: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, glusterfsSubsystem, "read_bytes_total"),
+			"Bytes read from the volume by the client.",
+			labels, nil,
+		), prometheus.CounterValue},
+		bytesWritten: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, glusterfsSubsystem, "written_bytes_total"),
+			"Bytes written to the volume by the client.",
+			labels, nil,
+		), prometheus.CounterValue},
+		calls: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, glusterfsSubsystem, "fop_calls_total"),
+			"File operations issued by the client.",
+			fopLabels, nil,
+		), prometheus.CounterValue},
+		latency: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, glusterfsSubsystem, "fop_latency_seconds_total"),
+			"Time spent waiting for file operations by the client.",
+			fopLabels, nil,
+		), prometheus.CounterValue},
+		maxLat: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, glusterfsSubsystem, "fop_max_latency_seconds"),
+			"Highest latency of the file operation seen by the client.",
+			fopLabels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *glusterfsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	mps, err := glusterfsMounts()
+	if err != nil {
+		return fmt.Errorf("couldn't get mounts: %s", err)
+	}
+	for _, mp := range mps {
+		// The device of a GlusterFS mount is <server>:/<volume>.
+		volume := mp.device[strings.LastIndex(mp.device, "/")+1:]
+
+		stats, err := dumpGlusterFSStats(mp.mountPoint)
+		if err != nil {
+			return fmt.Errorf("couldn't get io-stats of %s: %s", mp.mountPoint, err)
+		}
+		ch <- c.bytesRead.mustNewConstMetric(stats.bytesRead, volume, mp.mountPoint)
+		ch <- c.bytesWritten.mustNewConstMetric(stats.bytesWritten, volume, mp.mountPoint)
+		for name, fop := range stats.fops {
+			ch <- c.calls.mustNewConstMetric(fop.calls, volume, mp.mountPoint, name)
+			ch <- c.latency.mustNewConstMetric(fop.calls*fop.avgLatency, volume, mp.mountPoint, name)
+			ch <- c.maxLat.mustNewConstMetric(fop.maxLatency, volume, mp.mountPoint, name)
+		}
+	}
+	return nil
+}
+
+type glusterfsMount struct {
+	device, mountPoint string
+}
+
+// glusterfsMounts returns the GlusterFS FUSE mounts listed in /proc/mounts.
+func glusterfsMounts() ([]glusterfsMount, error) {
+	file, err := os.Open(procFilePath("mounts"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseGlusterFSMounts(file)
+}
+
+func parseGlusterFSMounts(r io.Reader) ([]glusterfsMount, error) {
+	var mounts []glusterfsMount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 3 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		if parts[2] == "fuse.glusterfs" {
+			mounts = append(mounts, glusterfsMount{device: parts[0], mountPoint: parts[1]})
+		}
+	}
+	return mounts, scanner.Err()
+}
+
+// dumpGlusterFSStats has the client of the mount point dump its io-stats,
+// which requires latency-measurement and count-fop-hits to be enabled on the
+// volume for the operation statistics.
+func dumpGlusterFSStats(mountPoint string) (*glusterfsStats, error) {
+	file, err := ioutil.TempFile(*glusterfsDumpDir, "node_exporter_glusterfs")
+	if err != nil {
+		return nil, err
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := syscall.Setxattr(mountPoint, glusterfsDumpXattr, []byte(file.Name()), 0); err != nil {
+		return nil, err
+	}
+
+	file, err = os.Open(file.Name())
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseGlusterFSStats(file)
+}
+
+// parseGlusterFSStats parses the cumulative section of an io-stats dump.
+func parseGlusterFSStats(r io.Reader) (*glusterfsStats, error) {
+	var (
+		stats      = &glusterfsStats{fops: map[string]glusterfsFop{}}
+		cumulative bool
+		scanner    = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "===") {
+			cumulative = strings.Contains(line, "Cumulative")
+			continue
+		}
+		if !cumulative {
+			continue
+		}
+
+		if parts := strings.SplitN(line, ":", 2); len(parts) == 2 {
+			key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			var err error
+			switch key {
+			case "BytesRead":
+				stats.bytesRead, err = strconv.ParseFloat(value, 64)
+			case "BytesWritten":
+				stats.bytesWritten, err = strconv.ParseFloat(value, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid line: %s", line)
+			}
+			continue
+		}
+
+		// <FOP> <calls> <avg> us <min> us <max> us
+		fields := strings.Fields(line)
+		if len(fields) != 8 || fields[3] != "us" || fields[7] != "us" {
+			continue
+		}
+		var (
+			fop  glusterfsFop
+			errs [3]error
+		)
+		fop.calls, errs[0] = strconv.ParseFloat(fields[1], 64)
+		fop.avgLatency, errs[1] = strconv.ParseFloat(fields[2], 64)
+		fop.maxLatency, errs[2] = strconv.ParseFloat(fields[6], 64)
+		for _, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("invalid line: %s", line)
+			}
+		}
+		fop.avgLatency /= 1e6
+		fop.maxLatency /= 1e6
+		stats.fops[fields[0]] = fop
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGlusterFSStats(t *testing.T) {
+	file, err := os.Open("fixtures/glusterfs_io_stats.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseGlusterFSStats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1048576.0, stats.bytesRead; want != got {
+		t.Errorf("want %f bytes read, got %f", want, got)
+	}
+	if want, got := 524288.0, stats.bytesWritten; want != got {
+		t.Errorf("want %f bytes written, got %f", want, got)
+	}
+	if want, got := 4, len(stats.fops); want != got {
+		t.Fatalf("want %d fops, got %d", want, got)
+	}
+	want := glusterfsFop{calls: 149, avgLatency: 0.0015, maxLatency: 0.25}
+	if got := stats.fops["WRITE"]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestGlusterFSMounts(t *testing.T) {
+	mounts, err := parseGlusterFSMounts(strings.NewReader(`/dev/sda1 / ext4 rw,relatime 0 0
+gluster1:/data /mnt/data fuse.glusterfs rw,relatime,user_id=0,group_id=0 0 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []glusterfsMount{{device: "gluster1:/data", mountPoint: "/mnt/data"}}
+	if len(mounts) != len(want) || mounts[0] != want[0] {
+		t.Errorf("want %+v, got %+v", want, mounts)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nogpu
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const gpuSubsystem = "gpu"
+
+var (
+	gpuNvidiaSMI = flag.String("collector.gpu.nvidia-smi", "", "Command to run nvidia-smi to include NVIDIA GPUs, disabled if empty.")
+)
+
+type gpuMetric struct {
+	name, help string
+	valueType  prometheus.ValueType
+}
+
+var gpuMetrics = []gpuMetric{
+	{"busy_ratio", "Fraction of time the GPU was busy.", prometheus.GaugeValue},
+	{"memory_used_bytes", "Video memory used on the GPU.", prometheus.GaugeValue},
+	{"memory_total_bytes", "Video memory of the GPU.", prometheus.GaugeValue},
+	{"temperature_celsius", "Temperature of the GPU.", prometheus.GaugeValue},
+	{"power_watts", "Power drawn by the GPU.", prometheus.GaugeValue},
+	{"energy_joules_total", "Energy consumed by the GPU.", prometheus.CounterValue},
+}
+
+// gpuStats holds the metrics of a GPU by name, drivers expose different
+// subsets of them.
+type gpuStats struct {
+	card, driver string
+	values       map[string]float64
+}
+
+type gpuCollector struct {
+	descs map[string]*typedDesc
+}
+
+func init() {
+	Factories["gpu"] = NewGPUCollector
+}
+
+// NewGPUCollector returns a new Collector exposing utilization, memory,
+// temperature and power of GPUs.
+func NewGPUCollector() (Collector, error) {
+	c := &gpuCollector{descs: map[string]*typedDesc{}}
+	for _, m := range gpuMetrics {
+		c.descs[m.name] = &typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, gpuSubsystem, m.name),
+			m.help, []string{"card", "driver"}, nil,
+		), m.valueType}
+	}
+	return c, nil
+}
+
+func (c *gpuCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	gpus, err := readDRMGPUs(sysFilePath("class/drm"))
+	if err != nil {
+		return err
+	}
+	if *gpuNvidiaSMI != "" {
+		out, err := exec.Command(*gpuNvidiaSMI,
+			"--query-gpu=index,utilization.gpu,memory.used,memory.total,temperature.gpu,power.draw",
+			"--format=csv,noheader,nounits").Output()
+		if err != nil {
+			return fmt.Errorf("couldn't run nvidia-smi: %s", err)
+		}
+		nvidia, err := parseNvidiaSMI(bytes.NewReader(out))
+		if err != nil {
+			return fmt.Errorf("couldn't parse nvidia-smi output: %s", err)
+		}
+		gpus = append(gpus, nvidia...)
+	}
+
+	for _, gpu := range gpus {
+		for name, v := range gpu.values {
+			ch <- c.descs[name].mustNewConstMetric(v, gpu.card, gpu.driver)
+		}
+	}
+	return nil
+}
+
+// readDRMGPUs reads the GPUs of /sys/class/drm. Attributes are read as
+// provided by the driver: amdgpu exposes the busy percentage and VRAM
+// usage, both amdgpu and i915 provide a hwmon device for temperature and
+// power.
+func readDRMGPUs(root string) ([]gpuStats, error) {
+	cards, err := filepath.Glob(path.Join(root, "card[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var gpus []gpuStats
+	for _, card := range cards {
+		// Skip connectors like card0-DP-1.
+		if strings.Contains(path.Base(card), "-") {
+			continue
+		}
+		devPath := path.Join(card, "device")
+		driver, err := os.Readlink(path.Join(devPath, "driver"))
+		if err != nil {
+			continue
+		}
+		gpu := gpuStats{card: path.Base(card), driver: path.Base(driver), values: map[string]float64{}}
+
+		for file, m := range map[string]struct {
+			name  string
+			scale float64
+		}{
+			"gpu_busy_percent":    {"busy_ratio", 0.01},
+			"mem_info_vram_used":  {"memory_used_bytes", 1},
+			"mem_info_vram_total": {"memory_total_bytes", 1},
+		} {
+			if v, err := readUintFromFile(path.Join(devPath, file)); err == nil {
+				gpu.values[m.name] = float64(v) * m.scale
+			}
+		}
+
+		hwmons, err := filepath.Glob(path.Join(devPath, "hwmon", "hwmon*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, hwmon := range hwmons {
+			for file, m := range map[string]struct {
+				name  string
+				scale float64
+			}{
+				"temp1_input":    {"temperature_celsius", 1e-3},
+				"power1_average": {"power_watts", 1e-6},
+				"energy1_input":  {"energy_joules_total", 1e-6},
+			} {
+				if v, err := readUintFromFile(path.Join(hwmon, file)); err == nil {
+					gpu.values[m.name] = float64(v) * m.scale
+				}
+			}
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, nil
+}
+
+// parseNvidiaSMI parses the CSV output of nvidia-smi --query-gpu for the
+// index, utilization in percent, used and total memory in MiB, temperature
+// and power draw. Values the GPU doesn't support are printed as [N/A] and
+// skipped.
+func parseNvidiaSMI(r io.Reader) ([]gpuStats, error) {
+	var (
+		gpus    []gpuStats
+		scanner = bufio.NewScanner(r)
+		columns = []struct {
+			name  string
+			scale float64
+		}{
+			{"busy_ratio", 0.01},
+			{"memory_used_bytes", 1024 * 1024},
+			{"memory_total_bytes", 1024 * 1024},
+			{"temperature_celsius", 1},
+			{"power_watts", 1},
+		}
+	)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) != len(columns)+1 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		gpu := gpuStats{
+			card:   "nvidia" + strings.TrimSpace(fields[0]),
+			driver: "nvidia",
+			values: map[string]float64{},
+		}
+		for i, col := range columns {
+			v, err := strconv.ParseFloat(strings.TrimSpace(fields[i+1]), 64)
+			if err != nil {
+				continue
+			}
+			gpu.values[col.name] = v * col.scale
+		}
+		gpus = append(gpus, gpu)
+	}
+	return gpus, scanner.Err()
+}
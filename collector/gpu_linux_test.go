@@ -0,0 +1,73 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDRMGPUs(t *testing.T) {
+	gpus, err := readDRMGPUs("fixtures/sys/class/drm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gpuStats{
+		{card: "card0", driver: "amdgpu", values: map[string]float64{
+			"busy_ratio":          0.37,
+			"memory_used_bytes":   1073741824,
+			"memory_total_bytes":  8573157376,
+			"temperature_celsius": 54,
+			"power_watts":         42,
+		}},
+		{card: "card1", driver: "i915", values: map[string]float64{
+			"energy_joules_total": 123456,
+		}},
+	}
+	if !reflect.DeepEqual(want, gpus) {
+		t.Errorf("want %+v, got %+v", want, gpus)
+	}
+}
+
+func TestNvidiaSMI(t *testing.T) {
+	file, err := os.Open("fixtures/nvidia_smi.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	gpus, err := parseNvidiaSMI(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []gpuStats{
+		{card: "nvidia0", driver: "nvidia", values: map[string]float64{
+			"busy_ratio":          0.45,
+			"memory_used_bytes":   1024 * 1024 * 1024,
+			"memory_total_bytes":  16384 * 1024 * 1024,
+			"temperature_celsius": 60,
+			"power_watts":         75.31,
+		}},
+		{card: "nvidia1", driver: "nvidia", values: map[string]float64{
+			"busy_ratio":          0,
+			"memory_used_bytes":   0,
+			"memory_total_bytes":  16384 * 1024 * 1024,
+			"temperature_celsius": 35,
+		}},
+	}
+	if !reflect.DeepEqual(want, gpus) {
+		t.Errorf("want %+v, got %+v", want, gpus)
+	}
+}
@@ -14,6 +14,7 @@
 package collector
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"strconv"
@@ -42,3 +43,50 @@ func readUintFromFile(path string) (uint64, error) {
 	}
 	return value, nil
 }
+
+func readStringFromFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// parseCPUList parses a list of cpus in the kernel's format, e.g. "0-3,8".
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds, err := splitToInts(part, "-")
+		if err != nil {
+			return nil, err
+		}
+		switch len(bounds) {
+		case 1:
+			cpus = append(cpus, bounds[0])
+		case 2:
+			for i := bounds[0]; i <= bounds[1]; i++ {
+				cpus = append(cpus, i)
+			}
+		default:
+			return nil, fmt.Errorf("invalid cpu range '%s'", part)
+		}
+	}
+	return cpus, nil
+}
+
+// alignTo rounds n up to a multiple of align.
+func alignTo(n, align int) int {
+	return (n + align - 1) / align * align
+}
+
+// cString returns b up to the first NUL byte.
+func cString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
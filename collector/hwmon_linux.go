@@ -37,6 +37,7 @@ var (
 	hwmonFilenameFormat     = regexp.MustCompile(`^(?P<type>[^0-9]+)(?P<id>[0-9]*)?(_(?P<property>.+))?$`)
 	hwmonLabelDesc          = []string{"chip", "sensor"}
 	hwmonChipNameLabelDesc  = []string{"chip", "chip_name"}
+	hwmonSensorLabelDesc    = []string{"chip", "sensor", "label"}
 	hwmonSensorTypes        = []string{
 		"vrm", "beep_enable", "update_interval", "in", "cpu", "fan",
 		"pwm", "temp", "curr", "power", "energy", "humidity",
@@ -164,17 +165,25 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) (e
 	}
 
 	// format all sensors
+	sensorNames := hwmonSensorNames(data)
 	for sensor, sensorData := range data {
 
 		_, sensorType, _, _ := explodeSensorFilename(sensor)
 
+		sensor = sensorNames[sensor]
+		labels := []string{hwmonName, sensor}
+
 		if labelText, ok := sensorData["label"]; ok {
-			label := cleanMetricName(labelText)
-			if label != "" {
-				sensor = label
-			}
+			// sensor metadata, keeps the label as written by the driver
+			desc := prometheus.NewDesc(
+				"node_hwmon_sensor_label",
+				"Label of the sensor as reported by the driver, value is always 1.",
+				hwmonSensorLabelDesc,
+				nil,
+			)
+			ch <- prometheus.MustNewConstMetric(
+				desc, prometheus.GaugeValue, 1.0, hwmonName, sensor, strings.TrimSpace(labelText))
 		}
-		labels := []string{hwmonName, sensor}
 
 		if sensorType == "beep_enable" {
 			value := 0.0
@@ -313,6 +322,29 @@ func (c *hwMonCollector) updateHwmon(ch chan<- prometheus.Metric, dir string) (e
 	return nil
 }
 
+// hwmonSensorNames maps the sensors of a chip to the names used in the
+// sensor label. Sensors are named after their label file if they have one,
+// unless the labels of several sensors clean up to the same name, as
+// duplicates would fail the scrape. Those keep names like in0.
+func hwmonSensorNames(data map[string]map[string]string) map[string]string {
+	names := make(map[string]string, len(data))
+	count := make(map[string]int, len(data))
+	for sensor, sensorData := range data {
+		name := sensor
+		if label := cleanMetricName(sensorData["label"]); label != "" {
+			name = label
+		}
+		names[sensor] = name
+		count[name]++
+	}
+	for sensor, name := range names {
+		if count[name] > 1 {
+			names[sensor] = sensor
+		}
+	}
+	return names
+}
+
 func (c *hwMonCollector) hwmonName(dir string) (string, error) {
 	// generate a name for a sensor path
 
@@ -0,0 +1,211 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nohwrng
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	hwrngSubsystem = "hwrng"
+
+	// Bytes read from the device by the read test.
+	hwrngTestSize = 16
+)
+
+var (
+	hwrngDevice      = flag.String("collector.hwrng.device", "", "Device to read from to test the hardware RNG, e.g. /dev/hwrng. Disabled if empty, as reads compete with rngd for entropy.")
+	hwrngTestTimeout = flag.Duration("collector.hwrng.timeout", time.Second, "Time the hardware RNG has to answer the read test.")
+)
+
+type hwrngState struct {
+	current   string
+	available []string
+	quality   *float64
+}
+
+type hwrngCollector struct {
+	source, quality, jitter typedDesc
+	failures, timeouts      typedDesc
+
+	mtx      sync.Mutex
+	pending  bool
+	failureN float64
+	timeoutN float64
+}
+
+func init() {
+	Factories["hwrng"] = NewHWRNGCollector
+}
+
+// NewHWRNGCollector returns a new Collector exposing the hardware random
+// number generators and their health.
+func NewHWRNGCollector() (Collector, error) {
+	return &hwrngCollector{
+		source: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwrngSubsystem, "source_current"),
+			"Whether the hardware RNG source is the one feeding the kernel.",
+			[]string{"source"}, nil,
+		), prometheus.GaugeValue},
+		quality: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwrngSubsystem, "quality"),
+			"Estimated entropy per mill of the bits of the current hardware RNG.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		jitter: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwrngSubsystem, "jitterentropy_available"),
+			"Whether the CPU jitter entropy RNG is registered with the crypto API.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		failures: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwrngSubsystem, "read_failures_total"),
+			"Read tests of the hardware RNG device that failed.",
+			nil, nil,
+		), prometheus.CounterValue},
+		timeouts: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, hwrngSubsystem, "read_timeouts_total"),
+			"Read tests of the hardware RNG device that didn't complete in time.",
+			nil, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *hwrngCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	state, err := readHWRNGState(sysFilePath("class/misc/hw_random"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		for _, s := range state.available {
+			v := 0.0
+			if s == state.current {
+				v = 1.0
+			}
+			ch <- c.source.mustNewConstMetric(v, s)
+		}
+		if state.quality != nil {
+			ch <- c.quality.mustNewConstMetric(*state.quality)
+		}
+	}
+
+	file, err := os.Open(procFilePath("crypto"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	jitter, err := parseCryptoJitterEntropy(file)
+	if err != nil {
+		return err
+	}
+	ch <- c.jitter.mustNewConstMetric(jitter)
+
+	if *hwrngDevice == "" {
+		return nil
+	}
+	c.test(*hwrngDevice)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	ch <- c.failures.mustNewConstMetric(c.failureN)
+	ch <- c.timeouts.mustNewConstMetric(c.timeoutN)
+	return nil
+}
+
+// test reads from the device, counting failures and timeouts. A read that
+// timed out isn't repeated until it returns.
+func (c *hwrngCollector) test(device string) {
+	c.mtx.Lock()
+	if c.pending {
+		c.timeoutN++
+		c.mtx.Unlock()
+		return
+	}
+	c.pending = true
+	c.mtx.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := readHWRNG(device)
+		c.mtx.Lock()
+		c.pending = false
+		c.mtx.Unlock()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			c.mtx.Lock()
+			c.failureN++
+			c.mtx.Unlock()
+		}
+	case <-time.After(*hwrngTestTimeout):
+		c.mtx.Lock()
+		c.timeoutN++
+		c.mtx.Unlock()
+	}
+}
+
+func readHWRNG(device string) error {
+	file, err := os.Open(device)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.ReadFull(file, make([]byte, hwrngTestSize))
+	return err
+}
+
+// readHWRNGState reads the sources of the hw_random misc device. The
+// current source is "none" without a hardware RNG.
+func readHWRNGState(hwrngPath string) (*hwrngState, error) {
+	current, err := readStringFromFile(path.Join(hwrngPath, "rng_current"))
+	if err != nil {
+		return nil, err
+	}
+	available, err := readStringFromFile(path.Join(hwrngPath, "rng_available"))
+	if err != nil {
+		return nil, err
+	}
+	state := &hwrngState{current: current, available: strings.Fields(available)}
+	// Only provided by kernels since 5.17.
+	if q, err := readUintFromFile(path.Join(hwrngPath, "rng_quality")); err == nil {
+		quality := float64(q)
+		state.quality = &quality
+	}
+	return state, nil
+}
+
+// parseCryptoJitterEntropy returns 1 if /proc/crypto lists the jitter
+// entropy RNG.
+func parseCryptoJitterEntropy(r io.Reader) (float64, error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == "name" && strings.TrimSpace(parts[1]) == "jitterentropy_rng" {
+			return 1, nil
+		}
+	}
+	return 0, scanner.Err()
+}
@@ -0,0 +1,52 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestHWRNGState(t *testing.T) {
+	state, err := readHWRNGState("fixtures/sys/class/misc/hw_random")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "tpm-rng-0", state.current; want != got {
+		t.Errorf("want current source %s, got %s", want, got)
+	}
+	if want, got := []string{"tpm-rng-0", "virtio_rng.0"}, state.available; !reflect.DeepEqual(want, got) {
+		t.Errorf("want available sources %v, got %v", want, got)
+	}
+	if want, got := 1024.0, *state.quality; want != got {
+		t.Errorf("want quality %f, got %f", want, got)
+	}
+}
+
+func TestCryptoJitterEntropy(t *testing.T) {
+	file, err := os.Open("fixtures/proc/crypto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	jitter, err := parseCryptoJitterEntropy(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.0, jitter; want != got {
+		t.Errorf("want %f, got %f", want, got)
+	}
+}
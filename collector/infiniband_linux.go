@@ -0,0 +1,242 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noinfiniband
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const infinibandSubsystem = "infiniband"
+
+// infinibandCounters are the port counters exposed, by their file name in
+// ports/<port>/counters. The data counters are in units of four bytes.
+var infinibandCounters = []struct {
+	file, name, help string
+	scale            float64
+}{
+	{"port_rcv_data", "port_data_received_bytes_total", "Data received on the port.", 4},
+	{"port_xmit_data", "port_data_transmitted_bytes_total", "Data transmitted on the port.", 4},
+	{"port_rcv_packets", "port_packets_received_total", "Packets received on the port.", 1},
+	{"port_xmit_packets", "port_packets_transmitted_total", "Packets transmitted on the port.", 1},
+	{"port_multicast_rcv_packets", "port_multicast_packets_received_total", "Multicast packets received on the port.", 1},
+	{"port_multicast_xmit_packets", "port_multicast_packets_transmitted_total", "Multicast packets transmitted on the port.", 1},
+	{"port_rcv_errors", "port_errors_received_total", "Packets with errors received on the port.", 1},
+	{"port_xmit_discards", "port_transmit_discards_total", "Packets discarded on the port because it was down or congested.", 1},
+	{"port_xmit_wait", "port_transmit_wait_total", "Ticks the port had data to transmit but none was sent.", 1},
+	{"port_rcv_remote_physical_errors", "port_receive_remote_physical_errors_total", "Packets received on the port that were marked bad by a remote port.", 1},
+	{"symbol_error", "symbol_error_total", "Minor link errors detected on the physical lanes.", 1},
+	{"link_error_recovery", "link_error_recovery_total", "Times the link successfully recovered from an error.", 1},
+	{"link_downed", "link_downed_total", "Times the link failed to recover from an error and went down.", 1},
+	{"local_link_integrity_errors", "local_link_integrity_errors_total", "Times the number of physical errors exceeded the threshold.", 1},
+	{"excessive_buffer_overrun_errors", "excessive_buffer_overrun_errors_total", "Times consecutive flow control update periods had buffer overruns.", 1},
+	{"VL15_dropped", "vl15_dropped_total", "Subnet management packets dropped for lack of buffers.", 1},
+}
+
+type infinibandDevice struct {
+	name, boardID, firmware, hcaType string
+	ports                            []infinibandPort
+}
+
+type infinibandPort struct {
+	name string
+	// The ids and names of the state and physical state, e.g. 4: ACTIVE.
+	stateID, physStateID float64
+	state, physState     string
+	rate                 float64
+	// Counters missing or not available from the port are left out.
+	counters map[string]float64
+}
+
+type infinibandCollector struct {
+	info, stateID, physStateID, rate typedDesc
+	counters                         []typedDesc
+}
+
+func init() {
+	Factories["infiniband"] = NewInfiniBandCollector
+}
+
+// NewInfiniBandCollector returns a new Collector exposing the port states
+// and counters of InfiniBand devices.
+func NewInfiniBandCollector() (Collector, error) {
+	portLabels := []string{"device", "port"}
+	c := &infinibandCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, infinibandSubsystem, "info"),
+			"Board, firmware version and type of the InfiniBand device, value is always 1.",
+			[]string{"device", "board_id", "firmware_version", "hca_type"}, nil,
+		), prometheus.GaugeValue},
+		stateID: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, infinibandSubsystem, "state_id"),
+			"State of the InfiniBand port, 1 down, 2 init, 3 armed, 4 active, 5 active defer.",
+			append(portLabels, "state"), nil,
+		), prometheus.GaugeValue},
+		physStateID: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, infinibandSubsystem, "physical_state_id"),
+			"Physical state of the InfiniBand port, e.g. 2 polling, 3 disabled, 5 link up.",
+			append(portLabels, "physical_state"), nil,
+		), prometheus.GaugeValue},
+		rate: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, infinibandSubsystem, "rate_bytes_per_second"),
+			"Data rate of the InfiniBand port.",
+			portLabels, nil,
+		), prometheus.GaugeValue},
+	}
+	for _, counter := range infinibandCounters {
+		c.counters = append(c.counters, typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, infinibandSubsystem, counter.name),
+			counter.help, portLabels, nil,
+		), prometheus.CounterValue})
+	}
+	return c, nil
+}
+
+func (c *infinibandCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readInfiniBandDevices(sysFilePath("class/infiniband"))
+	if err != nil {
+		return fmt.Errorf("couldn't get InfiniBand devices: %s", err)
+	}
+	for _, d := range devices {
+		ch <- c.info.mustNewConstMetric(1, d.name, d.boardID, d.firmware, d.hcaType)
+		for _, p := range d.ports {
+			ch <- c.stateID.mustNewConstMetric(p.stateID, d.name, p.name, p.state)
+			ch <- c.physStateID.mustNewConstMetric(p.physStateID, d.name, p.name, p.physState)
+			ch <- c.rate.mustNewConstMetric(p.rate, d.name, p.name)
+			for i, counter := range infinibandCounters {
+				if v, ok := p.counters[counter.file]; ok {
+					ch <- c.counters[i].mustNewConstMetric(v, d.name, p.name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func readInfiniBandDevices(root string) ([]infinibandDevice, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		// No InfiniBand drivers loaded.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var devices []infinibandDevice
+	for _, dir := range dirs {
+		d := infinibandDevice{name: dir.Name()}
+		devPath := path.Join(root, d.name)
+		// Not all drivers provide the board id and type.
+		d.boardID, _ = readStringFromFile(path.Join(devPath, "board_id"))
+		d.hcaType, _ = readStringFromFile(path.Join(devPath, "hca_type"))
+		if d.firmware, err = readStringFromFile(path.Join(devPath, "fw_ver")); err != nil {
+			return nil, err
+		}
+
+		ports, err := ioutil.ReadDir(path.Join(devPath, "ports"))
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range ports {
+			p, err := readInfiniBandPort(path.Join(devPath, "ports", port.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("couldn't read port %s of %s: %s", port.Name(), d.name, err)
+			}
+			p.name = port.Name()
+			d.ports = append(d.ports, p)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+func readInfiniBandPort(portPath string) (infinibandPort, error) {
+	p := infinibandPort{counters: map[string]float64{}}
+	state, err := readStringFromFile(path.Join(portPath, "state"))
+	if err != nil {
+		return p, err
+	}
+	if p.stateID, p.state, err = parseInfiniBandState(state); err != nil {
+		return p, err
+	}
+	physState, err := readStringFromFile(path.Join(portPath, "phys_state"))
+	if err != nil {
+		return p, err
+	}
+	if p.physStateID, p.physState, err = parseInfiniBandState(physState); err != nil {
+		return p, err
+	}
+	rate, err := readStringFromFile(path.Join(portPath, "rate"))
+	if err != nil {
+		return p, err
+	}
+	if p.rate, err = parseInfiniBandRate(rate); err != nil {
+		return p, err
+	}
+
+	for _, counter := range infinibandCounters {
+		s, err := readStringFromFile(path.Join(portPath, "counters", counter.file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return p, err
+		}
+		v, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			// E.g. "N/A (no PMA)" on ports without a performance management
+			// agent.
+			log.Debugf("Ignoring counter %s of %s: %s", counter.file, portPath, s)
+			continue
+		}
+		p.counters[counter.file] = float64(v) * counter.scale
+	}
+	return p, nil
+}
+
+// parseInfiniBandState parses states like "4: ACTIVE".
+func parseInfiniBandState(s string) (float64, string, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("invalid state %q", s)
+	}
+	id, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid state %q", s)
+	}
+	return float64(id), strings.TrimSpace(parts[1]), nil
+}
+
+// parseInfiniBandRate parses rates like "100 Gb/sec (4X EDR)" into bytes
+// per second.
+func parseInfiniBandRate(s string) (float64, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || fields[1] != "Gb/sec" {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	gbits, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	return gbits * 1e9 / 8, nil
+}
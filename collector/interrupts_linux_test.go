@@ -33,4 +33,17 @@ func TestInterrupts(t *testing.T) {
 	if want, got := "5031", interrupts["NMI"].values[1]; want != got {
 		t.Errorf("want interrupts %s, got %s", want, got)
 	}
+
+	if want, got := 4, len(interrupts["NMI"].values); want != got {
+		t.Fatalf("want values of %d CPUs, got %d", want, got)
+	}
+	if want, got := "28", interrupts["1"].values[3]; want != got {
+		t.Errorf("want interrupts %s on the last CPU, got %s", want, got)
+	}
+	if want, got := "IR-IO-APIC-edge", interrupts["1"].info; want != got {
+		t.Errorf("want info %s, got %s", want, got)
+	}
+	if want, got := "i8042", interrupts["1"].devices; want != got {
+		t.Errorf("want devices %s, got %s", want, got)
+	}
 }
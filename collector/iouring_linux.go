@@ -0,0 +1,173 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noiouring
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	iouringSubsystem = "iouring"
+
+	iouringLink = "anon_inode:[io_uring]"
+)
+
+// iouringUsage is the io_uring usage of one or more instances.
+type iouringUsage struct {
+	instances  float64
+	files      float64
+	buffers    float64
+	cqOverflow float64
+}
+
+type iouringCollector struct {
+	instances, files, buffers, cqOverflow typedDesc
+}
+
+func init() {
+	Factories["iouring"] = NewIOUringCollector
+}
+
+// NewIOUringCollector returns a new Collector exposing the io_uring
+// instances of all processes.
+func NewIOUringCollector() (Collector, error) {
+	return &iouringCollector{
+		instances: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iouringSubsystem, "instances"),
+			"Number of io_uring instances of all processes.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		files: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iouringSubsystem, "registered_files"),
+			"Number of files registered with io_uring instances.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		buffers: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iouringSubsystem, "registered_buffers"),
+			"Number of buffers registered with io_uring instances.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		cqOverflow: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iouringSubsystem, "cq_overflow_entries"),
+			"Completions of io_uring instances that didn't fit the completion queue and wait to be reaped.",
+			nil, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *iouringCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	usage, err := readIOUringUsage(procFilePath(""))
+	if err != nil {
+		return err
+	}
+	ch <- c.instances.mustNewConstMetric(usage.instances)
+	ch <- c.files.mustNewConstMetric(usage.files)
+	ch <- c.buffers.mustNewConstMetric(usage.buffers)
+	ch <- c.cqOverflow.mustNewConstMetric(usage.cqOverflow)
+	return nil
+}
+
+// readIOUringUsage sums the io_uring instances found in the file
+// descriptors of all processes. Processes that exit or can't be inspected
+// are skipped.
+func readIOUringUsage(procPath string) (u iouringUsage, err error) {
+	dir, err := os.Open(procPath)
+	if err != nil {
+		return u, err
+	}
+	pids, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return u, err
+	}
+
+	for _, pid := range pids {
+		if _, err := strconv.Atoi(pid); err != nil {
+			continue
+		}
+		fdPath := path.Join(procPath, pid, "fd")
+		dir, err := os.Open(fdPath)
+		if err != nil {
+			continue
+		}
+		fds, err := dir.Readdirnames(-1)
+		dir.Close()
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			target, err := os.Readlink(path.Join(fdPath, fd))
+			if err != nil || target != iouringLink {
+				continue
+			}
+			u.instances++
+			file, err := os.Open(path.Join(procPath, pid, "fdinfo", fd))
+			if err != nil {
+				continue
+			}
+			r, err := parseIOUringFDInfo(file)
+			file.Close()
+			if err != nil {
+				continue
+			}
+			u.files += r.files
+			u.buffers += r.buffers
+			u.cqOverflow += r.cqOverflow
+		}
+	}
+	return u, nil
+}
+
+// parseIOUringFDInfo parses the fdinfo of an io_uring file descriptor. The
+// registered files and buffers are followed by one line per entry, as are
+// the completions on the overflow list.
+func parseIOUringFDInfo(r io.Reader) (u iouringUsage, err error) {
+	var section string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if section == "CqOverflowList" {
+				u.cqOverflow++
+			}
+			continue
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return u, fmt.Errorf("invalid line: %s", line)
+		}
+		section = parts[0]
+		value := strings.TrimSpace(parts[1])
+		switch section {
+		case "UserFiles":
+			u.files, err = strconv.ParseFloat(value, 64)
+		case "UserBufs":
+			u.buffers, err = strconv.ParseFloat(value, 64)
+		}
+		if err != nil {
+			return u, fmt.Errorf("invalid line: %s", line)
+		}
+	}
+	return u, scanner.Err()
+}
@@ -0,0 +1,36 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIOUringFDInfo(t *testing.T) {
+	file, err := os.Open("fixtures/iouring_fdinfo.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	usage, err := parseIOUringFDInfo(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := iouringUsage{files: 3, buffers: 2, cqOverflow: 2}
+	if want != usage {
+		t.Errorf("want %+v, got %+v", want, usage)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noipmi_sel
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	ipmiSELCommand = flag.String("collector.ipmi_sel.command", "ipmi-sel", "Command to run ipmi-sel of FreeIPMI.")
+)
+
+// ipmiSELEntry is an entry of the System Event Log of the BMC.
+type ipmiSELEntry struct {
+	// record is the whole line, identifying the entry across scrapes.
+	record     string
+	sensorType string
+	state      string
+}
+
+type ipmiSELCollector struct {
+	cli      string
+	entries  typedDesc
+	critical typedDesc
+
+	mtx sync.Mutex
+	// seen holds the records of the last scrape, nil before the first.
+	seen   map[string]bool
+	counts map[string]uint64
+}
+
+func init() {
+	Factories["ipmi_sel"] = NewIPMISELCollector
+}
+
+// NewIPMISELCollector returns a new Collector exposing the number of entries
+// in the System Event Log and the critical events added to it since the
+// exporter started.
+func NewIPMISELCollector() (Collector, error) {
+	return &ipmiSELCollector{
+		cli: *ipmiSELCommand,
+		entries: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "ipmi_sel", "entries"),
+			"Number of entries in the IPMI System Event Log.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		critical: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "ipmi_sel", "critical_events_total"),
+			"Critical events added to the IPMI System Event Log since the exporter started, by sensor type.",
+			[]string{"type"}, nil,
+		), prometheus.CounterValue},
+		counts: map[string]uint64{},
+	}, nil
+}
+
+func (c *ipmiSELCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	out, err := exec.Command(c.cli, "--output-event-state", "--comma-separated-output", "--no-header-output").Output()
+	if err != nil {
+		return fmt.Errorf("couldn't run %s: %s", c.cli, err)
+	}
+	entries, err := parseIPMISEL(strings.NewReader(string(out)))
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.count(entries)
+
+	ch <- c.entries.mustNewConstMetric(float64(len(entries)))
+	for sensorType, count := range c.counts {
+		ch <- c.critical.mustNewConstMetric(float64(count), sensorType)
+	}
+	return nil
+}
+
+// count adds the critical entries not seen in the previous scrape to the
+// counts. The entries present at the first scrape predate the exporter and
+// aren't counted.
+func (c *ipmiSELCollector) count(entries []ipmiSELEntry) {
+	seen := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		seen[e.record] = true
+		if c.seen == nil || c.seen[e.record] {
+			continue
+		}
+		if e.state == "Critical" {
+			c.counts[e.sensorType]++
+		}
+	}
+	c.seen = seen
+}
+
+// parseIPMISEL parses the output of `ipmi-sel --output-event-state
+// --comma-separated-output --no-header-output`, with the fields ID, Date,
+// Time, Name, Type, State and Event.
+func parseIPMISEL(r io.Reader) ([]ipmiSELEntry, error) {
+	var entries []ipmiSELEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.SplitN(line, ",", 7)
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("invalid SEL entry: %q", line)
+		}
+		entries = append(entries, ipmiSELEntry{
+			record:     line,
+			sensorType: fields[4],
+			state:      fields[5],
+		})
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestIPMISEL(t *testing.T) {
+	file, err := os.Open("fixtures/ipmi_sel.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	entries, err := parseIPMISEL(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 5, len(entries); want != got {
+		t.Fatalf("want %d entries, got %d", want, got)
+	}
+	if want, got := (ipmiSELEntry{
+		record:     "3,Mar-14-2017,22:41:55,PS2 Status,Power Supply,Critical,Power Supply input lost (AC/DC)",
+		sensorType: "Power Supply",
+		state:      "Critical",
+	}), entries[2]; want != got {
+		t.Errorf("want entry %+v, got %+v", want, got)
+	}
+
+	c := &ipmiSELCollector{counts: map[string]uint64{}}
+	// Entries logged before the first scrape are not counted.
+	c.count(entries[:3])
+	if len(c.counts) != 0 {
+		t.Errorf("want no critical events, got %v", c.counts)
+	}
+	c.count(entries)
+	c.count(append(entries, ipmiSELEntry{record: "6", sensorType: "Power Supply", state: "Critical"}))
+	if want, got := uint64(1), c.counts["Power Supply"]; want != got {
+		t.Errorf("want %d power supply events, got %d", want, got)
+	}
+	// A cleared log doesn't count its entries again.
+	c.count(entries[:1])
+	c.count(append(entries[:1], ipmiSELEntry{record: "2", sensorType: "Physical Security", state: "Critical"}))
+	if want, got := uint64(1), c.counts["Physical Security"]; want != got {
+		t.Errorf("want %d physical security events, got %d", want, got)
+	}
+	if len(c.counts) != 2 {
+		t.Errorf("unexpected critical events %v", c.counts)
+	}
+}
@@ -0,0 +1,21 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noiptables,!386
+
+package collector
+
+// iptU64Align is the alignment of __u64 in the kernel ABI, which aligns the
+// counters of ipt_entry. It is 8 even on 32-bit arm and mips, where Go only
+// aligns uint64 to 4.
+const iptU64Align = 8
@@ -0,0 +1,282 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noiptables
+
+package collector
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	iptablesRules    = flag.Bool("collector.iptables.rules", false, "Expose counters of individual iptables rules.")
+	iptablesMaxRules = flag.Int("collector.iptables.max-rules", 256, "Maximum number of iptables rules per table to expose counters for.")
+)
+
+// Socket options and struct sizes from include/uapi/linux/netfilter_ipv4/ip_tables.h
+// and include/uapi/linux/netfilter_ipv6/ip6_tables.h.
+const (
+	iptSoGetInfo    = 64
+	iptSoGetEntries = 65
+
+	iptTableMaxNameLen = 32
+	iptGetInfoSize     = 84
+	iptFunctionMaxLen  = 29
+	iptCommentLen      = 256
+	iptErrorTarget     = "ERROR"
+)
+
+var (
+	iptHookNames = []string{"PREROUTING", "INPUT", "FORWARD", "OUTPUT", "POSTROUTING"}
+)
+
+// iptLayout describes the differences between ipt_entry and ip6t_entry.
+type iptLayout struct {
+	family     string
+	domain     int
+	level      int
+	tablesFile string
+	// Offset of nfcache, i.e. size of struct ipt_ip or ip6t_ip6.
+	ipSize int
+}
+
+var iptLayouts = []iptLayout{
+	{family: "ipv4", domain: syscall.AF_INET, level: syscall.IPPROTO_IP, tablesFile: "net/ip_tables_names", ipSize: 84},
+	{family: "ipv6", domain: syscall.AF_INET6, level: syscall.IPPROTO_IPV6, tablesFile: "net/ip6_tables_names", ipSize: 136},
+}
+
+func (l iptLayout) targetOffsetOffset() int { return l.ipSize + 4 }
+func (l iptLayout) nextOffsetOffset() int   { return l.ipSize + 6 }
+func (l iptLayout) countersOffset() int     { return alignTo(l.ipSize+12, iptU64Align) }
+func (l iptLayout) entrySize() int          { return alignTo(l.countersOffset()+16, iptU64Align) }
+
+type iptCounters struct {
+	chain   string
+	rule    int
+	comment string
+	packets uint64
+	bytes   uint64
+}
+
+type iptTable struct {
+	chains []iptCounters
+	rules  []iptCounters
+}
+
+type iptablesCollector struct {
+	chainPackets, chainBytes typedDesc
+	rulePackets, ruleBytes   typedDesc
+}
+
+func init() {
+	Factories["iptables"] = NewIptablesCollector
+}
+
+// NewIptablesCollector returns a new Collector exposing iptables and
+// ip6tables chain and rule counters.
+func NewIptablesCollector() (Collector, error) {
+	const subsystem = "iptables"
+
+	chainLabels := []string{"family", "table", "chain"}
+	ruleLabels := []string{"family", "table", "chain", "rule", "comment"}
+	return &iptablesCollector{
+		chainPackets: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "chain_packets_total"),
+			"Packets that hit the policy of the built-in chain.",
+			chainLabels, nil,
+		), prometheus.CounterValue},
+		chainBytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "chain_bytes_total"),
+			"Bytes that hit the policy of the built-in chain.",
+			chainLabels, nil,
+		), prometheus.CounterValue},
+		rulePackets: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "rule_packets_total"),
+			"Packets matched by the rule.",
+			ruleLabels, nil,
+		), prometheus.CounterValue},
+		ruleBytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "rule_bytes_total"),
+			"Bytes matched by the rule.",
+			ruleLabels, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *iptablesCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	for _, l := range iptLayouts {
+		names, err := ioutil.ReadFile(procFilePath(l.tablesFile))
+		if os.IsNotExist(err) {
+			log.Debugf("Not collecting %s tables: %s", l.family, err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range strings.Fields(string(names)) {
+			table, err := getIptTable(l, name)
+			if err != nil {
+				return fmt.Errorf("couldn't get %s table %s: %s", l.family, name, err)
+			}
+			for _, chain := range table.chains {
+				ch <- c.chainPackets.mustNewConstMetric(float64(chain.packets), l.family, name, chain.chain)
+				ch <- c.chainBytes.mustNewConstMetric(float64(chain.bytes), l.family, name, chain.chain)
+			}
+			if !*iptablesRules {
+				continue
+			}
+			for i, rule := range table.rules {
+				if i >= *iptablesMaxRules {
+					log.Debugf("Table %s has more than %d rules, skipping the rest", name, *iptablesMaxRules)
+					break
+				}
+				ruleNum := strconv.Itoa(rule.rule)
+				ch <- c.rulePackets.mustNewConstMetric(float64(rule.packets), l.family, name, rule.chain, ruleNum, rule.comment)
+				ch <- c.ruleBytes.mustNewConstMetric(float64(rule.bytes), l.family, name, rule.chain, ruleNum, rule.comment)
+			}
+		}
+	}
+	return nil
+}
+
+func getIptTable(l iptLayout, name string) (*iptTable, error) {
+	fd, err := syscall.Socket(l.domain, syscall.SOCK_RAW, syscall.IPPROTO_RAW)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	info := make([]byte, iptGetInfoSize)
+	copy(info, name)
+	if _, err := getsockoptBuffer(fd, l.level, iptSoGetInfo, info); err != nil {
+		return nil, err
+	}
+	size := nativeEndian.Uint32(info[80:])
+
+	// struct ipt_get_entries is the table name and size followed by the
+	// entries, which are aligned for their 64 bit counters.
+	header := alignTo(iptTableMaxNameLen+4, iptU64Align)
+	entries := make([]byte, header+int(size))
+	copy(entries, name)
+	nativeEndian.PutUint32(entries[iptTableMaxNameLen:], size)
+	if _, err := getsockoptBuffer(fd, l.level, iptSoGetEntries, entries); err != nil {
+		return nil, err
+	}
+	return parseIptEntries(l, info, entries[header:])
+}
+
+// parseIptEntries walks the entries of a table like iptables-save does:
+// built-in chains start at their hook entry and end with the policy at their
+// underflow, user-defined chains start with an ERROR target carrying the
+// chain name and end with an unconditional RETURN.
+func parseIptEntries(l iptLayout, info, entries []byte) (*iptTable, error) {
+	var (
+		validHooks = nativeEndian.Uint32(info[32:])
+		hookEntry  = map[int]string{}
+		underflow  = map[int]string{}
+		table      = &iptTable{}
+		chain      string
+		ruleNum    int
+	)
+	for h, hookName := range iptHookNames {
+		if validHooks&(1<<uint(h)) == 0 {
+			continue
+		}
+		hookEntry[int(nativeEndian.Uint32(info[36+4*h:]))] = hookName
+		underflow[int(nativeEndian.Uint32(info[56+4*h:]))] = hookName
+	}
+
+	for off := 0; off < len(entries); {
+		if off+l.entrySize() > len(entries) {
+			return nil, fmt.Errorf("truncated entry at offset %d", off)
+		}
+		e := entries[off:]
+		targetOff := int(nativeEndian.Uint16(e[l.targetOffsetOffset():]))
+		nextOff := int(nativeEndian.Uint16(e[l.nextOffsetOffset():]))
+		if nextOff == 0 || targetOff+32 > nextOff || off+nextOff > len(entries) {
+			return nil, fmt.Errorf("invalid entry at offset %d", off)
+		}
+		counters := e[l.countersOffset():]
+		packets := nativeEndian.Uint64(counters)
+		octets := nativeEndian.Uint64(counters[8:])
+		target := cString(e[targetOff+2 : targetOff+2+iptFunctionMaxLen])
+
+		if name, ok := hookEntry[off]; ok {
+			chain, ruleNum = name, 0
+		}
+
+		switch {
+		case target == iptErrorTarget:
+			name := cString(e[targetOff+32 : nextOff])
+			if name == iptErrorTarget {
+				// Terminating entry of the table.
+				return table, nil
+			}
+			chain, ruleNum = name, 0
+		case underflow[off] != "":
+			table.chains = append(table.chains, iptCounters{chain: underflow[off], packets: packets, bytes: octets})
+		case isIptChainEnd(l, entries, off+nextOff):
+			// Implicit RETURN at the end of a user-defined chain.
+		default:
+			ruleNum++
+			table.rules = append(table.rules, iptCounters{
+				chain:   chain,
+				rule:    ruleNum,
+				comment: iptComment(e[l.entrySize():targetOff]),
+				packets: packets,
+				bytes:   octets,
+			})
+		}
+		off += nextOff
+	}
+	return table, nil
+}
+
+// isIptChainEnd reports whether the entry at off starts a new user-defined
+// chain or terminates the table.
+func isIptChainEnd(l iptLayout, entries []byte, off int) bool {
+	if off+l.entrySize() > len(entries) {
+		return false
+	}
+	e := entries[off:]
+	targetOff := int(nativeEndian.Uint16(e[l.targetOffsetOffset():]))
+	if targetOff+2+iptFunctionMaxLen > len(e) {
+		return false
+	}
+	return cString(e[targetOff+2:targetOff+2+iptFunctionMaxLen]) == iptErrorTarget
+}
+
+// iptComment returns the text of the first comment match.
+func iptComment(matches []byte) string {
+	for len(matches) >= 32 {
+		size := int(nativeEndian.Uint16(matches))
+		if size < 32 || size > len(matches) {
+			break
+		}
+		if cString(matches[2:2+iptFunctionMaxLen]) == "comment" && size >= 32+iptCommentLen {
+			return cString(matches[32 : 32+iptCommentLen])
+		}
+		matches = matches[size:]
+	}
+	return ""
+}
@@ -0,0 +1,123 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"runtime"
+	"testing"
+)
+
+func iptTestTarget(name string, dataLen int, data string) []byte {
+	t := make([]byte, alignTo(32+dataLen, 8))
+	nativeEndian.PutUint16(t, uint16(len(t)))
+	copy(t[2:], name)
+	copy(t[32:], data)
+	return t
+}
+
+func iptTestEntry(l iptLayout, packets, bytes uint64, comment string, target []byte) []byte {
+	e := make([]byte, l.entrySize())
+	if comment != "" {
+		m := make([]byte, 32+iptCommentLen)
+		nativeEndian.PutUint16(m, uint16(len(m)))
+		copy(m[2:], "comment")
+		copy(m[32:], comment)
+		e = append(e, m...)
+	}
+	nativeEndian.PutUint16(e[l.targetOffsetOffset():], uint16(len(e)))
+	e = append(e, target...)
+	nativeEndian.PutUint16(e[l.nextOffsetOffset():], uint16(len(e)))
+	nativeEndian.PutUint64(e[l.countersOffset():], packets)
+	nativeEndian.PutUint64(e[l.countersOffset()+8:], bytes)
+	return e
+}
+
+func TestIptLayoutSizes(t *testing.T) {
+	// sizeof(struct ipt_entry) and sizeof(struct ip6t_entry) of the kernel,
+	// only the i386 ABI aligns their __u64 counters to 4.
+	want := map[string]int{"ipv4": 112, "ipv6": 168}
+	if runtime.GOARCH == "386" {
+		want["ipv6"] = 164
+	}
+	for _, l := range iptLayouts {
+		if got := l.entrySize(); want[l.family] != got {
+			t.Errorf("want %s entry size %d, got %d", l.family, want[l.family], got)
+		}
+	}
+}
+
+func TestIptEntries(t *testing.T) {
+	for _, l := range iptLayouts {
+		var (
+			info    = make([]byte, iptGetInfoSize)
+			entries []byte
+			verdict = iptTestTarget("", 4, "")
+		)
+		add := func(e []byte) int {
+			off := len(entries)
+			entries = append(entries, e...)
+			return off
+		}
+		hook := func(h, entry, underflow int) {
+			nativeEndian.PutUint32(info[32:], nativeEndian.Uint32(info[32:])|1<<uint(h))
+			nativeEndian.PutUint32(info[36+4*h:], uint32(entry))
+			nativeEndian.PutUint32(info[56+4*h:], uint32(underflow))
+		}
+
+		input := add(iptTestEntry(l, 10, 600, "allow ssh", verdict))
+		add(iptTestEntry(l, 3, 180, "", verdict))
+		hook(1, input, add(iptTestEntry(l, 100, 6000, "", verdict)))
+		forward := add(iptTestEntry(l, 0, 0, "", verdict))
+		hook(2, forward, forward)
+		output := add(iptTestEntry(l, 50, 3000, "", verdict))
+		hook(3, output, output)
+		add(iptTestEntry(l, 0, 0, "", iptTestTarget(iptErrorTarget, 30, "DOCKER")))
+		add(iptTestEntry(l, 5, 300, "", verdict))
+		add(iptTestEntry(l, 0, 0, "", verdict))
+		add(iptTestEntry(l, 0, 0, "", iptTestTarget(iptErrorTarget, 30, iptErrorTarget)))
+
+		table, err := parseIptEntries(l, info, entries)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wantChains := []iptCounters{
+			{chain: "INPUT", packets: 100, bytes: 6000},
+			{chain: "FORWARD"},
+			{chain: "OUTPUT", packets: 50, bytes: 3000},
+		}
+		if len(table.chains) != len(wantChains) {
+			t.Fatalf("%s: want %d chains, got %d", l.family, len(wantChains), len(table.chains))
+		}
+		for i, c := range table.chains {
+			if c != wantChains[i] {
+				t.Errorf("%s: want chain %+v, got %+v", l.family, wantChains[i], c)
+			}
+		}
+
+		wantRules := []iptCounters{
+			{chain: "INPUT", rule: 1, comment: "allow ssh", packets: 10, bytes: 600},
+			{chain: "INPUT", rule: 2, packets: 3, bytes: 180},
+			{chain: "DOCKER", rule: 1, packets: 5, bytes: 300},
+		}
+		if len(table.rules) != len(wantRules) {
+			t.Fatalf("%s: want %d rules, got %d", l.family, len(wantRules), len(table.rules))
+		}
+		for i, r := range table.rules {
+			if r != wantRules[i] {
+				t.Errorf("%s: want rule %+v, got %+v", l.family, wantRules[i], r)
+			}
+		}
+	}
+}
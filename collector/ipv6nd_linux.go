@@ -0,0 +1,244 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noipv6nd
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	ipv6NDSubsystem = "ipv6nd"
+
+	// IFA_F_DADFAILED from include/uapi/linux/if_addr.h.
+	ifaFDADFailed = 0x08
+	// RTA_CACHEINFO from include/uapi/linux/rtnetlink.h.
+	rtaCacheInfo = 12
+	// Offset of rta_expires in struct rta_cacheinfo.
+	rtaCacheInfoExpiresOffset = 8
+	// USER_HZ, the unit of rta_expires.
+	userHZ = 100
+)
+
+// ipv6NDRoute is a route learned from a router advertisement, i.e. one that
+// expires.
+type ipv6NDRoute struct {
+	ifindex int
+	dst     *net.IPNet
+	gateway net.IP
+	expires float64
+}
+
+type ipv6NDCollector struct {
+	raReceived, rsSent typedDesc
+	dadFailed          typedDesc
+	routerExpiry       typedDesc
+	prefixExpiry       typedDesc
+}
+
+func init() {
+	Factories["ipv6nd"] = NewIPv6NDCollector
+}
+
+// NewIPv6NDCollector returns a new Collector exposing IPv6 neighbor
+// discovery and router advertisement state.
+func NewIPv6NDCollector() (Collector, error) {
+	return &ipv6NDCollector{
+		raReceived: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ipv6NDSubsystem, "router_advertisements_received_total"),
+			"Router advertisements received on the interface.",
+			[]string{"device"}, nil,
+		), prometheus.CounterValue},
+		rsSent: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ipv6NDSubsystem, "router_solicitations_sent_total"),
+			"Router solicitations sent on the interface.",
+			[]string{"device"}, nil,
+		), prometheus.CounterValue},
+		dadFailed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ipv6NDSubsystem, "dad_failed_addresses"),
+			"Addresses on the interface that failed duplicate address detection.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		routerExpiry: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ipv6NDSubsystem, "default_router_expiry_seconds"),
+			"Seconds until the default route learned from the router expires.",
+			[]string{"device", "router"}, nil,
+		), prometheus.GaugeValue},
+		prefixExpiry: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ipv6NDSubsystem, "prefix_expiry_seconds"),
+			"Seconds until the on-link prefix learned from router advertisements expires.",
+			[]string{"device", "prefix"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *ipv6NDCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	snmp6Dir := procFilePath("net/dev_snmp6")
+	devices, err := ioutil.ReadDir(snmp6Dir)
+	if err != nil {
+		return fmt.Errorf("couldn't list IPv6 interfaces: %s", err)
+	}
+	for _, device := range devices {
+		stats, err := readIPv6DevStats(path.Join(snmp6Dir, device.Name()))
+		if err != nil {
+			return fmt.Errorf("couldn't get IPv6 stats of %s: %s", device.Name(), err)
+		}
+		ch <- c.raReceived.mustNewConstMetric(stats["Icmp6InRouterAdvertisements"], device.Name())
+		ch <- c.rsSent.mustNewConstMetric(stats["Icmp6OutRouterSolicits"], device.Name())
+	}
+
+	file, err := os.Open(procFilePath("net/if_inet6"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	dadFailed, err := parseIPv6DADFailed(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse IPv6 addresses: %s", err)
+	}
+	for device, count := range dadFailed {
+		ch <- c.dadFailed.mustNewConstMetric(count, device)
+	}
+
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETROUTE, syscall.AF_INET6)
+	if err != nil {
+		return fmt.Errorf("couldn't get IPv6 routes: %s", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return err
+	}
+	routes, err := parseIPv6NDRoutes(msgs)
+	if err != nil {
+		return err
+	}
+	for _, r := range routes {
+		iface, err := net.InterfaceByIndex(r.ifindex)
+		if err != nil {
+			// The link was removed since dumping the routes.
+			log.Debugf("Couldn't get interface %d of IPv6 route: %s", r.ifindex, err)
+			continue
+		}
+		if ones, _ := r.dst.Mask.Size(); ones == 0 {
+			ch <- c.routerExpiry.mustNewConstMetric(r.expires, iface.Name, r.gateway.String())
+		} else {
+			ch <- c.prefixExpiry.mustNewConstMetric(r.expires, iface.Name, r.dst.String())
+		}
+	}
+	return nil
+}
+
+// readIPv6DevStats reads a file of /proc/net/dev_snmp6.
+func readIPv6DevStats(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stats := map[string]float64{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, err
+		}
+		stats[fields[0]] = v
+	}
+	return stats, scanner.Err()
+}
+
+// parseIPv6DADFailed returns the number of addresses that failed duplicate
+// address detection per device of /proc/net/if_inet6. All devices with IPv6
+// addresses are included.
+func parseIPv6DADFailed(r io.Reader) (map[string]float64, error) {
+	failed := map[string]float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		flags, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags in line: %s", scanner.Text())
+		}
+		if flags&ifaFDADFailed != 0 {
+			failed[fields[5]]++
+		} else if _, ok := failed[fields[5]]; !ok {
+			failed[fields[5]] = 0
+		}
+	}
+	return failed, scanner.Err()
+}
+
+// parseIPv6NDRoutes returns the routes of the main table that expire, which
+// are the default routes and prefixes learned from router advertisements.
+func parseIPv6NDRoutes(msgs []syscall.NetlinkMessage) ([]ipv6NDRoute, error) {
+	var routes []ipv6NDRoute
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWROUTE || len(m.Data) < syscall.SizeofRtMsg {
+			continue
+		}
+		// struct rtmsg: family, dst_len, src_len, tos, table, protocol,
+		// scope, type and flags.
+		dstLen, table, typ := m.Data[1], m.Data[4], m.Data[7]
+		if table != syscall.RT_TABLE_MAIN || typ != syscall.RTN_UNICAST {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return nil, err
+		}
+		r := ipv6NDRoute{dst: &net.IPNet{IP: net.IPv6zero, Mask: net.CIDRMask(int(dstLen), 128)}}
+		for _, a := range attrs {
+			switch a.Attr.Type {
+			case syscall.RTA_DST:
+				r.dst.IP = net.IP(a.Value)
+			case syscall.RTA_GATEWAY:
+				r.gateway = net.IP(a.Value)
+			case syscall.RTA_OIF:
+				if len(a.Value) >= 4 {
+					r.ifindex = int(nativeEndian.Uint32(a.Value))
+				}
+			case rtaCacheInfo:
+				if len(a.Value) >= rtaCacheInfoExpiresOffset+4 {
+					r.expires = float64(int32(nativeEndian.Uint32(a.Value[rtaCacheInfoExpiresOffset:]))) / userHZ
+				}
+			}
+		}
+		if r.expires <= 0 || r.ifindex == 0 {
+			continue
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
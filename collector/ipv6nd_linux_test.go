@@ -0,0 +1,124 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIPv6DevStats(t *testing.T) {
+	stats, err := readIPv6DevStats("fixtures/proc/net/dev_snmp6/eth0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 42.0, stats["Icmp6InRouterAdvertisements"]; want != got {
+		t.Errorf("want %f router advertisements, got %f", want, got)
+	}
+	if want, got := 3.0, stats["Icmp6OutRouterSolicits"]; want != got {
+		t.Errorf("want %f router solicitations, got %f", want, got)
+	}
+}
+
+func TestIPv6DADFailed(t *testing.T) {
+	file, err := os.Open("fixtures/proc/net/if_inet6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	failed, err := parseIPv6DADFailed(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"lo": 0, "eth0": 0, "eth1": 1}
+	if want, got := len(want), len(failed); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	for device, count := range want {
+		if got := failed[device]; count != got {
+			t.Errorf("want %f failed addresses on %s, got %f", count, device, got)
+		}
+	}
+}
+
+func TestIPv6NDRoutes(t *testing.T) {
+	route := func(table uint8, dstLen uint8, attrs ...[]byte) syscall.NetlinkMessage {
+		data := make([]byte, syscall.SizeofRtMsg)
+		data[0], data[1], data[4], data[7] = syscall.AF_INET6, dstLen, table, syscall.RTN_UNICAST
+		for _, a := range attrs {
+			data = append(data, a...)
+		}
+		return syscall.NetlinkMessage{Header: syscall.NlMsghdr{Type: syscall.RTM_NEWROUTE}, Data: data}
+	}
+	attr := func(typ uint16, value []byte) []byte {
+		b := make([]byte, alignTo(syscall.SizeofRtAttr+len(value), syscall.RTA_ALIGNTO))
+		nativeEndian.PutUint16(b, uint16(syscall.SizeofRtAttr+len(value)))
+		nativeEndian.PutUint16(b[2:], typ)
+		copy(b[syscall.SizeofRtAttr:], value)
+		return b
+	}
+	u32 := func(v uint32) []byte {
+		b := make([]byte, 4)
+		nativeEndian.PutUint32(b, v)
+		return b
+	}
+	cacheinfo := func(expires uint32) []byte {
+		b := make([]byte, 32)
+		nativeEndian.PutUint32(b[rtaCacheInfoExpiresOffset:], expires)
+		return b
+	}
+
+	msgs := []syscall.NetlinkMessage{
+		route(syscall.RT_TABLE_MAIN, 0,
+			attr(syscall.RTA_GATEWAY, net.ParseIP("fe80::1")),
+			attr(syscall.RTA_OIF, u32(2)),
+			attr(rtaCacheInfo, cacheinfo(179500))),
+		route(syscall.RT_TABLE_MAIN, 64,
+			attr(syscall.RTA_DST, net.ParseIP("2001:db8:1::")),
+			attr(syscall.RTA_OIF, u32(2)),
+			attr(rtaCacheInfo, cacheinfo(8640000))),
+		// Static route that doesn't expire.
+		route(syscall.RT_TABLE_MAIN, 48,
+			attr(syscall.RTA_DST, net.ParseIP("2001:db8:2::")),
+			attr(syscall.RTA_OIF, u32(2)),
+			attr(rtaCacheInfo, cacheinfo(0))),
+		// Local table.
+		route(255, 128,
+			attr(syscall.RTA_DST, net.ParseIP("2001:db8:1::10")),
+			attr(syscall.RTA_OIF, u32(2)),
+			attr(rtaCacheInfo, cacheinfo(100))),
+	}
+	routes, err := parseIPv6NDRoutes(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(routes); want != got {
+		t.Fatalf("want %d routes, got %d", want, got)
+	}
+	if want, got := "fe80::1", routes[0].gateway.String(); want != got {
+		t.Errorf("want gateway %s, got %s", want, got)
+	}
+	if want, got := 1795.0, routes[0].expires; want != got {
+		t.Errorf("want expiry %f, got %f", want, got)
+	}
+	if want, got := "2001:db8:1::/64", routes[1].dst.String(); want != got {
+		t.Errorf("want prefix %s, got %s", want, got)
+	}
+	if want, got := 86400.0, routes[1].expires; want != got {
+		t.Errorf("want expiry %f, got %f", want, got)
+	}
+}
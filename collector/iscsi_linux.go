@@ -0,0 +1,237 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noiscsi
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const iscsiSubsystem = "iscsi"
+
+// Session states as named in drivers/scsi/scsi_transport_iscsi.c.
+var iscsiSessionStates = []string{"LOGGED_IN", "FAILED", "FREE"}
+
+type iscsiSession struct {
+	name        string
+	target      string
+	tpgt        string
+	state       string
+	recoveryTmo float64
+	// Sums over the SCSI devices of the session.
+	ioRequests, ioDone, ioErrors float64
+}
+
+type iscsiConnection struct {
+	name, session string
+	address, port string
+	// Empty on kernels that don't expose the connection state.
+	state string
+}
+
+type iscsiCollector struct {
+	sessionInfo, sessionState, recoveryTimeout typedDesc
+	ioRequests, ioDone, ioErrors               typedDesc
+	connInfo, connUp                           typedDesc
+}
+
+func init() {
+	Factories["iscsi"] = NewISCSICollector
+}
+
+// NewISCSICollector returns a new Collector exposing iSCSI initiator
+// sessions and connections.
+func NewISCSICollector() (Collector, error) {
+	return &iscsiCollector{
+		sessionInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "session_info"),
+			"Target of the iSCSI session, value is always 1.",
+			[]string{"session", "target", "tpgt"}, nil,
+		), prometheus.GaugeValue},
+		sessionState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "session_state"),
+			"State of the iSCSI session.",
+			[]string{"session", "state"}, nil,
+		), prometheus.GaugeValue},
+		recoveryTimeout: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "session_recovery_timeout_seconds"),
+			"Time the iSCSI session is given to recover before failing commands.",
+			[]string{"session"}, nil,
+		), prometheus.GaugeValue},
+		ioRequests: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "session_io_requests_total"),
+			"I/O requests submitted to the SCSI devices of the iSCSI session.",
+			[]string{"session"}, nil,
+		), prometheus.CounterValue},
+		ioDone: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "session_io_completed_total"),
+			"I/O requests completed by the SCSI devices of the iSCSI session.",
+			[]string{"session"}, nil,
+		), prometheus.CounterValue},
+		ioErrors: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "session_io_errors_total"),
+			"I/O requests of the SCSI devices of the iSCSI session that completed with an error.",
+			[]string{"session"}, nil,
+		), prometheus.CounterValue},
+		connInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "connection_info"),
+			"Portal the iSCSI connection is established to, value is always 1.",
+			[]string{"session", "connection", "address", "port"}, nil,
+		), prometheus.GaugeValue},
+		connUp: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, iscsiSubsystem, "connection_up"),
+			"Whether the iSCSI connection is up.",
+			[]string{"session", "connection"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *iscsiCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	sessions, err := readISCSISessions(sysFilePath("class/iscsi_session"))
+	if err != nil {
+		return err
+	}
+	for _, s := range sessions {
+		ch <- c.sessionInfo.mustNewConstMetric(1, s.name, s.target, s.tpgt)
+		for _, state := range iscsiSessionStates {
+			v := 0.0
+			if state == s.state {
+				v = 1.0
+			}
+			ch <- c.sessionState.mustNewConstMetric(v, s.name, state)
+		}
+		ch <- c.recoveryTimeout.mustNewConstMetric(s.recoveryTmo, s.name)
+		ch <- c.ioRequests.mustNewConstMetric(s.ioRequests, s.name)
+		ch <- c.ioDone.mustNewConstMetric(s.ioDone, s.name)
+		ch <- c.ioErrors.mustNewConstMetric(s.ioErrors, s.name)
+	}
+
+	conns, err := readISCSIConnections(sysFilePath("class/iscsi_connection"))
+	if err != nil {
+		return err
+	}
+	for _, conn := range conns {
+		ch <- c.connInfo.mustNewConstMetric(1, conn.session, conn.name, conn.address, conn.port)
+		if conn.state == "" {
+			continue
+		}
+		up := 0.0
+		if conn.state == "up" {
+			up = 1.0
+		}
+		ch <- c.connUp.mustNewConstMetric(up, conn.session, conn.name)
+	}
+	return nil
+}
+
+func readISCSISessions(root string) ([]iscsiSession, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		// scsi_transport_iscsi not loaded.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sessions []iscsiSession
+	for _, d := range dirs {
+		s := iscsiSession{name: d.Name()}
+		sessionPath := path.Join(root, d.Name())
+		if s.target, err = readStringFromFile(path.Join(sessionPath, "targetname")); err != nil {
+			return nil, err
+		}
+		if s.tpgt, err = readStringFromFile(path.Join(sessionPath, "tpgt")); err != nil {
+			return nil, err
+		}
+		if s.state, err = readStringFromFile(path.Join(sessionPath, "state")); err != nil {
+			return nil, err
+		}
+		tmo, err := readUintFromFile(path.Join(sessionPath, "recovery_tmo"))
+		if err != nil {
+			return nil, err
+		}
+		s.recoveryTmo = float64(tmo)
+
+		// SCSI devices are found below device/target<h>:<c>:<t>/<h>:<c>:<t>:<l>.
+		devices, err := filepath.Glob(path.Join(sessionPath, "device", "target*", "*:*:*:*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, dev := range devices {
+			for file, v := range map[string]*float64{
+				"iorequest_cnt": &s.ioRequests,
+				"iodone_cnt":    &s.ioDone,
+				"ioerr_cnt":     &s.ioErrors,
+			} {
+				n, err := readHexUintFromFile(path.Join(dev, file))
+				if err != nil {
+					return nil, err
+				}
+				*v += float64(n)
+			}
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+func readISCSIConnections(root string) ([]iscsiConnection, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var conns []iscsiConnection
+	for _, d := range dirs {
+		// Connections are named connection<session id>:<connection id>.
+		conn := iscsiConnection{name: d.Name()}
+		if i := strings.Index(d.Name(), ":"); strings.HasPrefix(d.Name(), "connection") && i > 0 {
+			conn.session = "session" + d.Name()[len("connection"):i]
+		}
+		connPath := path.Join(root, d.Name())
+		if conn.address, err = readStringFromFile(path.Join(connPath, "persistent_address")); err != nil {
+			return nil, err
+		}
+		if conn.port, err = readStringFromFile(path.Join(connPath, "persistent_port")); err != nil {
+			return nil, err
+		}
+		// The connection state is only exposed since Linux 5.18.
+		conn.state, err = readStringFromFile(path.Join(connPath, "state"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		conns = append(conns, conn)
+	}
+	return conns, nil
+}
+
+// readHexUintFromFile reads a counter formatted like 0x1f, as used by the
+// SCSI device attributes.
+func readHexUintFromFile(path string) (uint64, error) {
+	s, err := readStringFromFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 0, 64)
+}
@@ -0,0 +1,63 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestISCSISessions(t *testing.T) {
+	sessions, err := readISCSISessions("fixtures/sys/class/iscsi_session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(sessions); want != got {
+		t.Fatalf("want %d sessions, got %d", want, got)
+	}
+
+	want := iscsiSession{
+		name:        "session1",
+		target:      "iqn.2017-01.org.example:storage.lun1",
+		tpgt:        "1",
+		state:       "LOGGED_IN",
+		recoveryTmo: 120,
+		ioRequests:  436,
+		ioDone:      435,
+		ioErrors:    2,
+	}
+	if got := sessions[0]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestISCSIConnections(t *testing.T) {
+	conns, err := readISCSIConnections("fixtures/sys/class/iscsi_connection")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(conns); want != got {
+		t.Fatalf("want %d connections, got %d", want, got)
+	}
+
+	want := iscsiConnection{
+		name:    "connection1:0",
+		session: "session1",
+		address: "192.0.2.10",
+		port:    "3260",
+		state:   "up",
+	}
+	if got := conns[0]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
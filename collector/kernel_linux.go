@@ -0,0 +1,193 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nokernel
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const kernelSubsystem = "kernel"
+
+var (
+	kernelModulesLimit = flag.Int("collector.kernel.modules-limit", 500, "Maximum number of loaded kernel modules to expose node_kernel_module_info for.")
+	kernelSysctls      = flag.String("collector.kernel.sysctls", "net.core.somaxconn,fs.file-max,net.ipv4.tcp_congestion_control", "Comma-separated list of sysctls to expose.")
+)
+
+type kernelModule struct {
+	name, state, taint, version string
+}
+
+type kernelCollector struct {
+	sysctls                      []string
+	moduleInfo, modules, tainted typedDesc
+	sysctl, sysctlInfo           typedDesc
+}
+
+func init() {
+	Factories["kernel"] = NewKernelCollector
+}
+
+// NewKernelCollector returns a new Collector exposing the loaded kernel
+// modules and selected sysctls.
+func NewKernelCollector() (Collector, error) {
+	var sysctls []string
+	for _, s := range strings.Split(*kernelSysctls, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			sysctls = append(sysctls, s)
+		}
+	}
+	return &kernelCollector{
+		sysctls: sysctls,
+		moduleInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "module_info"),
+			"Loaded kernel module with its version and taint flags, value is always 1.",
+			[]string{"module", "version", "state", "taint"}, nil,
+		), prometheus.GaugeValue},
+		modules: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "modules"),
+			"Number of loaded kernel modules.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		tainted: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "tainted"),
+			"Taint flags of the kernel as a bitmask, see Documentation/admin-guide/tainted-kernels.rst.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		sysctl: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "sysctl"),
+			"Value of a numeric sysctl, field is the position of the value in sysctls holding several.",
+			[]string{"name", "field"}, nil,
+		), prometheus.GaugeValue},
+		sysctlInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, kernelSubsystem, "sysctl_info"),
+			"Value of a non-numeric sysctl, value is always 1.",
+			[]string{"name", "value"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *kernelCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("modules"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	modules, err := parseKernelModules(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse modules: %s", err)
+	}
+	ch <- c.modules.mustNewConstMetric(float64(len(modules)))
+	if len(modules) > *kernelModulesLimit {
+		log.Debugf("Exposing %d of %d kernel modules, see --collector.kernel.modules-limit", *kernelModulesLimit, len(modules))
+		modules = modules[:*kernelModulesLimit]
+	}
+	for _, m := range modules {
+		version, err := readStringFromFile(sysFilePath(path.Join("module", m.name, "version")))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		ch <- c.moduleInfo.mustNewConstMetric(1, m.name, version, m.state, m.taint)
+	}
+
+	tainted, err := readUintFromFile(procFilePath("sys/kernel/tainted"))
+	if err != nil {
+		return err
+	}
+	ch <- c.tainted.mustNewConstMetric(float64(tainted))
+
+	for _, name := range c.sysctls {
+		value, err := readStringFromFile(procFilePath(path.Join("sys", strings.Replace(name, ".", "/", -1))))
+		if err != nil {
+			if os.IsNotExist(err) {
+				log.Debugf("Sysctl %s not available", name)
+				continue
+			}
+			return err
+		}
+		numbers, ok := parseSysctlNumbers(value)
+		if !ok {
+			ch <- c.sysctlInfo.mustNewConstMetric(1, name, value)
+			continue
+		}
+		if len(numbers) == 1 {
+			ch <- c.sysctl.mustNewConstMetric(numbers[0], name, "")
+			continue
+		}
+		for i, v := range numbers {
+			ch <- c.sysctl.mustNewConstMetric(v, name, strconv.Itoa(i))
+		}
+	}
+	return nil
+}
+
+// parseKernelModules parses /proc/modules and returns the modules sorted by
+// name.
+func parseKernelModules(r io.Reader) ([]kernelModule, error) {
+	var (
+		modules []kernelModule
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		// name size refcount dependencies state address [(taint)]
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 6 {
+			return nil, fmt.Errorf("invalid line in modules: %s", scanner.Text())
+		}
+		m := kernelModule{name: parts[0], state: parts[4]}
+		if len(parts) > 6 {
+			m.taint = strings.Trim(parts[6], "()")
+		}
+		modules = append(modules, m)
+	}
+	sort.Sort(kernelModulesByName(modules))
+	return modules, scanner.Err()
+}
+
+type kernelModulesByName []kernelModule
+
+func (m kernelModulesByName) Len() int           { return len(m) }
+func (m kernelModulesByName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m kernelModulesByName) Less(i, j int) bool { return m[i].name < m[j].name }
+
+// parseSysctlNumbers returns the values of a sysctl holding one or more
+// numbers, and false for other sysctls.
+func parseSysctlNumbers(value string) ([]float64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	numbers := make([]float64, 0, len(fields))
+	for _, f := range fields {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return nil, false
+		}
+		numbers = append(numbers, v)
+	}
+	return numbers, true
+}
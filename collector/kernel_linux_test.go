@@ -0,0 +1,56 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestKernelModules(t *testing.T) {
+	file, err := os.Open("fixtures/proc/modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	modules, err := parseKernelModules(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 6, len(modules); want != got {
+		t.Fatalf("want %d modules, got %d", want, got)
+	}
+	if want, got := (kernelModule{name: "nvidia", state: "Live", taint: "POE"}), modules[2]; want != got {
+		t.Errorf("want module %v, got %v", want, got)
+	}
+	if want, got := (kernelModule{name: "zfs", state: "Loading", taint: "PO"}), modules[5]; want != got {
+		t.Errorf("want module %v, got %v", want, got)
+	}
+}
+
+func TestParseSysctlNumbers(t *testing.T) {
+	for value, want := range map[string][]float64{
+		"4096":                  {4096},
+		"4096\t131072\t6291456": {4096, 131072, 6291456},
+		"bbr":                   nil,
+		"":                      nil,
+	} {
+		got, ok := parseSysctlNumbers(value)
+		if ok != (want != nil) || !reflect.DeepEqual(want, got) {
+			t.Errorf("%q: want %v, got %v", value, want, got)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// kstat holds the integer named values of a kernel statistic of illumos and
+// Solaris, identified by module, instance and name as in cpu:0:sys.
+type kstat struct {
+	instance int
+	name     string
+	values   map[string]uint64
+}
+
+// kstatReader reads all kstats of a module.
+type kstatReader interface {
+	Kstats(module string) ([]kstat, error)
+}
+
+// kstatFixture replays kstats from a directory holding the output of
+// `kstat -p <module>` in a file per module. It allows testing the illumos
+// collectors on any platform.
+type kstatFixture string
+
+func (dir kstatFixture) Kstats(module string) ([]kstat, error) {
+	file, err := os.Open(filepath.Join(string(dir), module))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		stats   []kstat
+		scanner = bufio.NewScanner(file)
+	)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "\t", 2)
+		id := strings.SplitN(parts[0], ":", 4)
+		if len(parts) != 2 || len(id) != 4 || id[0] != module {
+			return nil, fmt.Errorf("invalid line in kstats of %s: %q", module, scanner.Text())
+		}
+		// Times and strings like the class aren't named values.
+		value, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		instance, err := strconv.Atoi(id[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid instance in kstats of %s: %q", module, scanner.Text())
+		}
+		if n := len(stats); n == 0 || stats[n-1].instance != instance || stats[n-1].name != id[2] {
+			stats = append(stats, kstat{instance: instance, name: id[2], values: map[string]uint64{}})
+		}
+		stats[len(stats)-1].values[id[3]] = value
+	}
+	return stats, scanner.Err()
+}
+
+// lookupKstat returns the values of module:instance:name.
+func lookupKstat(r kstatReader, module string, instance int, name string) (map[string]uint64, error) {
+	stats, err := r.Kstats(module)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range stats {
+		if s.instance == instance && s.name == name {
+			return s.values, nil
+		}
+	}
+	return nil, fmt.Errorf("kstat %s:%d:%s not found", module, instance, name)
+}
@@ -0,0 +1,89 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build solaris
+
+package collector
+
+import "fmt"
+
+/*
+#cgo LDFLAGS: -lkstat
+#include <kstat.h>
+
+// named_value returns the integer value of a named kstat, the data types
+// not handled are strings and characters.
+static int named_value(kstat_t *ks, uint_t i, char **name, uint64_t *value) {
+	kstat_named_t *kn = &((kstat_named_t *)ks->ks_data)[i];
+	*name = kn->name;
+	switch (kn->data_type) {
+	case KSTAT_DATA_INT32:
+		*value = kn->value.i32;
+		return 1;
+	case KSTAT_DATA_UINT32:
+		*value = kn->value.ui32;
+		return 1;
+	case KSTAT_DATA_INT64:
+		*value = kn->value.i64;
+		return 1;
+	case KSTAT_DATA_UINT64:
+		*value = kn->value.ui64;
+		return 1;
+	}
+	return 0;
+}
+*/
+import "C"
+
+type nativeKstatReader struct{}
+
+func newKstatReader() kstatReader {
+	return nativeKstatReader{}
+}
+
+// Kstats opens the kstat chain on every call, so it is never out of date
+// with CPUs or links being added.
+func (nativeKstatReader) Kstats(module string) ([]kstat, error) {
+	kc, err := C.kstat_open()
+	if kc == nil {
+		return nil, fmt.Errorf("kstat_open failed: %s", err)
+	}
+	defer C.kstat_close(kc)
+
+	var stats []kstat
+	for ks := kc.kc_chain; ks != nil; ks = ks.ks_next {
+		if ks.ks_type != C.KSTAT_TYPE_NAMED || C.GoString(&ks.ks_module[0]) != module {
+			continue
+		}
+		if C.kstat_read(kc, ks, nil) == -1 {
+			// Kstats can go away between walking the chain and reading them.
+			continue
+		}
+		s := kstat{
+			instance: int(ks.ks_instance),
+			name:     C.GoString(&ks.ks_name[0]),
+			values:   map[string]uint64{},
+		}
+		for i := C.uint_t(0); i < ks.ks_ndata; i++ {
+			var (
+				name  *C.char
+				value C.uint64_t
+			)
+			if C.named_value(ks, i, &name, &value) == 1 {
+				s.values[C.GoString(name)] = uint64(value)
+			}
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"regexp"
+	"testing"
+)
+
+// omniosKstat holds the output of `kstat -p` on an OmniOS host.
+const omniosKstat = kstatFixture("fixtures/kstat/omnios")
+
+func TestKstatCPUTimes(t *testing.T) {
+	cpus, err := kstatCPUTimes(omniosKstat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(cpus); want != got {
+		t.Fatalf("want %d cpus, got %d", want, got)
+	}
+	if want, got := (cputime{user: 120.5, sys: 35.25, intr: 1.5, idle: 900}), cpus[0]; want != got {
+		t.Errorf("want cpu0 %+v, got %+v", want, got)
+	}
+	if want, got := (cputime{user: 98, sys: 41, intr: 0.5, idle: 910.25}), cpus[1]; want != got {
+		t.Errorf("want cpu1 %+v, got %+v", want, got)
+	}
+}
+
+func TestKstatMemInfo(t *testing.T) {
+	info, err := kstatMemInfo(omniosKstat, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]float64{
+		"total":     16 << 30,
+		"free":      4 << 30,
+		"available": 14 << 30,
+		"kernel":    2 << 30,
+		"locked":    2.5 * (1 << 30),
+	} {
+		if got := info[key]; want != got {
+			t.Errorf("want %s %f, got %f", key, want, got)
+		}
+	}
+}
+
+func TestKstatNetDevStats(t *testing.T) {
+	netDev, err := kstatNetDevStats(omniosKstat, regexp.MustCompile("^vnic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(netDev); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	for key, want := range map[string]string{
+		"receive_bytes":    "9876543210",
+		"transmit_packets": "4123456",
+		"receive_errs":     "2",
+		"receive_drop":     "5",
+		"transmit_colls":   "0",
+	} {
+		if got := netDev["e1000g0"][key]; want != got {
+			t.Errorf("want e1000g0 %s %s, got %s", key, want, got)
+		}
+	}
+}
+
+func TestKstatArcstats(t *testing.T) {
+	stats, err := kstatArcstats(omniosKstat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := uint64(987654321), stats["hits"]; want != got {
+		t.Errorf("want %d hits, got %d", want, got)
+	}
+	if want, got := uint64(3221225472), stats["size"]; want != got {
+		t.Errorf("want size %d, got %d", want, got)
+	}
+	if _, ok := stats["class"]; ok {
+		t.Error("want class skipped as it isn't a number")
+	}
+}
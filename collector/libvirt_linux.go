@@ -0,0 +1,218 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nolibvirt
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const libvirtSubsystem = "libvirt"
+
+var (
+	virshCommand = flag.String("collector.libvirt.command", "virsh", "Command to run virsh of libvirt.")
+	libvirtURI   = flag.String("collector.libvirt.uri", "qemu:///system", "URI of the libvirt daemon to connect to.")
+)
+
+// libvirtDomain holds the statistics of a domain as printed by virsh
+// domstats, keyed by their name like cpu.time or block.0.rd.bytes.
+type libvirtDomain struct {
+	name  string
+	stats map[string]string
+}
+
+// libvirtDeviceStat is a counter of block or network devices.
+type libvirtDeviceStat struct {
+	key   string
+	desc  *typedDesc
+	scale float64
+}
+
+type libvirtCollector struct {
+	cli, uri                       string
+	cpu, vcpu, balloon, balloonMax typedDesc
+	blockStats, netStats           []libvirtDeviceStat
+}
+
+func init() {
+	Factories["libvirt"] = NewLibvirtCollector
+}
+
+// NewLibvirtCollector returns a new Collector exposing CPU, memory and
+// device statistics of libvirt domains.
+func NewLibvirtCollector() (Collector, error) {
+	c := &libvirtCollector{
+		cli: *virshCommand,
+		uri: *libvirtURI,
+		cpu: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_cpu_seconds_total"),
+			"CPU time used by the domain.",
+			[]string{"domain"}, nil,
+		), prometheus.CounterValue},
+		vcpu: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_vcpu_seconds_total"),
+			"CPU time used by the virtual CPU of the domain.",
+			[]string{"domain", "vcpu"}, nil,
+		), prometheus.CounterValue},
+		balloon: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_balloon_bytes"),
+			"Current size of the memory balloon of the domain.",
+			[]string{"domain"}, nil,
+		), prometheus.GaugeValue},
+		balloonMax: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_balloon_max_bytes"),
+			"Maximum size of the memory balloon of the domain.",
+			[]string{"domain"}, nil,
+		), prometheus.GaugeValue},
+	}
+	for _, s := range []struct {
+		key, name, help string
+		scale           float64
+	}{
+		{"rd.reqs", "read_requests_total", "Read requests of the block device.", 1},
+		{"rd.bytes", "read_bytes_total", "Bytes read from the block device.", 1},
+		{"rd.times", "read_time_seconds_total", "Time spent on reads from the block device.", 1e-9},
+		{"wr.reqs", "write_requests_total", "Write requests of the block device.", 1},
+		{"wr.bytes", "written_bytes_total", "Bytes written to the block device.", 1},
+		{"wr.times", "write_time_seconds_total", "Time spent on writes to the block device.", 1e-9},
+		{"fl.reqs", "flush_requests_total", "Flush requests of the block device.", 1},
+	} {
+		c.blockStats = append(c.blockStats, libvirtDeviceStat{s.key, &typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_block_"+s.name),
+			s.help, []string{"domain", "device"}, nil,
+		), prometheus.CounterValue}, s.scale})
+	}
+	for _, s := range []struct{ key, name, help string }{
+		{"rx.bytes", "receive_bytes_total", "Bytes received on the network interface."},
+		{"rx.pkts", "receive_packets_total", "Packets received on the network interface."},
+		{"rx.errs", "receive_errors_total", "Receive errors on the network interface."},
+		{"rx.drop", "receive_drop_total", "Received packets dropped on the network interface."},
+		{"tx.bytes", "transmit_bytes_total", "Bytes transmitted on the network interface."},
+		{"tx.pkts", "transmit_packets_total", "Packets transmitted on the network interface."},
+		{"tx.errs", "transmit_errors_total", "Transmit errors on the network interface."},
+		{"tx.drop", "transmit_drop_total", "Transmitted packets dropped on the network interface."},
+	} {
+		c.netStats = append(c.netStats, libvirtDeviceStat{s.key, &typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, libvirtSubsystem, "domain_network_"+s.name),
+			s.help, []string{"domain", "device"}, nil,
+		), prometheus.CounterValue}, 1})
+	}
+	return c, nil
+}
+
+func (c *libvirtCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "--readonly", "--connect", c.uri, "domstats", "--raw",
+		"--cpu-total", "--balloon", "--vcpu", "--interface", "--block")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	domains, err := parseVirshDomStats(pipe)
+	if err != nil {
+		// Stop virsh, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	for _, d := range domains {
+		if v, ok := d.value("cpu.time"); ok {
+			ch <- c.cpu.mustNewConstMetric(v/1e9, d.name)
+		}
+		// Balloon sizes are in KiB.
+		if v, ok := d.value("balloon.current"); ok {
+			ch <- c.balloon.mustNewConstMetric(v*1024, d.name)
+		}
+		if v, ok := d.value("balloon.maximum"); ok {
+			ch <- c.balloonMax.mustNewConstMetric(v*1024, d.name)
+		}
+		vcpus, _ := d.value("vcpu.current")
+		for i := 0; i < int(vcpus); i++ {
+			if v, ok := d.value(fmt.Sprintf("vcpu.%d.time", i)); ok {
+				ch <- c.vcpu.mustNewConstMetric(v/1e9, d.name, strconv.Itoa(i))
+			}
+		}
+		c.collectDevices(ch, d, "block", c.blockStats)
+		c.collectDevices(ch, d, "net", c.netStats)
+	}
+	return nil
+}
+
+// collectDevices emits the statistics of the devices of a type, which are
+// numbered <type>.<n> with their name in <type>.<n>.name.
+func (c *libvirtCollector) collectDevices(ch chan<- prometheus.Metric, d libvirtDomain, typ string, stats []libvirtDeviceStat) {
+	count, _ := d.value(typ + ".count")
+	for i := 0; i < int(count); i++ {
+		prefix := fmt.Sprintf("%s.%d.", typ, i)
+		device := d.stats[prefix+"name"]
+		for _, s := range stats {
+			if v, ok := d.value(prefix + s.key); ok {
+				ch <- s.desc.mustNewConstMetric(v*s.scale, d.name, device)
+			}
+		}
+	}
+}
+
+func (d libvirtDomain) value(key string) (float64, bool) {
+	s, ok := d.stats[key]
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	return v, err == nil
+}
+
+// parseVirshDomStats parses the output of virsh domstats, which prints a
+// Domain: '<name>' line followed by indented <key>=<value> lines for every
+// domain.
+func parseVirshDomStats(r io.Reader) ([]libvirtDomain, error) {
+	var (
+		domains []libvirtDomain
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "Domain:") {
+			name := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "Domain:")), "'")
+			domains = append(domains, libvirtDomain{name: name, stats: map[string]string{}})
+			continue
+		}
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || len(domains) == 0 {
+			return nil, fmt.Errorf("invalid line: %s", line)
+		}
+		domains[len(domains)-1].stats[kv[0]] = kv[1]
+	}
+	return domains, scanner.Err()
+}
@@ -0,0 +1,59 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestVirshDomStats(t *testing.T) {
+	file, err := os.Open("fixtures/virsh_domstats.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	domains, err := parseVirshDomStats(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(domains); want != got {
+		t.Fatalf("want %d domains, got %d", want, got)
+	}
+
+	web := domains[0]
+	if want, got := "web1", web.name; want != got {
+		t.Errorf("want domain %s, got %s", want, got)
+	}
+	for key, want := range map[string]float64{
+		"cpu.time":         1532456789012,
+		"vcpu.1.time":      652000000000,
+		"block.0.wr.bytes": 98304000,
+		"net.0.rx.drop":    4,
+		"balloon.current":  2097152,
+	} {
+		if got, ok := web.value(key); !ok || want != got {
+			t.Errorf("want %s %f, got %f", key, want, got)
+		}
+	}
+	if want, got := "vda", web.stats["block.0.name"]; want != got {
+		t.Errorf("want block device %s, got %s", want, got)
+	}
+
+	// Stopped domains only report static values.
+	if _, ok := domains[1].value("cpu.time"); ok {
+		t.Error("want no CPU time for db1")
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nolldp
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	lldpctlCommand = flag.String("collector.lldp.command", "lldpctl", "Command to run lldpctl of lldpd.")
+)
+
+// Keys identifying chassis and port in lldpctl output, ordered by preference.
+var (
+	lldpChassisIDKeys = []string{"chassis.mac", "chassis.local", "chassis.ip", "chassis.ifname"}
+	lldpPortIDKeys    = []string{"port.ifname", "port.mac", "port.local", "port.ip"}
+)
+
+type lldpNeighbor struct {
+	iface      string
+	chassisID  string
+	portID     string
+	systemName string
+}
+
+type lldpCollector struct {
+	cli       string
+	neighbors typedDesc
+	info      typedDesc
+}
+
+func init() {
+	Factories["lldp"] = NewLLDPCollector
+}
+
+// NewLLDPCollector returns a new Collector exposing LLDP neighbors as seen
+// by lldpd.
+func NewLLDPCollector() (Collector, error) {
+	return &lldpCollector{
+		cli: *lldpctlCommand,
+		neighbors: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "lldp", "neighbors"),
+			"Number of LLDP neighbors seen on the interface.",
+			[]string{"interface"}, nil,
+		), prometheus.GaugeValue},
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "lldp", "neighbor_info"),
+			"LLDP neighbor seen on the interface, value is always 1.",
+			[]string{"interface", "chassis_id", "port_id", "system_name"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *lldpCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "-f", "keyvalue")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	neighbors, err := parseLLDPNeighbors(pipe)
+	if err != nil {
+		// Stop lldpctl, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, n := range neighbors {
+		counts[n.iface]++
+		ch <- c.info.mustNewConstMetric(1, n.iface, n.chassisID, n.portID, n.systemName)
+	}
+	for iface, count := range counts {
+		ch <- c.neighbors.mustNewConstMetric(float64(count), iface)
+	}
+	return nil
+}
+
+// parseLLDPNeighbors parses the output of `lldpctl -f keyvalue`. Every
+// neighbor starts with a lldp.<interface>.via line, so an interface with
+// multiple neighbors yields multiple entries.
+func parseLLDPNeighbors(r io.Reader) ([]lldpNeighbor, error) {
+	var (
+		neighbors []lldpNeighbor
+		current   map[string]string
+		iface     string
+		scanner   = bufio.NewScanner(r)
+	)
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		n := lldpNeighbor{
+			iface:      iface,
+			systemName: current["chassis.name"],
+		}
+		n.chassisID = firstLLDPValue(current, lldpChassisIDKeys)
+		n.portID = firstLLDPValue(current, lldpPortIDKeys)
+		neighbors = append(neighbors, n)
+	}
+
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if !strings.HasPrefix(parts[0], "lldp.") {
+			continue
+		}
+		// Interface names can contain dots, e.g. eth0.100 of a VLAN, so
+		// they are taken from the via line and stripped from the keys
+		// following it.
+		key := strings.TrimPrefix(parts[0], "lldp.")
+		if strings.HasSuffix(key, ".via") {
+			flush()
+			iface = strings.TrimSuffix(key, ".via")
+			current = map[string]string{}
+			continue
+		}
+		if current != nil && strings.HasPrefix(key, iface+".") {
+			current[strings.TrimPrefix(key, iface+".")] = parts[1]
+		}
+	}
+	flush()
+
+	return neighbors, scanner.Err()
+}
+
+func firstLLDPValue(values map[string]string, keys []string) string {
+	for _, k := range keys {
+		if v, ok := values[k]; ok {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLLDPNeighbors(t *testing.T) {
+	file, err := os.Open("fixtures/lldpctl.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	neighbors, err := parseLLDPNeighbors(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []lldpNeighbor{
+		{iface: "eth0", chassisID: "00:1b:21:3c:9d:40", portID: "Gi1/0/12", systemName: "sw-core-01.example.com"},
+		{iface: "eth1", chassisID: "sw-core-02", portID: "00:1b:21:3c:9e:0c", systemName: "sw-core-02.example.com"},
+		{iface: "eth0.100", chassisID: "00:1b:21:3c:9f:01", portID: "Gi0/7", systemName: "sw-access-03.example.com"},
+	}
+	if len(neighbors) != len(want) {
+		t.Fatalf("want %d neighbors, got %d", len(want), len(neighbors))
+	}
+	for i, n := range neighbors {
+		if n != want[i] {
+			t.Errorf("want neighbor %+v, got %+v", want[i], n)
+		}
+	}
+}
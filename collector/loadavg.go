@@ -38,6 +38,9 @@ func NewLoadavgCollector() (Collector, error) {
 			{prometheus.NewDesc(Namespace+"_load1", "1m load average.", nil, nil), prometheus.GaugeValue},
 			{prometheus.NewDesc(Namespace+"_load5", "5m load average.", nil, nil), prometheus.GaugeValue},
 			{prometheus.NewDesc(Namespace+"_load15", "15m load average.", nil, nil), prometheus.GaugeValue},
+			// Only on Linux.
+			{prometheus.NewDesc(Namespace+"_load_runnable_entities", "Number of currently runnable processes and threads.", nil, nil), prometheus.GaugeValue},
+			{prometheus.NewDesc(Namespace+"_load_scheduling_entities", "Number of processes and threads that currently exist.", nil, nil), prometheus.GaugeValue},
 		},
 	}, nil
 }
@@ -35,11 +35,12 @@ func getLoad() (loads []float64, err error) {
 	return loads, nil
 }
 
-// Parse /proc loadavg and return 1m, 5m and 15m.
+// Parse /proc loadavg and return 1m, 5m and 15m followed by the number of
+// runnable and existing scheduling entities.
 func parseLoad(data string) (loads []float64, err error) {
-	loads = make([]float64, 3)
+	loads = make([]float64, 5)
 	parts := strings.Fields(data)
-	if len(parts) < 3 {
+	if len(parts) < 4 {
 		return nil, fmt.Errorf("unexpected content in %s", procFilePath("loadavg"))
 	}
 	for i, load := range parts[0:3] {
@@ -48,5 +49,15 @@ func parseLoad(data string) (loads []float64, err error) {
 			return nil, fmt.Errorf("could not parse load '%s': %s", load, err)
 		}
 	}
+	entities := strings.Split(parts[3], "/")
+	if len(entities) != 2 {
+		return nil, fmt.Errorf("could not parse scheduling entities '%s'", parts[3])
+	}
+	for i, e := range entities {
+		loads[3+i], err = strconv.ParseFloat(e, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse scheduling entities '%s': %s", parts[3], err)
+		}
+	}
 	return loads, nil
 }
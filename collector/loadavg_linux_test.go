@@ -16,12 +16,15 @@ package collector
 import "testing"
 
 func TestLoad(t *testing.T) {
-	want := []float64{0.21, 0.37, 0.39}
+	want := []float64{0.21, 0.37, 0.39, 1, 719}
 	loads, err := parseLoad("0.21 0.37 0.39 1/719 19737")
 	if err != nil {
 		t.Fatal(err)
 	}
 
+	if len(want) != len(loads) {
+		t.Fatalf("want %d values, got %d", len(want), len(loads))
+	}
 	for i, load := range loads {
 		if want[i] != load {
 			t.Fatalf("want load %f, got %f", want[i], load)
@@ -0,0 +1,42 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noloadavg
+
+package collector
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// sysctlLoad returns the load averages from the vm.loadavg sysctl, a
+// struct loadavg of three fixpt_t followed by the long fscale.
+func sysctlLoad(s sysctlReader) ([]float64, error) {
+	b, err := s.SysctlRaw("vm.loadavg")
+	if err != nil {
+		return nil, err
+	}
+	// fscale is aligned to the size of a long.
+	size := strconv.IntSize / 8
+	offset := (12 + size - 1) / size * size
+	if len(b) < offset+size {
+		return nil, fmt.Errorf("sysctl vm.loadavg has %d bytes, expected %d", len(b), offset+size)
+	}
+	scale := float64(sysctlLong(b[offset:]))
+	return []float64{
+		float64(nativeEndian.Uint32(b[0:])) / scale,
+		float64(nativeEndian.Uint32(b[4:])) / scale,
+		float64(nativeEndian.Uint32(b[8:])) / scale,
+	}, nil
+}
@@ -18,7 +18,10 @@ package collector
 import (
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/godbus/dbus"
 	"github.com/prometheus/client_golang/prometheus"
@@ -41,6 +44,26 @@ var (
 		prometheus.BuildFQName(Namespace, logindSubsystem, "sessions"),
 		"Number of sessions registered in logind.", []string{"seat", "remote", "type", "class"}, nil,
 	)
+	lidClosedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, logindSubsystem, "lid_closed"),
+		"Whether the lid of the machine is closed.", nil, nil,
+	)
+	idleDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, logindSubsystem, "idle"),
+		"Whether all sessions are idle.", nil, nil,
+	)
+	idleSinceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, logindSubsystem, "idle_since_seconds"),
+		"Time the idle state last changed in unixtime.", nil, nil,
+	)
+	preparingForSleepDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, logindSubsystem, "preparing_for_sleep"),
+		"Whether the machine is about to suspend or hibernate.", nil, nil,
+	)
+	displayPowerDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "display", "power_on"),
+		"Whether the DPMS state of the connected display is on.", []string{"connector"}, nil,
+	)
 )
 
 type logindCollector struct{}
@@ -54,6 +77,15 @@ type logindInterface interface {
 	listSeats() ([]string, error)
 	listSessions() ([]logindSessionEntry, error)
 	getSession(logindSessionEntry) *logindSession
+	getManager() (*logindManager, error)
+}
+
+// logindManager holds the power related properties of the logind manager.
+type logindManager struct {
+	lidClosed         bool
+	idle              bool
+	idleSince         uint64
+	preparingForSleep bool
 }
 
 type logindSession struct {
@@ -94,7 +126,18 @@ func (lc *logindCollector) Update(ch chan<- prometheus.Metric) error {
 	}
 	defer c.conn.Close()
 
-	return collectMetrics(ch, c)
+	if err := collectMetrics(ch, c); err != nil {
+		return err
+	}
+
+	displays, err := readDisplayPower(sysFilePath("class/drm"))
+	if err != nil {
+		return fmt.Errorf("couldn't read display power state: %s", err)
+	}
+	for connector, on := range displays {
+		ch <- prometheus.MustNewConstMetric(displayPowerDesc, prometheus.GaugeValue, boolToFloat(on), connector)
+	}
+	return nil
 }
 
 func collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
@@ -131,9 +174,48 @@ func collectMetrics(ch chan<- prometheus.Metric, c logindInterface) error {
 		}
 	}
 
+	m, err := c.getManager()
+	if err != nil {
+		return fmt.Errorf("unable to get manager properties: %s", err)
+	}
+	ch <- prometheus.MustNewConstMetric(lidClosedDesc, prometheus.GaugeValue, boolToFloat(m.lidClosed))
+	ch <- prometheus.MustNewConstMetric(idleDesc, prometheus.GaugeValue, boolToFloat(m.idle))
+	ch <- prometheus.MustNewConstMetric(idleSinceDesc, prometheus.GaugeValue, float64(m.idleSince)/1e6)
+	ch <- prometheus.MustNewConstMetric(preparingForSleepDesc, prometheus.GaugeValue, boolToFloat(m.preparingForSleep))
+
 	return nil
 }
 
+// readDisplayPower returns the DPMS state of the connected displays by DRM
+// connector, e.g. card0-DP-1. logind doesn't track it, so it is read from
+// sysfs.
+func readDisplayPower(root string) (map[string]bool, error) {
+	connectors, err := filepath.Glob(path.Join(root, "card[0-9]*-*"))
+	if err != nil {
+		return nil, err
+	}
+	displays := map[string]bool{}
+	for _, connector := range connectors {
+		status, err := readStringFromFile(path.Join(connector, "status"))
+		if err != nil || status != "connected" {
+			continue
+		}
+		dpms, err := readStringFromFile(path.Join(connector, "dpms"))
+		if err != nil {
+			continue
+		}
+		displays[path.Base(connector)] = strings.EqualFold(dpms, "On")
+	}
+	return displays, nil
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func knownStringOrOther(value string, known []string) string {
 	for i := range known {
 		if value == known[i] {
@@ -266,3 +348,33 @@ func (c *logindDbus) getSession(session logindSessionEntry) *logindSession {
 		class:       knownStringOrOther(classStr, attrClassValues),
 	}
 }
+
+func (c *logindDbus) getManager() (*logindManager, error) {
+	m := &logindManager{}
+	for name, target := range map[string]*bool{
+		"LidClosed":         &m.lidClosed,
+		"IdleHint":          &m.idle,
+		"PreparingForSleep": &m.preparingForSleep,
+	} {
+		v, err := c.object.GetProperty(dbusObject + ".Manager." + name)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := v.Value().(bool)
+		if !ok {
+			return nil, fmt.Errorf("unexpected type %s of %s", v.Signature(), name)
+		}
+		*target = b
+	}
+
+	v, err := c.object.GetProperty(dbusObject + ".Manager.IdleSinceHint")
+	if err != nil {
+		return nil, err
+	}
+	idleSince, ok := v.Value().(uint64)
+	if !ok {
+		return nil, fmt.Errorf("unexpected type %s of IdleSinceHint", v.Signature())
+	}
+	m.idleSince = idleSince
+	return m, nil
+}
@@ -66,6 +66,10 @@ func (c *testLogindInterface) getSession(session logindSessionEntry) *logindSess
 	return sessions[session.SessionObjectPath]
 }
 
+func (c *testLogindInterface) getManager() (*logindManager, error) {
+	return &logindManager{lidClosed: true, idle: true, idleSince: 1500000000000000}, nil
+}
+
 func TestLogindCollectorKnownStringOrOther(t *testing.T) {
 	known := []string{"foo", "bar"}
 
@@ -95,8 +99,25 @@ func TestLogindCollectorCollectMetrics(t *testing.T) {
 		count++
 	}
 
-	expected := len(testSeats) * len(attrRemoteValues) * len(attrTypeValues) * len(attrClassValues)
+	// Sessions and the lid, idle, idle since and sleep state of the manager.
+	expected := len(testSeats)*len(attrRemoteValues)*len(attrTypeValues)*len(attrClassValues) + 4
 	if count != expected {
 		t.Errorf("collectMetrics did not generate the expected number of metrics: got %d, expected %d.", count, expected)
 	}
 }
+
+func TestReadDisplayPower(t *testing.T) {
+	displays, err := readDisplayPower("fixtures/sys/class/drm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"card0-DP-1": true, "card1-eDP-1": false}
+	if len(displays) != len(want) {
+		t.Fatalf("want %d displays, got %v", len(want), displays)
+	}
+	for connector, on := range want {
+		if got, ok := displays[connector]; !ok || got != on {
+			t.Errorf("want %s power on %t, got %t", connector, on, got)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noloop
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	loopSubsystem  = "loop"
+	loopSectorSize = 512
+)
+
+type loopDevice struct {
+	name        string
+	backingFile string
+	offset      string
+	autoclear   string
+	size        float64
+	// Fields of the block device stat file, see Documentation/block/stat.rst.
+	stat []float64
+}
+
+type loopCollector struct {
+	info, size           typedDesc
+	reads, readBytes     typedDesc
+	writes, writtenBytes typedDesc
+}
+
+func init() {
+	Factories["loop"] = NewLoopCollector
+}
+
+// NewLoopCollector returns a new Collector exposing attached loop devices
+// and their backing files.
+func NewLoopCollector() (Collector, error) {
+	labels := []string{"device"}
+	return &loopCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loopSubsystem, "device_info"),
+			"Backing file of the loop device, value is always 1.",
+			[]string{"device", "backing_file", "offset", "autoclear"}, nil,
+		), prometheus.GaugeValue},
+		size: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loopSubsystem, "device_size_bytes"),
+			"Size of the loop device.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		reads: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loopSubsystem, "reads_completed_total"),
+			"Reads completed by the loop device.",
+			labels, nil,
+		), prometheus.CounterValue},
+		readBytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loopSubsystem, "read_bytes_total"),
+			"Bytes read from the loop device.",
+			labels, nil,
+		), prometheus.CounterValue},
+		writes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loopSubsystem, "writes_completed_total"),
+			"Writes completed by the loop device.",
+			labels, nil,
+		), prometheus.CounterValue},
+		writtenBytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, loopSubsystem, "written_bytes_total"),
+			"Bytes written to the loop device.",
+			labels, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *loopCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readLoopDevices(sysFilePath("block"))
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		ch <- c.info.mustNewConstMetric(1, d.name, d.backingFile, d.offset, d.autoclear)
+		ch <- c.size.mustNewConstMetric(d.size, d.name)
+		ch <- c.reads.mustNewConstMetric(d.stat[0], d.name)
+		ch <- c.readBytes.mustNewConstMetric(d.stat[2]*loopSectorSize, d.name)
+		ch <- c.writes.mustNewConstMetric(d.stat[4], d.name)
+		ch <- c.writtenBytes.mustNewConstMetric(d.stat[6]*loopSectorSize, d.name)
+	}
+	return nil
+}
+
+// readLoopDevices returns the loop devices that have a backing file, which
+// the kernel indicates by the loop directory.
+func readLoopDevices(root string) ([]loopDevice, error) {
+	dirs, err := filepath.Glob(path.Join(root, "loop*", "loop"))
+	if err != nil {
+		return nil, err
+	}
+	var devices []loopDevice
+	for _, dir := range dirs {
+		devPath := path.Dir(dir)
+		d := loopDevice{name: path.Base(devPath)}
+		if d.backingFile, err = readStringFromFile(path.Join(dir, "backing_file")); err != nil {
+			if os.IsNotExist(err) {
+				// Detached in the meantime.
+				continue
+			}
+			return nil, err
+		}
+		if d.offset, err = readStringFromFile(path.Join(dir, "offset")); err != nil {
+			return nil, err
+		}
+		if d.autoclear, err = readStringFromFile(path.Join(dir, "autoclear")); err != nil {
+			return nil, err
+		}
+		sectors, err := readUintFromFile(path.Join(devPath, "size"))
+		if err != nil {
+			return nil, err
+		}
+		d.size = float64(sectors * loopSectorSize)
+
+		stat, err := ioutil.ReadFile(path.Join(devPath, "stat"))
+		if err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(string(stat))
+		if len(fields) < 11 {
+			return nil, fmt.Errorf("invalid stat of %s: %s", d.name, stat)
+		}
+		for _, f := range fields {
+			v, err := strconv.ParseFloat(f, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stat of %s: %s", d.name, stat)
+			}
+			d.stat = append(d.stat, v)
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
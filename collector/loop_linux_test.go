@@ -0,0 +1,46 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestLoopDevices(t *testing.T) {
+	devices, err := readLoopDevices("fixtures/sys/block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// loop1 has no backing file.
+	if want, got := 1, len(devices); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+
+	d := devices[0]
+	if want, got := "loop0", d.name; want != got {
+		t.Errorf("want device %s, got %s", want, got)
+	}
+	if want, got := "/var/lib/snapd/snaps/core_1234.snap", d.backingFile; want != got {
+		t.Errorf("want backing file %s, got %s", want, got)
+	}
+	if want, got := "1", d.autoclear; want != got {
+		t.Errorf("want autoclear %s, got %s", want, got)
+	}
+	if want, got := 119537664.0, d.size; want != got {
+		t.Errorf("want size %f, got %f", want, got)
+	}
+	if want, got := 45202.0, d.stat[2]; want != got {
+		t.Errorf("want %f sectors read, got %f", want, got)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nolvm
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const lvmSubsystem = "lvm"
+
+var (
+	lvsCommand = flag.String("collector.lvm.command", "lvs", "Command to run lvs of LVM2.")
+)
+
+type lvmVolume struct {
+	VG       string `json:"vg_name"`
+	Name     string `json:"lv_name"`
+	SegType  string `json:"segtype"`
+	Pool     string `json:"pool_lv"`
+	Origin   string `json:"origin"`
+	Size     string `json:"lv_size"`
+	Data     string `json:"data_percent"`
+	Metadata string `json:"metadata_percent"`
+}
+
+type lvmCollector struct {
+	cli                        string
+	info, size, data, metadata typedDesc
+}
+
+func init() {
+	Factories["lvm"] = NewLVMCollector
+}
+
+// NewLVMCollector returns a new Collector exposing LVM logical volume sizes
+// and thin pool and snapshot usage.
+func NewLVMCollector() (Collector, error) {
+	labels := []string{"vg", "lv"}
+	return &lvmCollector{
+		cli: *lvsCommand,
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, lvmSubsystem, "lv_info"),
+			"Type, thin pool and origin of the logical volume, value is always 1.",
+			[]string{"vg", "lv", "type", "pool", "origin"}, nil,
+		), prometheus.GaugeValue},
+		size: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, lvmSubsystem, "lv_size_bytes"),
+			"Size of the logical volume.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		data: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, lvmSubsystem, "lv_data_usage_ratio"),
+			"Allocated fraction of the data of thin pools, thin volumes and snapshots.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		metadata: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, lvmSubsystem, "lv_metadata_usage_ratio"),
+			"Allocated fraction of the metadata of thin pools.",
+			labels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *lvmCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "--reportformat", "json", "--units", "b", "--nosuffix",
+		"-o", "vg_name,lv_name,segtype,pool_lv,origin,lv_size,data_percent,metadata_percent")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	volumes, err := parseLVMReport(pipe)
+	if err != nil {
+		// Stop lvs, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	for _, lv := range volumes {
+		ch <- c.info.mustNewConstMetric(1, lv.VG, lv.Name, lv.SegType, lv.Pool, lv.Origin)
+		size, err := strconv.ParseFloat(lv.Size, 64)
+		if err != nil {
+			return fmt.Errorf("invalid size of %s/%s: %s", lv.VG, lv.Name, err)
+		}
+		ch <- c.size.mustNewConstMetric(size, lv.VG, lv.Name)
+		// Percentages are empty for volumes they don't apply to.
+		if v, err := strconv.ParseFloat(lv.Data, 64); err == nil {
+			ch <- c.data.mustNewConstMetric(v/100, lv.VG, lv.Name)
+		}
+		if v, err := strconv.ParseFloat(lv.Metadata, 64); err == nil {
+			ch <- c.metadata.mustNewConstMetric(v/100, lv.VG, lv.Name)
+		}
+	}
+	return nil
+}
+
+// parseLVMReport parses the output of `lvs --reportformat json`.
+func parseLVMReport(r io.Reader) ([]lvmVolume, error) {
+	var doc struct {
+		Report []struct {
+			LV []lvmVolume `json:"lv"`
+		} `json:"report"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var volumes []lvmVolume
+	for _, report := range doc.Report {
+		volumes = append(volumes, report.LV...)
+	}
+	return volumes, nil
+}
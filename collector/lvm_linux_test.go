@@ -0,0 +1,52 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLVMReport(t *testing.T) {
+	file, err := os.Open("fixtures/lvs.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	volumes, err := parseLVMReport(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 4, len(volumes); want != got {
+		t.Fatalf("want %d volumes, got %d", want, got)
+	}
+
+	pool := volumes[2]
+	if want, got := "thin-pool", pool.SegType; want != got {
+		t.Errorf("want segment type %s, got %s", want, got)
+	}
+	if want, got := "87.50", pool.Data; want != got {
+		t.Errorf("want data percent %s, got %s", want, got)
+	}
+	if want, got := "12.25", pool.Metadata; want != got {
+		t.Errorf("want metadata percent %s, got %s", want, got)
+	}
+	if want, got := "thinpool", volumes[3].Pool; want != got {
+		t.Errorf("want pool %s, got %s", want, got)
+	}
+	if want, got := "root", volumes[1].Origin; want != got {
+		t.Errorf("want origin %s, got %s", want, got)
+	}
+}
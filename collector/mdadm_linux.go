@@ -33,8 +33,13 @@ var (
 	buildlineRE              = regexp.MustCompile(`\((\d+)/\d+\)`)
 	unknownPersonalityLineRE = regexp.MustCompile(`(\d+) blocks (.*)`)
 	raidPersonalityRE        = regexp.MustCompile(`raid[0-9]+`)
+	synclineRE               = regexp.MustCompile(`(recovery|resync|check|reshape)\s*=\s*([0-9.]+)%.*speed=(\d+)K/sec`)
 )
 
+// mdDeviceStates are the states of md devices, active and inactive arrays and
+// the sync actions of active ones.
+var mdDeviceStates = []string{"active", "inactive", "recovering", "resync", "check", "reshape"}
+
 type mdStatus struct {
 	mdName       string
 	isActive     bool
@@ -42,6 +47,13 @@ type mdStatus struct {
 	disksTotal   int64
 	blocksTotal  int64
 	blocksSynced int64
+	disksFailed  int64
+	disksSpare   int64
+	// The running sync action and its progress, empty if in sync.
+	syncAction    string
+	syncCompleted float64
+	// Bytes per second.
+	syncSpeed float64
 }
 
 type mdadmCollector struct{}
@@ -131,6 +143,23 @@ func evalBuildline(buildline string) (int64, error) {
 	return syncedSize, nil
 }
 
+// Gets the action, completed ratio and speed out of the sync-line.
+func evalSyncline(syncline string) (action string, completed, speed float64, err error) {
+	matches := synclineRE.FindStringSubmatch(syncline)
+	if len(matches) != 3+1 {
+		return "", 0, 0, fmt.Errorf("invalid sync line: %s", syncline)
+	}
+	percent, err := strconv.ParseFloat(matches[2], 64)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("%s in sync line: %s", err, syncline)
+	}
+	kbytes, err := strconv.ParseFloat(matches[3], 64)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("%s in sync line: %s", err, syncline)
+	}
+	return matches[1], percent / 100, kbytes * 1024, nil
+}
+
 // Parses an mdstat-file and returns a struct with the relevant infos.
 func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 	content, err := ioutil.ReadFile(mdStatusFilePath)
@@ -175,6 +204,17 @@ func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 		}
 		currentMD = mainLine[0]               // The name of the md-device.
 		isActive := (mainLine[2] == "active") // The activity status of the md-device.
+		// Member disks are listed like sda1[0], failed and spare ones are
+		// suffixed with (F) and (S).
+		var failed, spare int64
+		for _, member := range mainLine[3:] {
+			switch {
+			case strings.HasSuffix(member, "(F)"):
+				failed++
+			case strings.HasSuffix(member, "(S)"):
+				spare++
+			}
+		}
 		personality = ""
 		for _, possiblePersonality := range mainLine[3:] {
 			if raidPersonalityRE.MatchString(possiblePersonality) {
@@ -216,18 +256,25 @@ func parseMdstat(mdStatusFilePath string) ([]mdStatus, error) {
 
 		// If device is syncing at the moment, get the number of currently synced bytes,
 		// otherwise that number equals the size of the device.
-		if strings.Contains(lines[j], "recovery") ||
-			strings.Contains(lines[j], "resync") &&
-				!strings.Contains(lines[j], "\tresync=") {
+		var (
+			syncAction           string
+			completed, syncSpeed float64
+		)
+		if synclineRE.MatchString(lines[j]) {
 			syncedBlocks, err = evalBuildline(lines[j])
 			if err != nil {
 				return mdStates, fmt.Errorf("error parsing mdstat: %s", err)
 			}
+			syncAction, completed, syncSpeed, err = evalSyncline(lines[j])
+			if err != nil {
+				return mdStates, fmt.Errorf("error parsing mdstat: %s", err)
+			}
 		} else {
 			syncedBlocks = size
+			completed = 1
 		}
 
-		mdStates = append(mdStates, mdStatus{currentMD, isActive, active, total, size, syncedBlocks})
+		mdStates = append(mdStates, mdStatus{currentMD, isActive, active, total, size, syncedBlocks, failed, spare, syncAction, completed, syncSpeed})
 
 	}
 
@@ -274,6 +321,41 @@ var (
 		[]string{"device"},
 		nil,
 	)
+
+	disksFailedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "disks_failed"),
+		"Number of failed disks of device.",
+		[]string{"device"},
+		nil,
+	)
+
+	disksSpareDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "disks_spare"),
+		"Number of spare disks of device.",
+		[]string{"device"},
+		nil,
+	)
+
+	stateDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "state"),
+		"State of device, active arrays are in the state of their running sync action.",
+		[]string{"device", "state"},
+		nil,
+	)
+
+	syncCompletedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "sync_completed_ratio"),
+		"Progress of the running sync action of device, 1 if there is none.",
+		[]string{"device"},
+		nil,
+	)
+
+	syncSpeedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "md", "sync_speed_bytes_per_second"),
+		"Speed of the running sync action of device.",
+		[]string{"device"},
+		nil,
+	)
 )
 
 func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -343,6 +425,51 @@ func (c *mdadmCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			mds.mdName,
 		)
 
+		ch <- prometheus.MustNewConstMetric(
+			disksFailedDesc,
+			prometheus.GaugeValue,
+			float64(mds.disksFailed),
+			mds.mdName,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			disksSpareDesc,
+			prometheus.GaugeValue,
+			float64(mds.disksSpare),
+			mds.mdName,
+		)
+
+		state := "inactive"
+		switch {
+		case mds.isActive && mds.syncAction == "recovery":
+			state = "recovering"
+		case mds.isActive && mds.syncAction != "":
+			state = mds.syncAction
+		case mds.isActive:
+			state = "active"
+		}
+		for _, s := range mdDeviceStates {
+			v := 0.0
+			if s == state {
+				v = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, v, mds.mdName, s)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			syncCompletedDesc,
+			prometheus.GaugeValue,
+			mds.syncCompleted,
+			mds.mdName,
+		)
+
+		ch <- prometheus.MustNewConstMetric(
+			syncSpeedDesc,
+			prometheus.GaugeValue,
+			mds.syncSpeed,
+			mds.mdName,
+		)
+
 	}
 
 	return nil
@@ -25,20 +25,22 @@ func TestMdadm(t *testing.T) {
 	}
 
 	refs := map[string]mdStatus{
-		// { "<name>", <active?>, <numDisksActive>, <totalNumDisks>, <amountSynced>, <totalSize>}
-		"md3":   {"md3", true, 8, 8, 5853468288, 5853468288},
-		"md127": {"md127", true, 2, 2, 312319552, 312319552},
-		"md0":   {"md0", true, 2, 2, 248896, 248896},
-		"md4":   {"md4", false, 2, 2, 4883648, 4883648},
-		"md6":   {"md6", true, 1, 2, 195310144, 16775552},
-		"md8":   {"md8", true, 2, 2, 195310144, 16775552},
-		"md7":   {"md7", true, 3, 4, 7813735424, 7813735424},
-		"md9":   {"md9", true, 4, 4, 523968, 523968},
-		"md10":  {"md10", true, 2, 2, 314159265, 314159265},
-		"md11":  {"md11", true, 2, 2, 4190208, 4190208},
-		"md12":  {"md12", true, 2, 2, 3886394368, 3886394368},
-		"md219": {"md219", false, 2, 2, 7932, 7932},
-		"md00":  {"md00", true, 1, 1, 4186624, 4186624},
+		// { "<name>", <active?>, <numDisksActive>, <totalNumDisks>, <amountSynced>, <totalSize>, <failed>, <spare>, <syncAction>, <syncCompleted>, <syncSpeed>}
+		"md3":   {"md3", true, 8, 8, 5853468288, 5853468288, 0, 0, "", 1, 0},
+		"md127": {"md127", true, 2, 2, 312319552, 312319552, 0, 0, "", 1, 0},
+		"md0":   {"md0", true, 2, 2, 248896, 248896, 0, 0, "", 1, 0},
+		"md4":   {"md4", false, 2, 2, 4883648, 4883648, 0, 0, "", 1, 0},
+		"md6":   {"md6", true, 1, 2, 195310144, 16775552, 0, 0, "recovery", 0.085, 259783 * 1024},
+		"md8":   {"md8", true, 2, 2, 195310144, 16775552, 0, 0, "resync", 0.085, 259783 * 1024},
+		"md7":   {"md7", true, 3, 4, 7813735424, 7813735424, 0, 0, "", 1, 0},
+		"md9":   {"md9", true, 4, 4, 523968, 523968, 0, 0, "", 1, 0},
+		"md10":  {"md10", true, 2, 2, 314159265, 314159265, 0, 0, "", 1, 0},
+		"md11":  {"md11", true, 2, 2, 4190208, 4190208, 0, 0, "", 1, 0},
+		"md12":  {"md12", true, 2, 2, 3886394368, 3886394368, 0, 0, "", 1, 0},
+		"md219": {"md219", false, 2, 2, 7932, 7932, 0, 3, "", 1, 0},
+		"md00":  {"md00", true, 1, 1, 4186624, 4186624, 0, 0, "", 1, 0},
+		"md5":   {"md5", true, 3, 4, 2929893888, 2929893888, 1, 1, "", 1, 0},
+		"md1":   {"md1", true, 4, 4, 1953260544, 902451200, 0, 0, "check", 0.462, 197821 * 1024},
 	}
 
 	for _, md := range mdStates {
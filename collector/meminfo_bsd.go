@@ -16,36 +16,6 @@
 
 package collector
 
-import (
-	"fmt"
-
-	"golang.org/x/sys/unix"
-)
-
 func (c *meminfoCollector) getMemInfo() (map[string]float64, error) {
-	info := make(map[string]float64)
-
-	size, err := unix.SysctlUint32("vm.stats.vm.v_page_size")
-	if err != nil {
-		return nil, fmt.Errorf("sysctl(vm.stats.vm.v_page_size) failed: %s", err)
-	}
-
-	for key, v := range map[string]string{
-		"active":     "vm.stats.vm.v_active_count",
-		"inactive":   "vm.stats.vm.v_inactive_count",
-		"wire":       "vm.stats.vm.v_wire_count",
-		"cache":      "vm.stats.vm.v_cache_count",
-		"free":       "vm.stats.vm.v_free_count",
-		"swappgsin":  "vm.stats.vm.v_swappgsin",
-		"swappgsout": "vm.stats.vm.v_swappgsout",
-		"total":      "vm.stats.vm.v_page_count",
-	} {
-		value, err := unix.SysctlUint32(v)
-		if err != nil {
-			return nil, err
-		}
-		// Convert metrics to kB (same as Linux meminfo).
-		info[key] = float64(value) * float64(size)
-	}
-	return info, nil
+	return sysctlMemInfo(newSysctlReader())
 }
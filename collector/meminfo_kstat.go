@@ -0,0 +1,38 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomeminfo
+
+package collector
+
+// kstatMemInfo returns the memory statistics in bytes from the
+// unix:0:system_pages kstat of illumos.
+func kstatMemInfo(r kstatReader, pageSize uint64) (map[string]float64, error) {
+	pages, err := lookupKstat(r, "unix", 0, "system_pages")
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]float64{}
+	for key, name := range map[string]string{
+		"total":     "physmem",
+		"free":      "freemem",
+		"available": "availrmem",
+		"kernel":    "pp_kernel",
+		"locked":    "pageslocked",
+	} {
+		if v, ok := pages[name]; ok {
+			info[key] = float64(v * pageSize)
+		}
+	}
+	return info, nil
+}
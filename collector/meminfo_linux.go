@@ -19,10 +19,13 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/prometheus/common/log"
 )
 
 func (c *meminfoCollector) getMemInfo() (map[string]float64, error) {
@@ -32,7 +35,20 @@ func (c *meminfoCollector) getMemInfo() (map[string]float64, error) {
 	}
 	defer file.Close()
 
-	return parseMemInfo(file)
+	memInfo, err := parseMemInfo(file)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := memInfo["MemAvailable"]; !ok {
+		// Kernels before 3.14 don't report MemAvailable, estimate it the
+		// same way newer kernels do.
+		wmarkLow, err := readZoneinfoLowWatermark()
+		if err != nil {
+			log.Debugf("Couldn't read low watermark, estimating MemAvailable without it: %s", err)
+		}
+		memInfo["MemAvailable"] = estimateMemAvailable(memInfo, wmarkLow)
+	}
+	return memInfo, nil
 }
 
 func parseMemInfo(r io.Reader) (map[string]float64, error) {
@@ -45,6 +61,9 @@ func parseMemInfo(r io.Reader) (map[string]float64, error) {
 	for scanner.Scan() {
 		line := scanner.Text()
 		parts := strings.Fields(string(line))
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid line in meminfo: %s", line)
+		}
 		fv, err := strconv.ParseFloat(parts[1], 64)
 		if err != nil {
 			return nil, fmt.Errorf("invalid value in meminfo: %s", err)
@@ -64,3 +83,59 @@ func parseMemInfo(r io.Reader) (map[string]float64, error) {
 
 	return memInfo, nil
 }
+
+// readZoneinfoLowWatermark returns the sum of the low watermarks of all
+// memory zones in bytes.
+func readZoneinfoLowWatermark() (float64, error) {
+	file, err := os.Open(procFilePath("zoneinfo"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	pages, err := parseZoneinfoLowWatermark(file)
+	if err != nil {
+		return 0, err
+	}
+	return pages * float64(os.Getpagesize()), nil
+}
+
+// parseZoneinfoLowWatermark returns the sum of the low watermarks of all
+// memory zones in pages.
+func parseZoneinfoLowWatermark(r io.Reader) (float64, error) {
+	var (
+		pages   float64
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 || parts[0] != "low" {
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid value in zoneinfo: %s", err)
+		}
+		pages += v
+	}
+	return pages, scanner.Err()
+}
+
+// estimateMemAvailable mirrors the calculation of MemAvailable introduced in
+// Linux 3.14: free memory above the low watermark, plus the page cache and
+// reclaimable slab that can be freed without going below it.
+func estimateMemAvailable(memInfo map[string]float64, wmarkLow float64) float64 {
+	pageCache := memInfo["Cached"]
+	if active, ok := memInfo["Active_file"]; ok {
+		pageCache = active + memInfo["Inactive_file"]
+	}
+	reclaimable := memInfo["SReclaimable"]
+
+	available := memInfo["MemFree"] - wmarkLow
+	available += pageCache - math.Min(pageCache/2, wmarkLow)
+	available += reclaimable - math.Min(reclaimable/2, wmarkLow)
+	if available < 0 {
+		return 0
+	}
+	return available
+}
@@ -15,6 +15,7 @@ package collector
 
 import (
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -38,3 +39,73 @@ func TestMemInfo(t *testing.T) {
 		t.Errorf("want memory directMap2M %f, got %f", want, got)
 	}
 }
+
+func TestMemInfoFieldSet(t *testing.T) {
+	memInfo, err := parseMemInfo(strings.NewReader(`MemTotal:        8053216 kB
+MemAvailable:    5730016 kB
+Shmem:            412844 kB
+KReclaimable:     310932 kB
+SReclaimable:     310932 kB
+SUnreclaim:       106480 kB
+Percpu:             5760 kB
+HardwareCorrupted:     0 kB
+ShmemPmdMapped:        0 kB
+FileHugePages:         0 kB
+HugePages_Total:       0
+Hugetlb:               0 kB
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]float64{
+		"MemAvailable":      5867536384,
+		"Percpu":            5898240,
+		"SUnreclaim":        109035520,
+		"HardwareCorrupted": 0,
+		"HugePages_Total":   0,
+		"Hugetlb":           0,
+	} {
+		if got, ok := memInfo[k]; !ok || want != got {
+			t.Errorf("want %s %f, got %f", k, want, got)
+		}
+	}
+
+	if _, err := parseMemInfo(strings.NewReader("MemTotal:\n")); err == nil {
+		t.Error("expected error for line without value")
+	}
+}
+
+func TestEstimateMemAvailable(t *testing.T) {
+	file, err := os.Open("fixtures/proc/zoneinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	pages, err := parseZoneinfoLowWatermark(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 10584.0, pages; want != got {
+		t.Errorf("want low watermark %f pages, got %f", want, got)
+	}
+
+	memInfo := map[string]float64{
+		"MemFree":       1000,
+		"Cached":        900,
+		"Active_file":   300,
+		"Inactive_file": 100,
+		"SReclaimable":  60,
+	}
+	// 1000-100 + 400-min(200,100) + 60-min(30,100)
+	if want, got := 1230.0, estimateMemAvailable(memInfo, 100); want != got {
+		t.Errorf("want MemAvailable %f, got %f", want, got)
+	}
+	if want, got := 0.0, estimateMemAvailable(memInfo, 5000); want != got {
+		t.Errorf("want MemAvailable %f, got %f", want, got)
+	}
+	delete(memInfo, "Active_file")
+	if want, got := 1960.0, estimateMemAvailable(memInfo, 0); want != got {
+		t.Errorf("want MemAvailable from Cached %f, got %f", want, got)
+	}
+}
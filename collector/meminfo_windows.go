@@ -0,0 +1,50 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build experimental_windows,!nomeminfo
+
+package collector
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// memoryStatusEx is MEMORYSTATUSEX.
+type memoryStatusEx struct {
+	length               uint32
+	memoryLoad           uint32
+	totalPhys            uint64
+	availPhys            uint64
+	totalPageFile        uint64
+	availPageFile        uint64
+	totalVirtual         uint64
+	availVirtual         uint64
+	availExtendedVirtual uint64
+}
+
+func (c *meminfoCollector) getMemInfo() (map[string]float64, error) {
+	status := memoryStatusEx{}
+	status.length = uint32(unsafe.Sizeof(status))
+	if r, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status))); r == 0 {
+		return nil, fmt.Errorf("GlobalMemoryStatusEx failed: %s", err)
+	}
+	// The page file values are the commit limit and the commit charge still
+	// available, which include the physical memory.
+	return map[string]float64{
+		"total":            float64(status.totalPhys),
+		"available":        float64(status.availPhys),
+		"commit_limit":     float64(status.totalPageFile),
+		"commit_available": float64(status.availPageFile),
+	}, nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nomultipath
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const multipathSubsystem = "multipath"
+
+var (
+	multipathCommand = flag.String("collector.multipath.command", "dmsetup", "Command to run dmsetup.")
+
+	// Path group states of the dm-multipath status line.
+	multipathGroupStates = map[string]string{"A": "active", "E": "enabled", "D": "disabled"}
+)
+
+type multipathPath struct {
+	device    string
+	active    bool
+	failCount float64
+}
+
+type multipathGroup struct {
+	state string
+	paths []multipathPath
+}
+
+type multipathMap struct {
+	name   string
+	groups []multipathGroup
+}
+
+type multipathCollector struct {
+	cli                      string
+	paths, groupState        typedDesc
+	pathActive, pathFailures typedDesc
+}
+
+func init() {
+	Factories["multipath"] = NewMultipathCollector
+}
+
+// NewMultipathCollector returns a new Collector exposing the path health of
+// dm-multipath devices.
+func NewMultipathCollector() (Collector, error) {
+	pathLabels := []string{"map", "group", "device"}
+	return &multipathCollector{
+		cli: *multipathCommand,
+		paths: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, multipathSubsystem, "paths"),
+			"Number of paths of the multipath device by state.",
+			[]string{"map", "state"}, nil,
+		), prometheus.GaugeValue},
+		groupState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, multipathSubsystem, "path_group_state"),
+			"State of the path group of the multipath device.",
+			[]string{"map", "group", "state"}, nil,
+		), prometheus.GaugeValue},
+		pathActive: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, multipathSubsystem, "path_active"),
+			"Whether the path of the multipath device is active.",
+			pathLabels, nil,
+		), prometheus.GaugeValue},
+		pathFailures: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, multipathSubsystem, "path_failures_total"),
+			"Number of times the path of the multipath device failed.",
+			pathLabels, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *multipathCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "status", "--target", "multipath")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	maps, err := parseMultipathStatus(pipe)
+	if err != nil {
+		// Stop dmsetup, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	for _, m := range maps {
+		active, failed := 0, 0
+		for i, g := range m.groups {
+			group := strconv.Itoa(i + 1)
+			for code, state := range multipathGroupStates {
+				v := 0.0
+				if code == g.state {
+					v = 1.0
+				}
+				ch <- c.groupState.mustNewConstMetric(v, m.name, group, state)
+			}
+			for _, p := range g.paths {
+				v := 0.0
+				if p.active {
+					v = 1.0
+					active++
+				} else {
+					failed++
+				}
+				device := blockDeviceName(p.device)
+				ch <- c.pathActive.mustNewConstMetric(v, m.name, group, device)
+				ch <- c.pathFailures.mustNewConstMetric(p.failCount, m.name, group, device)
+			}
+		}
+		ch <- c.paths.mustNewConstMetric(float64(active), m.name, "active")
+		ch <- c.paths.mustNewConstMetric(float64(failed), m.name, "failed")
+	}
+	return nil
+}
+
+// blockDeviceName returns the name of the block device with the given
+// major:minor number, or the number if it can't be resolved.
+func blockDeviceName(dev string) string {
+	target, err := os.Readlink(sysFilePath(path.Join("dev/block", dev)))
+	if err != nil {
+		return dev
+	}
+	return path.Base(target)
+}
+
+// parseMultipathStatus parses the output of `dmsetup status --target
+// multipath`.
+func parseMultipathStatus(r io.Reader) ([]multipathMap, error) {
+	var maps []multipathMap
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "No devices found" {
+			continue
+		}
+		parts := strings.SplitN(line, ": ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid line: %s", line)
+		}
+		m, err := parseMultipathTarget(strings.Fields(parts[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid status of %s: %s", parts[0], err)
+		}
+		m.name = parts[0]
+		maps = append(maps, m)
+	}
+	return maps, scanner.Err()
+}
+
+// parseMultipathTarget parses the status of a multipath target, see
+// multipath_status() in drivers/md/dm-mpath.c. Feature, hardware handler and
+// path selector arguments are prefixed by their count and skipped.
+func parseMultipathTarget(f []string) (m multipathMap, err error) {
+	pos := 0
+	next := func() string {
+		if pos >= len(f) {
+			err = fmt.Errorf("unexpected end of status")
+			return ""
+		}
+		pos++
+		return f[pos-1]
+	}
+	count := func() int {
+		n, e := strconv.Atoi(next())
+		if e != nil && err == nil {
+			err = e
+		}
+		return n
+	}
+	skip := func(n int) {
+		for i := 0; i < n && err == nil; i++ {
+			next()
+		}
+	}
+
+	// Start, length and target type.
+	skip(2)
+	if target := next(); err == nil && target != "multipath" {
+		return m, fmt.Errorf("unexpected target %s", target)
+	}
+	// Feature and hardware handler status arguments.
+	skip(count())
+	skip(count())
+	groups := count()
+	// Number of the next path group to use.
+	skip(1)
+	for i := 0; i < groups && err == nil; i++ {
+		g := multipathGroup{state: next()}
+		skip(count())
+		paths := count()
+		selectorArgs := count()
+		for j := 0; j < paths && err == nil; j++ {
+			p := multipathPath{device: next()}
+			p.active = next() == "A"
+			p.failCount, _ = strconv.ParseFloat(next(), 64)
+			skip(selectorArgs)
+			g.paths = append(g.paths, p)
+		}
+		m.groups = append(m.groups, g)
+	}
+	return m, err
+}
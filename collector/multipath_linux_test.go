@@ -0,0 +1,58 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMultipathStatus(t *testing.T) {
+	file, err := os.Open("fixtures/dmsetup_multipath.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	maps, err := parseMultipathStatus(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []multipathMap{
+		{name: "mpatha", groups: []multipathGroup{
+			{state: "A", paths: []multipathPath{
+				{device: "8:16", active: true},
+				{device: "8:48", failCount: 3},
+			}},
+			{state: "E", paths: []multipathPath{
+				{device: "8:32", active: true, failCount: 1},
+			}},
+		}},
+		{name: "mpathb", groups: []multipathGroup{
+			{state: "A", paths: []multipathPath{
+				{device: "65:0", active: true},
+			}},
+		}},
+	}
+	if !reflect.DeepEqual(want, maps) {
+		t.Errorf("want %+v, got %+v", want, maps)
+	}
+
+	if _, err := parseMultipathStatus(strings.NewReader("mpathc: 0 2048 multipath 0 0 1 1 A 0 2 0 8:16 A 0\n")); err == nil {
+		t.Error("want error for truncated status, got none")
+	}
+}
@@ -17,58 +17,73 @@
 package collector
 
 import (
-	"errors"
-	"regexp"
 	"strconv"
-
-	"github.com/prometheus/common/log"
+	"strings"
 )
 
-/*
-#cgo CFLAGS: -D_IFI_OQDROPS
-#include <stdio.h>
-#include <sys/types.h>
-#include <sys/socket.h>
-#include <ifaddrs.h>
-#include <net/if.h>
-*/
-import "C"
-
-func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
-	netDev := map[string]map[string]string{}
+// netDevFlag names a bit of the interface flags or capabilities.
+type netDevFlag struct {
+	bit  uint64
+	name string
+}
 
-	var ifap, ifa *C.struct_ifaddrs
-	if C.getifaddrs(&ifap) == -1 {
-		return nil, errors.New("getifaddrs() failed")
-	}
-	defer C.freeifaddrs(ifap)
+// netDevFlags are the IFF_ bits of net/if.h, which FreeBSD and DragonFly
+// share. FreeBSD reports IFF_DRV_RUNNING and IFF_DRV_OACTIVE with the same
+// bits as RUNNING and OACTIVE.
+var netDevFlags = []netDevFlag{
+	{0x1, "UP"},
+	{0x2, "BROADCAST"},
+	{0x4, "DEBUG"},
+	{0x8, "LOOPBACK"},
+	{0x10, "POINTOPOINT"},
+	{0x40, "RUNNING"},
+	{0x80, "NOARP"},
+	{0x100, "PROMISC"},
+	{0x200, "ALLMULTI"},
+	{0x400, "OACTIVE"},
+	{0x800, "SIMPLEX"},
+	{0x8000, "MULTICAST"},
+}
 
-	for ifa = ifap; ifa != nil; ifa = ifa.ifa_next {
-		if ifa.ifa_addr.sa_family == C.AF_LINK {
-			dev := C.GoString(ifa.ifa_name)
-			if ignore.MatchString(dev) {
-				log.Debugf("Ignoring device: %s", dev)
-				continue
-			}
+// netDevCapabilities are the IFCAP_ bits of net/if.h of FreeBSD, as printed
+// by ifconfig(8).
+var netDevCapabilities = []netDevFlag{
+	{0x1, "RXCSUM"},
+	{0x2, "TXCSUM"},
+	{0x4, "NETCONS"},
+	{0x8, "VLAN_MTU"},
+	{0x10, "VLAN_HWTAGGING"},
+	{0x20, "JUMBO_MTU"},
+	{0x40, "POLLING"},
+	{0x80, "VLAN_HWCSUM"},
+	{0x100, "TSO4"},
+	{0x200, "TSO6"},
+	{0x400, "LRO"},
+	{0x800, "WOL_UCAST"},
+	{0x1000, "WOL_MCAST"},
+	{0x2000, "WOL_MAGIC"},
+	{0x4000, "TOE4"},
+	{0x8000, "TOE6"},
+	{0x10000, "VLAN_HWFILTER"},
+	{0x40000, "VLAN_HWTSO"},
+	{0x80000, "LINKSTATE"},
+	{0x100000, "NETMAP"},
+	{0x200000, "RXCSUM_IPV6"},
+	{0x400000, "TXCSUM_IPV6"},
+}
 
-			devStats := map[string]string{}
-			data := (*C.struct_if_data)(ifa.ifa_data)
+// netDevLinkStates are the LINK_STATE_ values of ifi_link_state.
+var netDevLinkStates = map[uint64]string{0: "unknown", 1: "down", 2: "up"}
 
-			devStats["receive_packets"] = convertFreeBSDCPUTime(uint64(data.ifi_ipackets))
-			devStats["transmit_packets"] = convertFreeBSDCPUTime(uint64(data.ifi_opackets))
-			devStats["receive_errs"] = convertFreeBSDCPUTime(uint64(data.ifi_ierrors))
-			devStats["transmit_errs"] = convertFreeBSDCPUTime(uint64(data.ifi_oerrors))
-			devStats["receive_bytes"] = convertFreeBSDCPUTime(uint64(data.ifi_ibytes))
-			devStats["transmit_bytes"] = convertFreeBSDCPUTime(uint64(data.ifi_obytes))
-			devStats["receive_multicast"] = convertFreeBSDCPUTime(uint64(data.ifi_imcasts))
-			devStats["transmit_multicast"] = convertFreeBSDCPUTime(uint64(data.ifi_omcasts))
-			devStats["receive_drop"] = convertFreeBSDCPUTime(uint64(data.ifi_iqdrops))
-			devStats["transmit_drop"] = convertFreeBSDCPUTime(uint64(data.ifi_oqdrops))
-			netDev[dev] = devStats
+// joinNetDevFlags returns the names of the bits set in v, comma separated.
+func joinNetDevFlags(v uint64, flags []netDevFlag) string {
+	var names []string
+	for _, f := range flags {
+		if v&f.bit != 0 {
+			names = append(names, f.name)
 		}
 	}
-
-	return netDev, nil
+	return strings.Join(names, ",")
 }
 
 func convertFreeBSDCPUTime(counter uint64) string {
@@ -51,3 +51,19 @@ func TestUintToString(t *testing.T) {
 		}
 	}
 }
+
+func TestJoinNetDevFlags(t *testing.T) {
+	for _, c := range []struct {
+		v     uint64
+		flags []netDevFlag
+		want  string
+	}{
+		{0, netDevCapabilities, ""},
+		{0x1 | 0x2 | 0x400, netDevCapabilities, "RXCSUM,TXCSUM,LRO"},
+		{0x80000 | 0x100, netDevCapabilities, "TSO4,LINKSTATE"},
+	} {
+		if got := joinNetDevFlags(c.v, c.flags); c.want != got {
+			t.Errorf("%#x: want %q, got %q", c.v, c.want, got)
+		}
+	}
+}
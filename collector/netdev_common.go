@@ -12,7 +12,7 @@
 // limitations under the License.
 
 // +build !nonetdev
-// +build linux freebsd openbsd dragonfly darwin
+// +build linux freebsd openbsd dragonfly darwin solaris windows,experimental_windows
 
 package collector
 
@@ -31,10 +31,21 @@ var (
 		"Regexp of net devices to ignore for netdev collector.")
 )
 
+// Arch-dependent implementation must define:
+// * getNetDevStats
+// * getNetDevInfo
+
 type netDevCollector struct {
 	subsystem             string
 	ignoredDevicesPattern *regexp.Regexp
 	metricDescs           map[string]*prometheus.Desc
+	infoDesc              *prometheus.Desc
+}
+
+// netDevInfo holds the settings of a device on platforms reporting them
+// next to its statistics.
+type netDevInfo struct {
+	flags, capabilities, linkState string
 }
 
 func init() {
@@ -44,10 +55,17 @@ func init() {
 // NewNetDevCollector returns a new Collector exposing network device stats.
 func NewNetDevCollector() (Collector, error) {
 	pattern := regexp.MustCompile(*netdevIgnoredDevices)
+	subsystem := "network"
 	return &netDevCollector{
-		subsystem:             "network",
+		subsystem:             subsystem,
 		ignoredDevicesPattern: pattern,
 		metricDescs:           map[string]*prometheus.Desc{},
+		infoDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "info"),
+			"Flags, capabilities and link state of the network device, value is always 1.",
+			[]string{"device", "flags", "capabilities", "link_state"},
+			nil,
+		),
 	}, nil
 }
 
@@ -75,5 +93,14 @@ func (c *netDevCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, v, dev)
 		}
 	}
+
+	info, err := getNetDevInfo(c.ignoredDevicesPattern)
+	if err != nil {
+		return fmt.Errorf("couldn't get network device info: %s", err)
+	}
+	for dev, i := range info {
+		ch <- prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1,
+			dev, i.flags, i.capabilities, i.linkState)
+	}
 	return nil
 }
@@ -65,3 +65,9 @@ func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error)
 
 	return netDev, nil
 }
+
+// getNetDevInfo returns no device settings, they aren't read on this
+// platform yet.
+func getNetDevInfo(ignore *regexp.Regexp) (map[string]netDevInfo, error) {
+	return nil, nil
+}
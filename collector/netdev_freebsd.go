@@ -0,0 +1,92 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetdev
+
+package collector
+
+import (
+	"regexp"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/common/log"
+)
+
+// netDevCurCap returns the enabled capabilities of a device with the
+// SIOCGIFCAP ioctl, they aren't available as sysctl. The fixture tests
+// replace it.
+var netDevCurCap = func(device string) (uint64, error) {
+	fd, err := syscall.Socket(syscall.AF_LOCAL, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Close(fd)
+
+	// struct ifreq, ifr_curcap is the second int of the union following
+	// the name.
+	var ifr [32]byte
+	copy(ifr[:syscall.IFNAMSIZ-1], device)
+	_, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFCAP, uintptr(unsafe.Pointer(&ifr[0])))
+	if e != 0 {
+		return 0, e
+	}
+	return uint64(nativeEndian.Uint32(ifr[syscall.IFNAMSIZ+4:])), nil
+}
+
+func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
+	devs, err := sysctlNetDevs(newSysctlReader())
+	if err != nil {
+		return nil, err
+	}
+	netDev := map[string]map[string]string{}
+	for _, d := range devs {
+		if ignore.MatchString(d.name) {
+			log.Debugf("Ignoring device: %s", d.name)
+			continue
+		}
+		devStats := map[string]string{}
+		for name, v := range d.counters {
+			devStats[name] = convertFreeBSDCPUTime(v)
+		}
+		netDev[d.name] = devStats
+	}
+	return netDev, nil
+}
+
+// getNetDevInfo returns the flags, capabilities and link state of the
+// devices.
+func getNetDevInfo(ignore *regexp.Regexp) (map[string]netDevInfo, error) {
+	devs, err := sysctlNetDevs(newSysctlReader())
+	if err != nil {
+		return nil, err
+	}
+	info := map[string]netDevInfo{}
+	for _, d := range devs {
+		if ignore.MatchString(d.name) {
+			continue
+		}
+		i := netDevInfo{
+			flags:     joinNetDevFlags(d.flags, netDevFlags),
+			linkState: netDevLinkStates[d.linkState],
+		}
+		caps, err := netDevCurCap(d.name)
+		if err != nil {
+			log.Debugf("Couldn't get capabilities of %s: %s", d.name, err)
+		} else {
+			i.capabilities = joinNetDevFlags(caps, netDevCapabilities)
+		}
+		info[d.name] = i
+	}
+	return info, nil
+}
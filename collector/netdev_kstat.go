@@ -0,0 +1,62 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetdev
+
+package collector
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/prometheus/common/log"
+)
+
+// kstatNetDevFields maps the statistics of the link:0:<link> kstats to the
+// names of the netdev metrics.
+var kstatNetDevFields = map[string]string{
+	"rbytes64":   "receive_bytes",
+	"obytes64":   "transmit_bytes",
+	"ipackets64": "receive_packets",
+	"opackets64": "transmit_packets",
+	"ierrors":    "receive_errs",
+	"oerrors":    "transmit_errs",
+	"norcvbuf":   "receive_drop",
+	"noxmtbuf":   "transmit_drop",
+	"multircv":   "receive_multicast",
+	"multixmt":   "transmit_multicast",
+	"collisions": "transmit_colls",
+}
+
+// kstatNetDevStats returns the statistics of the datalinks of illumos.
+func kstatNetDevStats(r kstatReader, ignore *regexp.Regexp) (map[string]map[string]string, error) {
+	stats, err := r.Kstats("link")
+	if err != nil {
+		return nil, err
+	}
+	netDev := map[string]map[string]string{}
+	for _, s := range stats {
+		if ignore.MatchString(s.name) {
+			log.Debugf("Ignoring device: %s", s.name)
+			continue
+		}
+		devStats := map[string]string{}
+		for field, key := range kstatNetDevFields {
+			if v, ok := s.values[field]; ok {
+				devStats[key] = strconv.FormatUint(v, 10)
+			}
+		}
+		netDev[s.name] = devStats
+	}
+	return netDev, nil
+}
@@ -72,3 +72,9 @@ func parseNetDevStats(r io.Reader, ignore *regexp.Regexp) (map[string]map[string
 	}
 	return netDev, scanner.Err()
 }
+
+// getNetDevInfo returns no device settings, they aren't read on this
+// platform yet.
+func getNetDevInfo(ignore *regexp.Regexp) (map[string]netDevInfo, error) {
+	return nil, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetdev
+
+package collector
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Layout of struct ifmibdata from net/if_mib.h of FreeBSD and of its
+// struct if_data from net/if.h.
+const (
+	ifdataGeneral = 1 // IFDATA_GENERAL
+
+	ifmdNameLen     = 16
+	ifmdFlagsOffset = 20
+
+	ifiLinkStateOffset = 4
+	ifiCountersOffset  = 24
+	ifDataSize         = 152
+)
+
+// ifiCounters are the uint64 counters of struct if_data in order, named
+// like the Linux netdev statistics.
+var ifiCounters = []string{
+	"receive_packets",
+	"receive_errs",
+	"transmit_packets",
+	"transmit_errs",
+	"transmit_colls",
+	"receive_bytes",
+	"transmit_bytes",
+	"receive_multicast",
+	"transmit_multicast",
+	"receive_drop",
+	"transmit_drop",
+	"receive_noproto",
+}
+
+type sysctlNetDev struct {
+	name      string
+	flags     uint64
+	linkState uint64
+	counters  map[string]uint64
+}
+
+// ifmdDataOffset returns the offset of ifmd_data, which follows 52 bytes of
+// name and ints and is aligned for its uint64 counters. Only the i386 ABI
+// aligns them to 4.
+func ifmdDataOffset() int {
+	if runtime.GOARCH == "386" {
+		return 52
+	}
+	return 56
+}
+
+// sysctlNetDevs returns the network devices from the
+// net.link.generic.ifdata.<index>.general sysctls, see ifmib(4).
+func sysctlNetDevs(s sysctlReader) ([]sysctlNetDev, error) {
+	count, err := sysctlUint32(s, "net.link.generic.system.ifcount")
+	if err != nil {
+		return nil, err
+	}
+	var devs []sysctlNetDev
+	for i := 1; i <= int(count); i++ {
+		b, err := s.SysctlRaw("net.link.generic.ifdata", i, ifdataGeneral)
+		if os.IsNotExist(err) {
+			// The interface with the index was removed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		dev, err := parseIfmibdata(b)
+		if err != nil {
+			return nil, fmt.Errorf("interface %d: %s", i, err)
+		}
+		devs = append(devs, dev)
+	}
+	return devs, nil
+}
+
+func parseIfmibdata(b []byte) (sysctlNetDev, error) {
+	data := ifmdDataOffset()
+	if len(b) < data+ifDataSize {
+		return sysctlNetDev{}, fmt.Errorf("ifmibdata has %d bytes, expected %d", len(b), data+ifDataSize)
+	}
+	dev := sysctlNetDev{
+		name:      cString(b[:ifmdNameLen]),
+		flags:     uint64(nativeEndian.Uint32(b[ifmdFlagsOffset:])),
+		linkState: uint64(b[data+ifiLinkStateOffset]),
+		counters:  map[string]uint64{},
+	}
+	for i, name := range ifiCounters {
+		dev.counters[name] = nativeEndian.Uint64(b[data+ifiCountersOffset+8*i:])
+	}
+	return dev, nil
+}
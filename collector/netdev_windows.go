@@ -0,0 +1,67 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build experimental_windows,!nonetdev
+
+package collector
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/prometheus/common/log"
+)
+
+// netDevCounters maps the counters of the Network Interface performance
+// object to the names of the netdev metrics.
+var netDevCounters = map[string]string{
+	`\Network Interface(*)\Bytes Received/sec`:         "receive_bytes",
+	`\Network Interface(*)\Bytes Sent/sec`:             "transmit_bytes",
+	`\Network Interface(*)\Packets Received/sec`:       "receive_packets",
+	`\Network Interface(*)\Packets Sent/sec`:           "transmit_packets",
+	`\Network Interface(*)\Packets Received Errors`:    "receive_errs",
+	`\Network Interface(*)\Packets Outbound Errors`:    "transmit_errs",
+	`\Network Interface(*)\Packets Received Discarded`: "receive_drop",
+	`\Network Interface(*)\Packets Outbound Discarded`: "transmit_drop",
+	`\Network Interface(*)\Packets Received Unknown`:   "receive_noproto",
+}
+
+func getNetDevStats(ignore *regexp.Regexp) (map[string]map[string]string, error) {
+	paths := make([]string, 0, len(netDevCounters))
+	for path := range netDevCounters {
+		paths = append(paths, path)
+	}
+	values, err := pdhRawCounters(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	netDev := map[string]map[string]string{}
+	for path, instances := range values {
+		for dev, v := range instances {
+			if ignore.MatchString(dev) {
+				log.Debugf("Ignoring device: %s", dev)
+				continue
+			}
+			if netDev[dev] == nil {
+				netDev[dev] = map[string]string{}
+			}
+			netDev[dev][netDevCounters[path]] = strconv.FormatInt(v, 10)
+		}
+	}
+	return netDev, nil
+}
+
+func getNetDevInfo(ignore *regexp.Regexp) (map[string]netDevInfo, error) {
+	return nil, nil
+}
@@ -0,0 +1,161 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetinfo
+
+package collector
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type netInterface struct {
+	name    string
+	kind    string
+	master  string
+	parent  string
+	ifindex uint64
+	iflink  uint64
+}
+
+type netInfoCollector struct {
+	info typedDesc
+}
+
+func init() {
+	Factories["netinfo"] = NewNetInfoCollector
+}
+
+// NewNetInfoCollector returns a new Collector exposing the relationship
+// between network interfaces.
+func NewNetInfoCollector() (Collector, error) {
+	return &netInfoCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "network", "interface_info"),
+			"Kind of the network interface, its master (bridge or bond) and parent (VLAN trunk or veth peer), value is always 1.",
+			[]string{"device", "kind", "master", "parent"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *netInfoCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	ifaces, err := readNetInterfaces(sysFilePath("class/net"))
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		ch <- c.info.mustNewConstMetric(1, iface.name, iface.kind, iface.master, iface.parent)
+	}
+	return nil
+}
+
+func readNetInterfaces(root string) ([]netInterface, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ifaces  []netInterface
+		byIndex = map[uint64]string{}
+	)
+	for _, entry := range entries {
+		ifacePath := path.Join(root, entry.Name())
+		// Entries in /sys/class/net are symlinks, so follow them to find out
+		// whether they are interfaces or plain files like bonding_masters.
+		if fi, err := os.Stat(ifacePath); err != nil || !fi.IsDir() {
+			continue
+		}
+		ifindex, err := readUintFromFile(path.Join(ifacePath, "ifindex"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		iflink, err := readUintFromFile(path.Join(ifacePath, "iflink"))
+		if err != nil {
+			return nil, err
+		}
+		kind, err := netInterfaceKind(ifacePath)
+		if err != nil {
+			return nil, err
+		}
+		iface := netInterface{
+			name:    entry.Name(),
+			kind:    kind,
+			ifindex: ifindex,
+			iflink:  iflink,
+		}
+		if master, err := os.Readlink(path.Join(ifacePath, "master")); err == nil {
+			iface.master = path.Base(master)
+		}
+		links, err := ioutil.ReadDir(ifacePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			if strings.HasPrefix(link.Name(), "lower_") {
+				iface.parent = strings.TrimPrefix(link.Name(), "lower_")
+				break
+			}
+		}
+		byIndex[ifindex] = iface.name
+		ifaces = append(ifaces, iface)
+	}
+
+	// Interfaces without lower devices may still be linked to another
+	// interface through iflink, e.g. veth pairs. The peer is only resolvable
+	// if it lives in the same network namespace.
+	for i, iface := range ifaces {
+		if iface.parent == "" && iface.iflink != iface.ifindex {
+			ifaces[i].parent = byIndex[iface.iflink]
+		}
+	}
+	return ifaces, nil
+}
+
+func netInterfaceKind(ifacePath string) (string, error) {
+	for dir, kind := range map[string]string{"bridge": "bridge", "bonding": "bond"} {
+		if _, err := os.Stat(path.Join(ifacePath, dir)); err == nil {
+			return kind, nil
+		}
+	}
+
+	file, err := os.Open(path.Join(ifacePath, "uevent"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "DEVTYPE=") {
+			return strings.TrimPrefix(scanner.Text(), "DEVTYPE="), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if _, err := os.Lstat(path.Join(ifacePath, "device")); err == nil {
+		return "physical", nil
+	}
+	return "virtual", nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestNetInterfaces(t *testing.T) {
+	ifaces, err := readNetInterfaces("fixtures/sys/class/net")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]netInterface{
+		"br0":        {name: "br0", kind: "bridge", ifindex: 4, iflink: 4},
+		"eth0":       {name: "eth0", kind: "physical", ifindex: 2, iflink: 2},
+		"eth0.100":   {name: "eth0.100", kind: "vlan", parent: "eth0", ifindex: 5, iflink: 2},
+		"eth2":       {name: "eth2", kind: "physical", master: "br0", ifindex: 3, iflink: 3},
+		"ppp0":       {name: "ppp0", kind: "ppp", ifindex: 9, iflink: 9},
+		"veth1a2b3c": {name: "veth1a2b3c", kind: "virtual", master: "br0", parent: "veth4d5e6f", ifindex: 8, iflink: 7},
+		"veth4d5e6f": {name: "veth4d5e6f", kind: "virtual", parent: "veth1a2b3c", ifindex: 7, iflink: 8},
+	}
+	if len(ifaces) != len(want) {
+		t.Fatalf("want %d interfaces, got %d", len(want), len(ifaces))
+	}
+	for _, iface := range ifaces {
+		if iface != want[iface.name] {
+			t.Errorf("want %+v, got %+v", want[iface.name], iface)
+		}
+	}
+}
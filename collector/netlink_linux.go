@@ -0,0 +1,57 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "syscall"
+
+// nlaTypeMask strips the NLA_F_NESTED and NLA_F_NET_BYTEORDER flags from
+// the type of a netlink attribute.
+const nlaTypeMask = 0x3fff
+
+// netlinkAttrValue is a netlink attribute with its type and value.
+type netlinkAttrValue struct {
+	typ   uint16
+	value []byte
+}
+
+// parseNetlinkAttrs splits b into netlink attributes, ignoring a truncated
+// one at the end.
+func parseNetlinkAttrs(b []byte) []netlinkAttrValue {
+	var attrs []netlinkAttrValue
+	for len(b) >= syscall.SizeofRtAttr {
+		l := int(nativeEndian.Uint16(b))
+		if l < syscall.SizeofRtAttr || l > len(b) {
+			break
+		}
+		attrs = append(attrs, netlinkAttrValue{
+			typ:   nativeEndian.Uint16(b[2:]) & nlaTypeMask,
+			value: b[syscall.SizeofRtAttr:l],
+		})
+		next := alignTo(l, syscall.NLMSG_ALIGNTO)
+		if next > len(b) {
+			break
+		}
+		b = b[next:]
+	}
+	return attrs
+}
+
+// netlinkAttr encodes a netlink attribute including its padding.
+func netlinkAttr(typ uint16, value []byte) []byte {
+	b := make([]byte, alignTo(syscall.SizeofRtAttr+len(value), syscall.NLMSG_ALIGNTO))
+	nativeEndian.PutUint16(b, uint16(syscall.SizeofRtAttr+len(value)))
+	nativeEndian.PutUint16(b[2:], typ)
+	copy(b[syscall.SizeofRtAttr:], value)
+	return b
+}
@@ -0,0 +1,58 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetstat
+
+package collector
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	netStatsSubsystem = "netstat"
+)
+
+type netStatCollector struct{}
+
+func init() {
+	Factories["netstat"] = NewNetStatCollector
+}
+
+// NewNetStatCollector returns a new Collector exposing the TCP, UDP, IP and
+// ICMP statistics under the names of the Linux netstat collector.
+func NewNetStatCollector() (Collector, error) {
+	return &netStatCollector{}, nil
+}
+
+func (c *netStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	netStats, err := sysctlNetStats(newSysctlReader())
+	if err != nil {
+		return fmt.Errorf("couldn't get netstats: %s", err)
+	}
+	for protocol, protocolStats := range netStats {
+		for name, value := range protocolStats {
+			ch <- prometheus.MustNewConstMetric(
+				prometheus.NewDesc(
+					prometheus.BuildFQName(Namespace, netStatsSubsystem, protocol+"_"+name),
+					fmt.Sprintf("Protocol %s statistic %s.", protocol, name),
+					nil, nil,
+				),
+				prometheus.UntypedValue, value,
+			)
+		}
+	}
+	return nil
+}
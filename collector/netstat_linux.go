@@ -17,9 +17,11 @@ package collector
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -30,7 +32,26 @@ const (
 	netStatsSubsystem = "netstat"
 )
 
-type netStatCollector struct{}
+var (
+	netStatFields = flag.String("collector.netstat.fields", ".*", "Regexp of fields of /proc/net/netstat and /proc/net/snmp to expose, as Protocol_Field.")
+	netStatTyped  = flag.Bool("collector.netstat.typed", false, "Expose netstat fields as counters and gauges instead of untyped.")
+)
+
+// netStatGauges are the fields that are not counters.
+var netStatGauges = map[string]bool{
+	"Ip_Forwarding":    true,
+	"Ip_DefaultTTL":    true,
+	"Tcp_RtoAlgorithm": true,
+	"Tcp_RtoMin":       true,
+	"Tcp_RtoMax":       true,
+	"Tcp_MaxConn":      true,
+	"Tcp_CurrEstab":    true,
+}
+
+type netStatCollector struct {
+	fieldPattern *regexp.Regexp
+	typed        bool
+}
 
 func init() {
 	Factories["netstat"] = NewNetStatCollector
@@ -39,7 +60,11 @@ func init() {
 // NewNetStatCollector takes and returns
 // a new Collector exposing network stats.
 func NewNetStatCollector() (Collector, error) {
-	return &netStatCollector{}, nil
+	pattern, err := regexp.Compile(*netStatFields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collector.netstat.fields: %s", err)
+	}
+	return &netStatCollector{fieldPattern: pattern, typed: *netStatTyped}, nil
 }
 
 func (c *netStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -59,6 +84,16 @@ func (c *netStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	for protocol, protocolStats := range netStats {
 		for name, value := range protocolStats {
 			key := protocol + "_" + name
+			if !c.fieldPattern.MatchString(key) {
+				continue
+			}
+			valueType := prometheus.UntypedValue
+			if c.typed {
+				valueType = prometheus.CounterValue
+				if netStatGauges[key] {
+					valueType = prometheus.GaugeValue
+				}
+			}
 			v, err := strconv.ParseFloat(value, 64)
 			if err != nil {
 				return fmt.Errorf("invalid value %s in netstats: %s", value, err)
@@ -69,7 +104,7 @@ func (c *netStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
 					fmt.Sprintf("Protocol %s statistic %s.", protocol, name),
 					nil, nil,
 				),
-				prometheus.UntypedValue, v,
+				valueType, v,
 			)
 		}
 	}
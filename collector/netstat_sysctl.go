@@ -0,0 +1,124 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetstat
+
+package collector
+
+import "fmt"
+
+// netstatSysctl maps counters of the FreeBSD protocol statistics to the names
+// of /proc/net/snmp on Linux. Counters listing several fields are their sum.
+type netstatSysctl struct {
+	protocol, sysctl string
+	// longs is set for structs of u_long instead of uint64_t fields.
+	longs    bool
+	counters map[string][]int
+}
+
+// Indexes of the fields of struct tcpstat, udpstat, ipstat and icmpstat in
+// netinet/*_var.h. The icmp histograms span ICMP_MAXTYPE + 1 fields.
+var netstatSysctls = []netstatSysctl{
+	{"Tcp", "net.inet.tcp.stats", false, map[string][]int{
+		"ActiveOpens":  {0},  // tcps_connattempt
+		"PassiveOpens": {1},  // tcps_accepts
+		"AttemptFails": {4},  // tcps_conndrops
+		"EstabResets":  {3},  // tcps_drops
+		"InSegs":       {27}, // tcps_rcvtotal
+		"OutSegs":      {16}, // tcps_sndtotal
+		"RetransSegs":  {19}, // tcps_sndrexmitpack
+		// tcps_rcvbadsum, tcps_rcvbadoff and tcps_rcvshort.
+		"InErrs":       {30, 31, 33},
+		"InCsumErrors": {30},
+		// Not counted on Linux, tcps_closed.
+		"Closed": {6},
+	}},
+	{"Udp", "net.inet.udp.stats", false, map[string][]int{
+		"InDatagrams": {0}, // udps_ipackets
+		// udps_hdrops, udps_badsum and udps_badlen.
+		"InErrors":     {1, 2, 4},
+		"InCsumErrors": {2},
+		"NoPorts":      {5},  // udps_noport
+		"RcvbufErrors": {7},  // udps_fullsock
+		"OutDatagrams": {10}, // udps_opackets
+	}},
+	{"Ip", "net.inet.ip.stats", false, map[string][]int{
+		"InReceives": {0}, // ips_total
+		// ips_badsum, ips_tooshort, ips_toosmall, ips_badhlen, ips_badlen,
+		// ips_badoptions and ips_badvers.
+		"InHdrErrors":     {1, 2, 3, 4, 5, 21, 23},
+		"ForwDatagrams":   {9},  // ips_forward
+		"InUnknownProtos": {13}, // ips_noproto
+		"InDelivers":      {14}, // ips_delivered
+		"OutRequests":     {15}, // ips_localout
+		"OutDiscards":     {16}, // ips_odropped
+		"OutNoRoutes":     {22}, // ips_noroute
+		"ReasmReqds":      {6},  // ips_fragments
+		"ReasmOKs":        {17}, // ips_reassembled
+		// ips_fragdropped and ips_fragtimeout.
+		"ReasmFails":  {7, 8},
+		"FragOKs":     {18}, // ips_fragmented
+		"FragCreates": {19}, // ips_ofragments
+		"FragFails":   {20}, // ips_cantfrag
+	}},
+	{"Icmp", "net.inet.icmp.stats", true, map[string][]int{
+		"InMsgs":  netstatRange(49, 90), // icps_inhist
+		"OutMsgs": netstatRange(3, 44),  // icps_outhist
+		// icps_badcode, icps_tooshort, icps_checksum and icps_badlen.
+		"InErrors":     {44, 45, 46, 47},
+		"InCsumErrors": {46},
+		"OutErrors":    {0}, // icps_error
+	}},
+}
+
+func netstatRange(from, to int) []int {
+	r := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		r = append(r, i)
+	}
+	return r
+}
+
+// sysctlNetStats returns the protocol statistics by protocol and counter
+// name, as parsed from /proc/net/snmp on Linux.
+func sysctlNetStats(s sysctlReader) (map[string]map[string]float64, error) {
+	stats := map[string]map[string]float64{}
+	for _, n := range netstatSysctls {
+		b, err := s.SysctlRaw(n.sysctl)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't get %s: %s", n.sysctl, err)
+		}
+		var counters []uint64
+		if n.longs {
+			for _, l := range sysctlLongs(b) {
+				counters = append(counters, uint64(l))
+			}
+		} else {
+			for ; len(b) >= 8; b = b[8:] {
+				counters = append(counters, nativeEndian.Uint64(b))
+			}
+		}
+		stats[n.protocol] = map[string]float64{}
+		for name, fields := range n.counters {
+			var v uint64
+			for _, i := range fields {
+				if i >= len(counters) {
+					return nil, fmt.Errorf("sysctl %s has %d counters, expected at least %d", n.sysctl, len(counters), i+1)
+				}
+				v += counters[i]
+			}
+			stats[n.protocol][name] = float64(v)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonftables
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"os/exec"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	nftCommand = flag.String("collector.nftables.command", "nft", "Command to run nft.")
+)
+
+type nftCounter struct {
+	Family  string  `json:"family"`
+	Table   string  `json:"table"`
+	Name    string  `json:"name"`
+	Packets float64 `json:"packets"`
+	Bytes   float64 `json:"bytes"`
+}
+
+type nftSet struct {
+	Family string            `json:"family"`
+	Table  string            `json:"table"`
+	Name   string            `json:"name"`
+	Elem   []json.RawMessage `json:"elem"`
+}
+
+type nftRuleset struct {
+	counters []nftCounter
+	sets     []nftSet
+}
+
+type nftablesCollector struct {
+	cli                      string
+	packets, bytes, setElems typedDesc
+}
+
+func init() {
+	Factories["nftables"] = NewNftablesCollector
+}
+
+// NewNftablesCollector returns a new Collector exposing named nftables
+// counters and set sizes.
+func NewNftablesCollector() (Collector, error) {
+	const subsystem = "nftables"
+
+	counterLabels := []string{"family", "table", "counter"}
+	return &nftablesCollector{
+		cli: *nftCommand,
+		packets: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "counter_packets_total"),
+			"Packets matched by the named nftables counter.",
+			counterLabels, nil,
+		), prometheus.CounterValue},
+		bytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "counter_bytes_total"),
+			"Bytes matched by the named nftables counter.",
+			counterLabels, nil,
+		), prometheus.CounterValue},
+		setElems: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "set_elements"),
+			"Number of elements in the nftables set.",
+			[]string{"family", "table", "set"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *nftablesCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "--json", "list", "ruleset")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	ruleset, err := parseNftRuleset(pipe)
+	if err != nil {
+		// Stop nft, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	for _, counter := range ruleset.counters {
+		ch <- c.packets.mustNewConstMetric(counter.Packets, counter.Family, counter.Table, counter.Name)
+		ch <- c.bytes.mustNewConstMetric(counter.Bytes, counter.Family, counter.Table, counter.Name)
+	}
+	for _, set := range ruleset.sets {
+		ch <- c.setElems.mustNewConstMetric(float64(len(set.Elem)), set.Family, set.Table, set.Name)
+	}
+	return nil
+}
+
+// parseNftRuleset parses the output of `nft --json list ruleset`, which is a
+// list of objects each keyed by their kind.
+func parseNftRuleset(r io.Reader) (*nftRuleset, error) {
+	var doc struct {
+		Nftables []struct {
+			Counter *nftCounter `json:"counter"`
+			Set     *nftSet     `json:"set"`
+		} `json:"nftables"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	ruleset := &nftRuleset{}
+	for _, obj := range doc.Nftables {
+		switch {
+		case obj.Counter != nil:
+			ruleset.counters = append(ruleset.counters, *obj.Counter)
+		case obj.Set != nil:
+			ruleset.sets = append(ruleset.sets, *obj.Set)
+		}
+	}
+	return ruleset, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNftRuleset(t *testing.T) {
+	file, err := os.Open("fixtures/nft_ruleset.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	ruleset, err := parseNftRuleset(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 2, len(ruleset.counters); want != got {
+		t.Fatalf("want %d counters, got %d", want, got)
+	}
+	if want, got := (nftCounter{"inet", "filter", "ssh_in", 1204, 81920}), ruleset.counters[0]; want != got {
+		t.Errorf("want counter %+v, got %+v", want, got)
+	}
+
+	if want, got := 2, len(ruleset.sets); want != got {
+		t.Fatalf("want %d sets, got %d", want, got)
+	}
+	if want, got := 3, len(ruleset.sets[0].Elem); want != got {
+		t.Errorf("want %d elements in set %s, got %d", want, ruleset.sets[0].Name, got)
+	}
+	if want, got := 0, len(ruleset.sets[1].Elem); want != got {
+		t.Errorf("want %d elements in set %s, got %d", want, ruleset.sets[1].Name, got)
+	}
+}
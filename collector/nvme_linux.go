@@ -0,0 +1,277 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonvme
+
+package collector
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path"
+	"regexp"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	nvmeSubsystem = "nvme"
+
+	// NVME_IOCTL_ADMIN_CMD from include/uapi/linux/nvme_ioctl.h.
+	nvmeIoctlAdminCmd   = 0xc0484e41
+	nvmeAdminGetLogPage = 0x02
+	nvmeLogSmart        = 0x02
+	nvmeSmartLogSize    = 512
+)
+
+var (
+	// Controller states as named in drivers/nvme/host/core.c.
+	nvmeControllerStates = []string{"new", "live", "resetting", "connecting", "deleting", "dead"}
+
+	nvmeSmart = flag.Bool(
+		"collector.nvme.smart", false,
+		"Read the SMART / health log of the NVMe controllers through the admin ioctl, which needs CAP_SYS_ADMIN.")
+
+	// Namespaces are nvme<ctrl>n<ns>, or nvme<subsys>c<ctrl>n<ns> with
+	// native multipathing.
+	nvmeNamespacePattern = regexp.MustCompile(`^nvme\d+(c\d+)?n\d+$`)
+)
+
+type nvmeController struct {
+	name, model, serial, firmware, transport, state string
+	// Capacities by namespace.
+	namespaces map[string]float64
+}
+
+// nvmeSmartLog holds the SMART / health log, log page 2 of the NVMe base
+// specification, with the values converted to base units.
+type nvmeSmartLog struct {
+	criticalWarning                             float64
+	temperature                                 float64
+	availableSpare, spareThreshold, used        float64
+	dataRead, dataWritten                       float64
+	hostReads, hostWrites                       float64
+	busyTime, powerCycles, powerOnTime          float64
+	unsafeShutdowns, mediaErrors, errLogEntries float64
+}
+
+// nvmePassthruCmd is struct nvme_passthru_cmd.
+type nvmePassthruCmd struct {
+	opcode, flags              uint8
+	rsvd1                      uint16
+	nsid, cdw2, cdw3           uint32
+	metadata, addr             uint64
+	metadataLen, dataLen       uint32
+	cdw10, cdw11, cdw12, cdw13 uint32
+	cdw14, cdw15               uint32
+	timeoutMs, result          uint32
+}
+
+type nvmeCollector struct {
+	info, state, capacity typedDesc
+	smart                 []nvmeSmartMetric
+}
+
+type nvmeSmartMetric struct {
+	desc  typedDesc
+	value func(*nvmeSmartLog) float64
+}
+
+func init() {
+	Factories["nvme"] = NewNVMeCollector
+}
+
+// NewNVMeCollector returns a new Collector exposing NVMe controllers, their
+// namespaces and optionally their SMART / health log.
+func NewNVMeCollector() (Collector, error) {
+	c := &nvmeCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeSubsystem, "info"),
+			"Model, serial number, firmware revision and transport of the NVMe controller, value is always 1.",
+			[]string{"device", "model", "serial", "firmware_revision", "transport"}, nil,
+		), prometheus.GaugeValue},
+		state: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeSubsystem, "controller_state"),
+			"State of the NVMe controller.",
+			[]string{"device", "state"}, nil,
+		), prometheus.GaugeValue},
+		capacity: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeSubsystem, "namespace_capacity_bytes"),
+			"Capacity of the NVMe namespace.",
+			[]string{"device", "namespace"}, nil,
+		), prometheus.GaugeValue},
+	}
+	for _, s := range []struct {
+		name, help string
+		valueType  prometheus.ValueType
+		value      func(*nvmeSmartLog) float64
+	}{
+		{"critical_warning", "Critical warning bits of the SMART log, e.g. 1 for spare below threshold, 4 for degraded reliability.", prometheus.GaugeValue, func(l *nvmeSmartLog) float64 { return l.criticalWarning }},
+		{"temperature_celsius", "Composite temperature of the controller.", prometheus.GaugeValue, func(l *nvmeSmartLog) float64 { return l.temperature }},
+		{"available_spare_ratio", "Remaining spare capacity.", prometheus.GaugeValue, func(l *nvmeSmartLog) float64 { return l.availableSpare }},
+		{"available_spare_threshold_ratio", "Spare capacity below which a critical warning is raised.", prometheus.GaugeValue, func(l *nvmeSmartLog) float64 { return l.spareThreshold }},
+		{"percentage_used_ratio", "Estimate of the used life of the device, can exceed 1.", prometheus.GaugeValue, func(l *nvmeSmartLog) float64 { return l.used }},
+		{"data_read_bytes_total", "Data read from the device by hosts.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.dataRead }},
+		{"data_written_bytes_total", "Data written to the device by hosts.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.dataWritten }},
+		{"host_read_commands_total", "Read commands completed by the controller.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.hostReads }},
+		{"host_write_commands_total", "Write commands completed by the controller.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.hostWrites }},
+		{"controller_busy_seconds_total", "Time the controller was busy with I/O commands.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.busyTime }},
+		{"power_cycles_total", "Power cycles of the device.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.powerCycles }},
+		{"power_on_seconds_total", "Time the device was powered on, in full hours.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.powerOnTime }},
+		{"unsafe_shutdowns_total", "Shutdowns without notification of the device.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.unsafeShutdowns }},
+		{"media_errors_total", "Unrecovered data integrity errors.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.mediaErrors }},
+		{"error_log_entries_total", "Entries added to the error information log of the controller.", prometheus.CounterValue, func(l *nvmeSmartLog) float64 { return l.errLogEntries }},
+	} {
+		c.smart = append(c.smart, nvmeSmartMetric{typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeSubsystem, s.name),
+			s.help, []string{"device"}, nil,
+		), s.valueType}, s.value})
+	}
+	return c, nil
+}
+
+func (c *nvmeCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	ctrls, err := readNVMeControllers(sysFilePath("class/nvme"))
+	if err != nil {
+		return fmt.Errorf("couldn't get NVMe controllers: %s", err)
+	}
+	for _, ctrl := range ctrls {
+		ch <- c.info.mustNewConstMetric(1, ctrl.name, ctrl.model, ctrl.serial, ctrl.firmware, ctrl.transport)
+		for _, state := range nvmeControllerStates {
+			v := 0.0
+			if state == ctrl.state {
+				v = 1.0
+			}
+			ch <- c.state.mustNewConstMetric(v, ctrl.name, state)
+		}
+		for ns, capacity := range ctrl.namespaces {
+			ch <- c.capacity.mustNewConstMetric(capacity, ctrl.name, ns)
+		}
+
+		if !*nvmeSmart {
+			continue
+		}
+		smartLog, err := readNVMeSmartLog(path.Join("/dev", ctrl.name))
+		if err != nil {
+			return fmt.Errorf("couldn't read SMART log of %s: %s", ctrl.name, err)
+		}
+		for _, s := range c.smart {
+			ch <- s.desc.mustNewConstMetric(s.value(smartLog), ctrl.name)
+		}
+	}
+	return nil
+}
+
+func readNVMeControllers(root string) ([]nvmeController, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		// nvme-core not loaded.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ctrls []nvmeController
+	for _, d := range dirs {
+		ctrlPath := path.Join(root, d.Name())
+		ctrl := nvmeController{name: d.Name(), namespaces: map[string]float64{}}
+		for file, v := range map[string]*string{
+			"model":        &ctrl.model,
+			"serial":       &ctrl.serial,
+			"firmware_rev": &ctrl.firmware,
+			"transport":    &ctrl.transport,
+			"state":        &ctrl.state,
+		} {
+			if *v, err = readStringFromFile(path.Join(ctrlPath, file)); err != nil {
+				return nil, err
+			}
+		}
+
+		entries, err := ioutil.ReadDir(ctrlPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if !nvmeNamespacePattern.MatchString(e.Name()) {
+				continue
+			}
+			// The size is in 512 byte sectors, whatever the block size.
+			sectors, err := readUintFromFile(path.Join(ctrlPath, e.Name(), "size"))
+			if err != nil {
+				return nil, err
+			}
+			ctrl.namespaces[e.Name()] = float64(sectors) * 512
+		}
+		ctrls = append(ctrls, ctrl)
+	}
+	return ctrls, nil
+}
+
+// readNVMeSmartLog gets the SMART / health log from the character device of
+// a controller.
+func readNVMeSmartLog(device string) (*nvmeSmartLog, error) {
+	f, err := os.Open(device)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, nvmeSmartLogSize)
+	cmd := nvmePassthruCmd{
+		opcode:  nvmeAdminGetLogPage,
+		nsid:    0xffffffff,
+		addr:    uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		dataLen: nvmeSmartLogSize,
+		// The number of dwords to read, zero based, and the log page.
+		cdw10: (nvmeSmartLogSize/4-1)<<16 | nvmeLogSmart,
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), nvmeIoctlAdminCmd, uintptr(unsafe.Pointer(&cmd))); errno != 0 {
+		return nil, errno
+	}
+	return parseNVMeSmartLog(buf)
+}
+
+func parseNVMeSmartLog(b []byte) (*nvmeSmartLog, error) {
+	if len(b) < nvmeSmartLogSize {
+		return nil, fmt.Errorf("SMART log has %d bytes, expected %d", len(b), nvmeSmartLogSize)
+	}
+	// The counters are 128 bit little-endian integers.
+	u128 := func(off int) float64 {
+		return float64(binary.LittleEndian.Uint64(b[off:])) + float64(binary.LittleEndian.Uint64(b[off+8:]))*math.Pow(2, 64)
+	}
+	return &nvmeSmartLog{
+		criticalWarning: float64(b[0]),
+		// Kelvin.
+		temperature:    float64(binary.LittleEndian.Uint16(b[1:])) - 273.15,
+		availableSpare: float64(b[3]) / 100,
+		spareThreshold: float64(b[4]) / 100,
+		used:           float64(b[5]) / 100,
+		// Data units are thousands of 512 byte units.
+		dataRead:        u128(32) * 512000,
+		dataWritten:     u128(48) * 512000,
+		hostReads:       u128(64),
+		hostWrites:      u128(80),
+		busyTime:        u128(96) * 60,
+		powerCycles:     u128(112),
+		powerOnTime:     u128(128) * 3600,
+		unsafeShutdowns: u128(144),
+		mediaErrors:     u128(160),
+		errLogEntries:   u128(176),
+	}, nil
+}
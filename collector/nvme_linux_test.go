@@ -0,0 +1,84 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestNVMeControllers(t *testing.T) {
+	ctrls, err := readNVMeControllers("fixtures/sys/class/nvme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(ctrls); want != got {
+		t.Fatalf("want %d controllers, got %d", want, got)
+	}
+	ctrl := ctrls[0]
+	if want, got := "Samsung SSD 970 EVO Plus 1TB", ctrl.model; want != got {
+		t.Errorf("want model %q, got %q", want, got)
+	}
+	if want, got := "2B2QEXM7", ctrl.firmware; want != got {
+		t.Errorf("want firmware %q, got %q", want, got)
+	}
+	if want, got := 1000204886016.0, ctrl.namespaces["nvme0n1"]; want != got {
+		t.Errorf("want capacity %f, got %f", want, got)
+	}
+	// Namespaces of multipath controllers are named after the subsystem.
+	if want, got := 107374182400.0, ctrls[1].namespaces["nvme1c1n1"]; want != got {
+		t.Errorf("want capacity %f, got %f", want, got)
+	}
+}
+
+func TestParseNVMeSmartLog(t *testing.T) {
+	b := make([]byte, nvmeSmartLogSize)
+	b[0] = 0x04
+	binary.LittleEndian.PutUint16(b[1:], 310)
+	b[3], b[4], b[5] = 100, 10, 3
+	binary.LittleEndian.PutUint64(b[32:], 2000)
+	binary.LittleEndian.PutUint64(b[56:], 1)
+	binary.LittleEndian.PutUint64(b[96:], 45)
+	binary.LittleEndian.PutUint64(b[128:], 1200)
+	binary.LittleEndian.PutUint64(b[160:], 7)
+
+	l, err := parseNVMeSmartLog(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		name      string
+		want, got float64
+	}{
+		{"critical warning", 4, l.criticalWarning},
+		{"available spare", 1, l.availableSpare},
+		{"spare threshold", 0.1, l.spareThreshold},
+		{"used", 0.03, l.used},
+		{"data read", 1024000000, l.dataRead},
+		{"data written", 512000 * (1 << 64), l.dataWritten},
+		{"busy time", 2700, l.busyTime},
+		{"power on time", 4320000, l.powerOnTime},
+		{"media errors", 7, l.mediaErrors},
+	} {
+		if c.want != c.got {
+			t.Errorf("want %s %f, got %f", c.name, c.want, c.got)
+		}
+	}
+	if l.temperature < 36.84 || l.temperature > 36.86 {
+		t.Errorf("want temperature 36.85, got %f", l.temperature)
+	}
+	if _, err := parseNVMeSmartLog(b[:64]); err == nil {
+		t.Error("expected error for short log")
+	}
+}
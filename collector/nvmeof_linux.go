@@ -0,0 +1,159 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonvmeof
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const nvmeofSubsystem = "nvmeof"
+
+// Controller states as named in drivers/nvme/host/core.c.
+var nvmeofControllerStates = []string{"new", "live", "resetting", "connecting", "deleting", "dead"}
+
+type nvmeofController struct {
+	name      string
+	transport string
+	subsysNQN string
+	address   string
+	state     string
+	queues    uint64
+	queueSize uint64
+}
+
+type nvmeofCollector struct {
+	info, state, queues, queueSize, reconnects typedDesc
+
+	mtx        sync.Mutex
+	lastState  map[string]string
+	reconnectN map[string]float64
+}
+
+func init() {
+	Factories["nvmeof"] = NewNVMeoFCollector
+}
+
+// NewNVMeoFCollector returns a new Collector exposing the state of NVMe
+// over Fabrics host controllers.
+func NewNVMeoFCollector() (Collector, error) {
+	return &nvmeofCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeofSubsystem, "controller_info"),
+			"Transport and subsystem of the NVMe-oF controller, value is always 1.",
+			[]string{"controller", "transport", "subsysnqn", "address"}, nil,
+		), prometheus.GaugeValue},
+		state: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeofSubsystem, "controller_state"),
+			"State of the NVMe-oF controller.",
+			[]string{"controller", "state"}, nil,
+		), prometheus.GaugeValue},
+		queues: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeofSubsystem, "controller_queues"),
+			"Number of queues of the NVMe-oF controller, including the admin queue.",
+			[]string{"controller"}, nil,
+		), prometheus.GaugeValue},
+		queueSize: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeofSubsystem, "controller_queue_size"),
+			"Size of the I/O submission queues of the NVMe-oF controller.",
+			[]string{"controller"}, nil,
+		), prometheus.GaugeValue},
+		reconnects: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, nvmeofSubsystem, "controller_reconnects_total"),
+			"Number of times the NVMe-oF controller was seen leaving the live state since the exporter started.",
+			[]string{"controller"}, nil,
+		), prometheus.CounterValue},
+		lastState:  map[string]string{},
+		reconnectN: map[string]float64{},
+	}, nil
+}
+
+func (c *nvmeofCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	ctrls, err := readNVMeoFControllers(sysFilePath("class/nvme"))
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, ctrl := range ctrls {
+		// The kernel doesn't count reconnects, so transitions are only
+		// seen if a scrape happens while the controller isn't live.
+		if c.lastState[ctrl.name] == "live" && ctrl.state != "live" {
+			c.reconnectN[ctrl.name]++
+		}
+		c.lastState[ctrl.name] = ctrl.state
+
+		ch <- c.info.mustNewConstMetric(1, ctrl.name, ctrl.transport, ctrl.subsysNQN, ctrl.address)
+		for _, state := range nvmeofControllerStates {
+			v := 0.0
+			if state == ctrl.state {
+				v = 1.0
+			}
+			ch <- c.state.mustNewConstMetric(v, ctrl.name, state)
+		}
+		ch <- c.queues.mustNewConstMetric(float64(ctrl.queues), ctrl.name)
+		ch <- c.queueSize.mustNewConstMetric(float64(ctrl.queueSize), ctrl.name)
+		ch <- c.reconnects.mustNewConstMetric(c.reconnectN[ctrl.name], ctrl.name)
+	}
+	return nil
+}
+
+// readNVMeoFControllers returns the controllers of /sys/class/nvme with a
+// fabrics transport, skipping local PCIe ones.
+func readNVMeoFControllers(root string) ([]nvmeofController, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		// nvme-core not loaded.
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ctrls []nvmeofController
+	for _, d := range dirs {
+		ctrlPath := path.Join(root, d.Name())
+		ctrl := nvmeofController{name: d.Name()}
+		if ctrl.transport, err = readStringFromFile(path.Join(ctrlPath, "transport")); err != nil {
+			return nil, err
+		}
+		if ctrl.transport == "pcie" {
+			continue
+		}
+		if ctrl.subsysNQN, err = readStringFromFile(path.Join(ctrlPath, "subsysnqn")); err != nil {
+			return nil, err
+		}
+		if ctrl.address, err = readStringFromFile(path.Join(ctrlPath, "address")); err != nil {
+			return nil, err
+		}
+		if ctrl.state, err = readStringFromFile(path.Join(ctrlPath, "state")); err != nil {
+			return nil, err
+		}
+		if ctrl.queues, err = readUintFromFile(path.Join(ctrlPath, "queue_count")); err != nil {
+			return nil, err
+		}
+		if ctrl.queueSize, err = readUintFromFile(path.Join(ctrlPath, "sqsize")); err != nil {
+			return nil, err
+		}
+		ctrls = append(ctrls, ctrl)
+	}
+	return ctrls, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestNVMeoFControllers(t *testing.T) {
+	ctrls, err := readNVMeoFControllers("fixtures/sys/class/nvme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// nvme0 is a local PCIe controller.
+	if want, got := 1, len(ctrls); want != got {
+		t.Fatalf("want %d controllers, got %d", want, got)
+	}
+	want := nvmeofController{
+		name:      "nvme1",
+		transport: "tcp",
+		subsysNQN: "nqn.2014-08.org.nvmexpress:uuid:b0f8e2a4-2c5b-4f64-9b43-6e6c7d8c1a2f",
+		address:   "traddr=192.168.10.20,trsvcid=4420",
+		state:     "connecting",
+		queues:    9,
+		queueSize: 127,
+	}
+	if got := ctrls[0]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noovs
+
+package collector
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const ovsSubsystem = "ovs"
+
+var (
+	ovsRunDir = flag.String("collector.ovs.rundir", "/var/run/openvswitch", "Directory containing the ovsdb-server and ovs-vswitchd sockets.")
+
+	ovsDpifHeaderRE = regexp.MustCompile(`^(\S+): hit:(\d+) missed:(\d+)`)
+	ovsFlowsRE      = regexp.MustCompile(`^\s*flows\s*:\s*\(current (\d+)\)`)
+)
+
+type ovsInterface struct {
+	bridge, port, name string
+	stats              map[string]float64
+}
+
+type ovsDatapath struct {
+	name         string
+	hits, missed float64
+	flows        float64
+}
+
+type ovsCollector struct {
+	interfaceStat typedDesc
+	hits, missed  typedDesc
+	flows         typedDesc
+}
+
+func init() {
+	Factories["ovs"] = NewOVSCollector
+}
+
+// NewOVSCollector returns a new Collector exposing Open vSwitch interface
+// and datapath statistics.
+func NewOVSCollector() (Collector, error) {
+	return &ovsCollector{
+		interfaceStat: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ovsSubsystem, "interface_stat_total"),
+			"Statistic of the Open vSwitch interface as stored in its statistics column.",
+			[]string{"bridge", "port", "interface", "stat"}, nil,
+		), prometheus.CounterValue},
+		hits: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ovsSubsystem, "datapath_hits_total"),
+			"Packets that matched a flow in the datapath.",
+			[]string{"datapath"}, nil,
+		), prometheus.CounterValue},
+		missed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ovsSubsystem, "datapath_upcalls_total"),
+			"Packets that missed the datapath flow table and were sent to userspace.",
+			[]string{"datapath"}, nil,
+		), prometheus.CounterValue},
+		flows: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, ovsSubsystem, "datapath_flows"),
+			"Number of flows installed in the datapath.",
+			[]string{"datapath"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *ovsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	var result json.RawMessage
+	err = ovsCall(path.Join(*ovsRunDir, "db.sock"), "transact", []interface{}{
+		"Open_vSwitch",
+		ovsSelect("Bridge", "name", "ports"),
+		ovsSelect("Port", "_uuid", "name", "interfaces"),
+		ovsSelect("Interface", "_uuid", "name", "statistics"),
+	}, &result)
+	if err != nil {
+		return fmt.Errorf("couldn't query ovsdb: %s", err)
+	}
+	ifaces, err := parseOVSInterfaces(result)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		for stat, v := range iface.stats {
+			ch <- c.interfaceStat.mustNewConstMetric(v, iface.bridge, iface.port, iface.name, stat)
+		}
+	}
+
+	ctl, err := ovsVswitchdSocket()
+	if err != nil {
+		return err
+	}
+	var dpif, upcall string
+	if err := ovsCall(ctl, "dpif/show", []string{}, &dpif); err != nil {
+		return fmt.Errorf("couldn't query ovs-vswitchd: %s", err)
+	}
+	if err := ovsCall(ctl, "upcall/show", []string{}, &upcall); err != nil {
+		return fmt.Errorf("couldn't query ovs-vswitchd: %s", err)
+	}
+	for _, dp := range parseOVSDatapaths(dpif, upcall) {
+		ch <- c.hits.mustNewConstMetric(dp.hits, dp.name)
+		ch <- c.missed.mustNewConstMetric(dp.missed, dp.name)
+		ch <- c.flows.mustNewConstMetric(dp.flows, dp.name)
+	}
+	return nil
+}
+
+func ovsSelect(table string, columns ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"op":      "select",
+		"table":   table,
+		"where":   []interface{}{},
+		"columns": columns,
+	}
+}
+
+// ovsVswitchdSocket returns the unixctl socket of the running ovs-vswitchd,
+// which is named after its pid.
+func ovsVswitchdSocket() (string, error) {
+	pid, err := readUintFromFile(path.Join(*ovsRunDir, "ovs-vswitchd.pid"))
+	if err != nil {
+		return "", fmt.Errorf("couldn't get ovs-vswitchd pid: %s", err)
+	}
+	return path.Join(*ovsRunDir, fmt.Sprintf("ovs-vswitchd.%d.ctl", pid)), nil
+}
+
+// ovsCall performs a single JSON-RPC call, as spoken by both ovsdb-server and
+// the unixctl interface of ovs-vswitchd.
+func ovsCall(socket, method string, params interface{}, result interface{}) error {
+	conn, err := net.DialTimeout("unix", socket, 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	req := map[string]interface{}{"method": method, "params": params, "id": 0}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return err
+	}
+
+	var resp struct {
+		Result json.RawMessage `json:"result"`
+		Error  interface{}     `json:"error"`
+	}
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s failed: %v", method, resp.Error)
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+// parseOVSInterfaces joins the results of selecting the Bridge, Port and
+// Interface tables.
+func parseOVSInterfaces(result json.RawMessage) ([]ovsInterface, error) {
+	var tables []struct {
+		Rows  []map[string]json.RawMessage `json:"rows"`
+		Error string                       `json:"error"`
+	}
+	if err := json.Unmarshal(result, &tables); err != nil {
+		return nil, err
+	}
+	if len(tables) != 3 {
+		return nil, fmt.Errorf("unexpected number of results: %d", len(tables))
+	}
+	for _, t := range tables {
+		if t.Error != "" {
+			return nil, fmt.Errorf("select failed: %s", t.Error)
+		}
+	}
+
+	portBridge := map[string]string{}
+	for _, row := range tables[0].Rows {
+		var name string
+		if err := json.Unmarshal(row["name"], &name); err != nil {
+			return nil, err
+		}
+		ports, err := ovsUUIDSet(row["ports"])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range ports {
+			portBridge[p] = name
+		}
+	}
+
+	type portRef struct{ bridge, name string }
+	ifacePort := map[string]portRef{}
+	for _, row := range tables[1].Rows {
+		var name string
+		if err := json.Unmarshal(row["name"], &name); err != nil {
+			return nil, err
+		}
+		uuid, err := ovsUUIDSet(row["_uuid"])
+		if err != nil || len(uuid) != 1 {
+			return nil, fmt.Errorf("invalid port uuid: %s", row["_uuid"])
+		}
+		ifaces, err := ovsUUIDSet(row["interfaces"])
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range ifaces {
+			ifacePort[i] = portRef{bridge: portBridge[uuid[0]], name: name}
+		}
+	}
+
+	var ifaces []ovsInterface
+	for _, row := range tables[2].Rows {
+		iface := ovsInterface{stats: map[string]float64{}}
+		if err := json.Unmarshal(row["name"], &iface.name); err != nil {
+			return nil, err
+		}
+		uuid, err := ovsUUIDSet(row["_uuid"])
+		if err != nil || len(uuid) != 1 {
+			return nil, fmt.Errorf("invalid interface uuid: %s", row["_uuid"])
+		}
+		port := ifacePort[uuid[0]]
+		iface.bridge, iface.port = port.bridge, port.name
+
+		// Maps are encoded as ["map", [[key, value], ...]].
+		var stats []json.RawMessage
+		if err := json.Unmarshal(row["statistics"], &stats); err != nil || len(stats) != 2 {
+			return nil, fmt.Errorf("invalid statistics of interface %s", iface.name)
+		}
+		var pairs [][2]json.RawMessage
+		if err := json.Unmarshal(stats[1], &pairs); err != nil {
+			return nil, err
+		}
+		for _, p := range pairs {
+			var (
+				k string
+				v float64
+			)
+			if err := json.Unmarshal(p[0], &k); err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(p[1], &v); err != nil {
+				return nil, err
+			}
+			iface.stats[k] = v
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// ovsUUIDSet decodes a set of uuids, which is either a single
+// ["uuid", "..."] atom or ["set", [["uuid", "..."], ...]].
+func ovsUUIDSet(raw json.RawMessage) ([]string, error) {
+	var v []json.RawMessage
+	if err := json.Unmarshal(raw, &v); err != nil || len(v) != 2 {
+		return nil, fmt.Errorf("invalid set: %s", raw)
+	}
+	var kind string
+	if err := json.Unmarshal(v[0], &kind); err != nil {
+		return nil, err
+	}
+	switch kind {
+	case "uuid":
+		var uuid string
+		if err := json.Unmarshal(v[1], &uuid); err != nil {
+			return nil, err
+		}
+		return []string{uuid}, nil
+	case "set":
+		var atoms [][2]string
+		if err := json.Unmarshal(v[1], &atoms); err != nil {
+			return nil, err
+		}
+		uuids := make([]string, 0, len(atoms))
+		for _, a := range atoms {
+			uuids = append(uuids, a[1])
+		}
+		return uuids, nil
+	}
+	return nil, fmt.Errorf("invalid set: %s", raw)
+}
+
+// parseOVSDatapaths parses the output of `ovs-appctl dpif/show` and
+// `ovs-appctl upcall/show`.
+func parseOVSDatapaths(dpif, upcall string) []ovsDatapath {
+	var (
+		datapaths []ovsDatapath
+		index     = map[string]int{}
+	)
+	for _, line := range strings.Split(dpif, "\n") {
+		m := ovsDpifHeaderRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		hits, _ := strconv.ParseFloat(m[2], 64)
+		missed, _ := strconv.ParseFloat(m[3], 64)
+		index[m[1]] = len(datapaths)
+		datapaths = append(datapaths, ovsDatapath{name: m[1], hits: hits, missed: missed})
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(upcall))
+	current := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, " ") && strings.HasSuffix(line, ":") {
+			current = strings.TrimSuffix(line, ":")
+			continue
+		}
+		if m := ovsFlowsRE.FindStringSubmatch(line); m != nil {
+			if i, ok := index[current]; ok {
+				datapaths[i].flows, _ = strconv.ParseFloat(m[1], 64)
+			}
+		}
+	}
+	return datapaths
+}
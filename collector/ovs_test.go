@@ -0,0 +1,58 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+func TestOVSInterfaces(t *testing.T) {
+	result, err := ioutil.ReadFile("fixtures/ovs/transact.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ifaces, err := parseOVSInterfaces(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(ifaces); want != got {
+		t.Fatalf("want %d interfaces, got %d", want, got)
+	}
+
+	vnet := ifaces[1]
+	if vnet.bridge != "br-int" || vnet.port != "vnet0" || vnet.name != "vnet0" {
+		t.Errorf("unexpected interface %+v", vnet)
+	}
+	if want, got := 12.0, vnet.stats["rx_dropped"]; want != got {
+		t.Errorf("want rx_dropped %f, got %f", want, got)
+	}
+}
+
+func TestOVSDatapaths(t *testing.T) {
+	dpif, err := ioutil.ReadFile("fixtures/ovs/dpif_show.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upcall, err := ioutil.ReadFile("fixtures/ovs/upcall_show.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	datapaths := parseOVSDatapaths(string(dpif), string(upcall))
+	want := ovsDatapath{name: "system@ovs-system", hits: 148216, missed: 3109, flows: 25}
+	if len(datapaths) != 1 || datapaths[0] != want {
+		t.Errorf("want %+v, got %+v", want, datapaths)
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopcie
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const pcieSubsystem = "pcie"
+
+// AER counter files by severity, see
+// Documentation/ABI/testing/sysfs-bus-pci-devices-aer_stats.
+var pcieAERFiles = map[string]string{
+	"correctable": "aer_dev_correctable",
+	"nonfatal":    "aer_dev_nonfatal",
+	"fatal":       "aer_dev_fatal",
+}
+
+type pcieLink struct {
+	speed, maxSpeed float64
+	width, maxWidth float64
+}
+
+// pciInfo identifies a PCI device and the driver bound to it.
+type pciInfo struct {
+	vendor, device                   string
+	subsystemVendor, subsystemDevice string
+	class, driver, numaNode          string
+}
+
+type pcieDevice struct {
+	name string
+	// info is nil if the device has no vendor ID.
+	info *pciInfo
+	// Error counters by severity and type, nil without AER support.
+	aer  map[string]map[string]float64
+	link *pcieLink
+}
+
+type pcieCollector struct {
+	info                        typedDesc
+	aerErrors                   typedDesc
+	speed, maxSpeed             typedDesc
+	width, maxWidth, downgraded typedDesc
+}
+
+func init() {
+	Factories["pcie"] = NewPCIeCollector
+}
+
+// NewPCIeCollector returns a new Collector exposing the inventory of PCI
+// devices, AER error counters and the link state of PCIe devices.
+func NewPCIeCollector() (Collector, error) {
+	labels := []string{"device"}
+	return &pcieCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "device_info"),
+			"IDs, class, bound driver and NUMA node of the PCI device, value is always 1.",
+			[]string{"device", "vendor_id", "device_id", "subsystem_vendor_id", "subsystem_device_id", "class_id", "driver", "numa_node"}, nil,
+		), prometheus.GaugeValue},
+		aerErrors: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "aer_errors_total"),
+			"Errors reported by Advanced Error Reporting of the device.",
+			[]string{"device", "severity", "type"}, nil,
+		), prometheus.CounterValue},
+		speed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_speed_gts"),
+			"Negotiated speed of the PCIe link in GT/s.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		maxSpeed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_max_speed_gts"),
+			"Maximum speed the PCIe link is capable of in GT/s.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		width: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_width"),
+			"Negotiated number of lanes of the PCIe link.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		maxWidth: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_max_width"),
+			"Maximum number of lanes the PCIe link is capable of.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		downgraded: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pcieSubsystem, "link_downgraded"),
+			"Whether the PCIe link runs below its maximum speed or width.",
+			labels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *pcieCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readPCIeDevices(sysFilePath("bus/pci/devices"))
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		if i := d.info; i != nil {
+			ch <- c.info.mustNewConstMetric(1, d.name, i.vendor, i.device, i.subsystemVendor, i.subsystemDevice, i.class, i.driver, i.numaNode)
+		}
+		for severity, counters := range d.aer {
+			for typ, v := range counters {
+				ch <- c.aerErrors.mustNewConstMetric(v, d.name, severity, typ)
+			}
+		}
+		if d.link == nil {
+			continue
+		}
+		downgraded := 0.0
+		if d.link.speed < d.link.maxSpeed || d.link.width < d.link.maxWidth {
+			downgraded = 1.0
+		}
+		ch <- c.speed.mustNewConstMetric(d.link.speed, d.name)
+		ch <- c.maxSpeed.mustNewConstMetric(d.link.maxSpeed, d.name)
+		ch <- c.width.mustNewConstMetric(d.link.width, d.name)
+		ch <- c.maxWidth.mustNewConstMetric(d.link.maxWidth, d.name)
+		ch <- c.downgraded.mustNewConstMetric(downgraded, d.name)
+	}
+	return nil
+}
+
+func readPCIeDevices(root string) ([]pcieDevice, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var devices []pcieDevice
+	for _, d := range dirs {
+		devPath := path.Join(root, d.Name())
+		dev := pcieDevice{name: d.Name()}
+		for severity, file := range pcieAERFiles {
+			f, err := os.Open(path.Join(devPath, file))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			counters, err := parsePCIeAERStats(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("couldn't parse %s of %s: %s", file, d.Name(), err)
+			}
+			if dev.aer == nil {
+				dev.aer = map[string]map[string]float64{}
+			}
+			dev.aer[severity] = counters
+		}
+		dev.link = readPCIeLink(devPath)
+		dev.info = readPCIInfo(devPath)
+		if dev.info == nil && dev.aer == nil && dev.link == nil {
+			continue
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+// readPCIInfo returns the IDs and driver of the device. The driver of
+// unbound devices and the NUMA node of kernels without NUMA support are left
+// empty, the kernel reports node -1 for devices not local to a node.
+func readPCIInfo(devPath string) *pciInfo {
+	vendor, err := readStringFromFile(path.Join(devPath, "vendor"))
+	if err != nil {
+		return nil
+	}
+	info := &pciInfo{vendor: vendor}
+	for file, target := range map[string]*string{
+		"device":           &info.device,
+		"subsystem_vendor": &info.subsystemVendor,
+		"subsystem_device": &info.subsystemDevice,
+		"class":            &info.class,
+		"numa_node":        &info.numaNode,
+	} {
+		*target, _ = readStringFromFile(path.Join(devPath, file))
+	}
+	if driver, err := os.Readlink(path.Join(devPath, "driver")); err == nil {
+		info.driver = path.Base(driver)
+	}
+	return info
+}
+
+// readPCIeLink returns the link of the device, or nil for conventional
+// PCI devices and links in an unknown state.
+func readPCIeLink(devPath string) *pcieLink {
+	var (
+		link pcieLink
+		errs [4]error
+	)
+	link.speed, errs[0] = readPCIeLinkSpeed(path.Join(devPath, "current_link_speed"))
+	link.maxSpeed, errs[1] = readPCIeLinkSpeed(path.Join(devPath, "max_link_speed"))
+	link.width, errs[2] = readPCIeLinkWidth(path.Join(devPath, "current_link_width"))
+	link.maxWidth, errs[3] = readPCIeLinkWidth(path.Join(devPath, "max_link_width"))
+	for _, err := range errs {
+		if err != nil {
+			return nil
+		}
+	}
+	return &link
+}
+
+// readPCIeLinkSpeed reads a link speed like "8.0 GT/s PCIe".
+func readPCIeLinkSpeed(file string) (float64, error) {
+	s, err := readStringFromFile(file)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.Fields(s + " ")[0], 64)
+}
+
+func readPCIeLinkWidth(file string) (float64, error) {
+	width, err := readUintFromFile(file)
+	if err != nil {
+		return 0, err
+	}
+	// Reported as 0 or 255 while the link is down.
+	if width == 0 || width == 255 {
+		return 0, fmt.Errorf("link width unknown")
+	}
+	return float64(width), nil
+}
+
+// parsePCIeAERStats parses an AER counter file of lines of <type> <count>,
+// skipping the totals.
+func parsePCIeAERStats(r io.Reader) (map[string]float64, error) {
+	counters := map[string]float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		if strings.HasPrefix(fields[0], "TOTAL_") {
+			continue
+		}
+		v, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		counters[fields[0]] = v
+	}
+	return counters, scanner.Err()
+}
@@ -0,0 +1,69 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestPCIeDevices(t *testing.T) {
+	devices, err := readPCIeDevices("fixtures/sys/bus/pci/devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 3, len(devices); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+
+	port := devices[0]
+	if port.aer != nil {
+		t.Errorf("want no AER counters for %s, got %v", port.name, port.aer)
+	}
+	if want, got := (pcieLink{speed: 8, maxSpeed: 8, width: 8, maxWidth: 8}), *port.link; want != got {
+		t.Errorf("want link %+v, got %+v", want, got)
+	}
+
+	// 0000:00:1f.0 has neither a PCIe link nor AER, nor a driver.
+	bridge := devices[1]
+	if bridge.link != nil || bridge.aer != nil {
+		t.Errorf("want only info for %s, got %+v", bridge.name, bridge)
+	}
+	if want, got := (pciInfo{vendor: "0x8086", device: "0xa305", subsystemVendor: "0x1028", subsystemDevice: "0x0869", class: "0x060100", numaNode: "-1"}), *bridge.info; want != got {
+		t.Errorf("want info %+v, got %+v", want, got)
+	}
+
+	dev := devices[2]
+	if want, got := "igb", dev.info.driver; want != got {
+		t.Errorf("want driver %s, got %s", want, got)
+	}
+	if want, got := (pcieLink{speed: 5, maxSpeed: 8, width: 4, maxWidth: 8}), *dev.link; want != got {
+		t.Errorf("want link %+v, got %+v", want, got)
+	}
+	for _, c := range []struct {
+		severity, typ string
+		want          float64
+	}{
+		{"correctable", "BadTLP", 12},
+		{"correctable", "RxErr", 3},
+		{"nonfatal", "CmpltTO", 1},
+		{"fatal", "DLP", 0},
+	} {
+		if got := dev.aer[c.severity][c.typ]; c.want != got {
+			t.Errorf("want %s %s %f, got %f", c.severity, c.typ, c.want, got)
+		}
+	}
+	if _, ok := dev.aer["correctable"]["TOTAL_ERR_COR"]; ok {
+		t.Error("want totals to be skipped")
+	}
+}
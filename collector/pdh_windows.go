@@ -0,0 +1,123 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build experimental_windows
+
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The Windows collectors are experimental and only built with the
+// experimental_windows tag. windows_exporter remains the recommended way
+// to monitor Windows hosts.
+
+var (
+	modkernel32 = windows.NewLazySystemDLL("kernel32.dll")
+	modntdll    = windows.NewLazySystemDLL("ntdll.dll")
+	modpdh      = windows.NewLazySystemDLL("pdh.dll")
+
+	procGlobalMemoryStatusEx     = modkernel32.NewProc("GlobalMemoryStatusEx")
+	procGetLogicalDriveStringsW  = modkernel32.NewProc("GetLogicalDriveStringsW")
+	procGetDriveTypeW            = modkernel32.NewProc("GetDriveTypeW")
+	procGetDiskFreeSpaceExW      = modkernel32.NewProc("GetDiskFreeSpaceExW")
+	procGetVolumeInformationW    = modkernel32.NewProc("GetVolumeInformationW")
+	procNtQuerySystemInformation = modntdll.NewProc("NtQuerySystemInformation")
+	procPdhOpenQueryW            = modpdh.NewProc("PdhOpenQueryW")
+	procPdhAddEnglishCounterW    = modpdh.NewProc("PdhAddEnglishCounterW")
+	procPdhCollectQueryData      = modpdh.NewProc("PdhCollectQueryData")
+	procPdhGetRawCounterArrayW   = modpdh.NewProc("PdhGetRawCounterArrayW")
+	procPdhCloseQuery            = modpdh.NewProc("PdhCloseQuery")
+)
+
+const (
+	pdhMoreData = 0x800007d2
+
+	// Size of PDH_RAW_COUNTER_ITEM_W and the offsets in it, which are the
+	// same on 386 and amd64 as the raw counter is aligned to eight bytes.
+	pdhRawCounterItemSize = 48
+	pdhRawCounterOffset   = 8
+	pdhFirstValueOffset   = pdhRawCounterOffset + 16
+)
+
+// pdhRawCounters returns the raw values of the instances of the PDH counter
+// paths, e.g. \Network Interface(*)\Bytes Received/sec, by path and
+// instance. For counters of events per second the raw value is the number
+// of events.
+func pdhRawCounters(paths []string) (map[string]map[string]int64, error) {
+	var query windows.Handle
+	if r, _, _ := procPdhOpenQueryW.Call(0, 0, uintptr(unsafe.Pointer(&query))); r != 0 {
+		return nil, fmt.Errorf("PdhOpenQuery failed with 0x%x", r)
+	}
+	defer procPdhCloseQuery.Call(uintptr(query))
+
+	counters := make([]windows.Handle, len(paths))
+	for i, path := range paths {
+		p, err := windows.UTF16PtrFromString(path)
+		if err != nil {
+			return nil, err
+		}
+		if r, _, _ := procPdhAddEnglishCounterW.Call(uintptr(query), uintptr(unsafe.Pointer(p)), 0, uintptr(unsafe.Pointer(&counters[i]))); r != 0 {
+			return nil, fmt.Errorf("PdhAddEnglishCounter(%s) failed with 0x%x", path, r)
+		}
+	}
+	if r, _, _ := procPdhCollectQueryData.Call(uintptr(query)); r != 0 {
+		return nil, fmt.Errorf("PdhCollectQueryData failed with 0x%x", r)
+	}
+
+	values := map[string]map[string]int64{}
+	for i, path := range paths {
+		var size, count uint32
+		r, _, _ := procPdhGetRawCounterArrayW.Call(uintptr(counters[i]), uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&count)), 0)
+		if r != pdhMoreData {
+			// No instances, e.g. no network interfaces.
+			values[path] = map[string]int64{}
+			continue
+		}
+		buf := make([]byte, size)
+		if r, _, _ := procPdhGetRawCounterArrayW.Call(uintptr(counters[i]), uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&buf[0]))); r != 0 {
+			return nil, fmt.Errorf("PdhGetRawCounterArray(%s) failed with 0x%x", path, r)
+		}
+		values[path] = parsePdhRawCounterItems(buf, int(count))
+	}
+	return values, nil
+}
+
+// parsePdhRawCounterItems reads the name and first value of count
+// PDH_RAW_COUNTER_ITEM_W. The names point into buf.
+func parsePdhRawCounterItems(buf []byte, count int) map[string]int64 {
+	values := map[string]int64{}
+	for i := 0; i < count && (i+1)*pdhRawCounterItemSize <= len(buf); i++ {
+		item := buf[i*pdhRawCounterItemSize:]
+		name := *(**uint16)(unsafe.Pointer(&item[0]))
+		values[utf16PtrToString(name)] = int64(binary.LittleEndian.Uint64(item[pdhFirstValueOffset:]))
+	}
+	return values
+}
+
+// utf16PtrToString converts a NUL terminated UTF-16 string.
+func utf16PtrToString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	var s []uint16
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; ptr = unsafe.Pointer(uintptr(ptr) + 2) {
+		s = append(s, *(*uint16)(ptr))
+	}
+	return windows.UTF16ToString(s)
+}
@@ -0,0 +1,197 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopmem
+
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os/exec"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const pmemSubsystem = "pmem"
+
+var (
+	ndctlCommand = flag.String("collector.pmem.command", "ndctl", "Command to run ndctl.")
+
+	// Health states as printed by ndctl.
+	pmemHealthStates = []string{"ok", "non-critical", "critical", "fatal"}
+)
+
+// pmemDIMM is a DIMM as listed by `ndctl list --dimms --health`. Health
+// fields the DIMM doesn't support are omitted.
+type pmemDIMM struct {
+	Dev    string `json:"dev"`
+	ID     string `json:"id"`
+	Health struct {
+		State                 string   `json:"health_state"`
+		Temperature           *float64 `json:"temperature_celsius"`
+		ControllerTemperature *float64 `json:"controller_temperature_celsius"`
+		Spares                *float64 `json:"spares_percentage"`
+		LifeUsed              *float64 `json:"life_used_percentage"`
+		ShutdownState         string   `json:"shutdown_state"`
+		ShutdownCount         *float64 `json:"shutdown_count"`
+	} `json:"health"`
+}
+
+type pmemCollector struct {
+	cli                            string
+	info, health                   typedDesc
+	temperature, controllerTemp    typedDesc
+	spares, lifeUsed               typedDesc
+	shutdownClean, unsafeShutdowns typedDesc
+}
+
+func init() {
+	Factories["pmem"] = NewPMemCollector
+}
+
+// NewPMemCollector returns a new Collector exposing the health of NVDIMMs.
+func NewPMemCollector() (Collector, error) {
+	labels := []string{"dimm"}
+	return &pmemCollector{
+		cli: *ndctlCommand,
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_info"),
+			"Identifier of the NVDIMM, value is always 1.",
+			[]string{"dimm", "id"}, nil,
+		), prometheus.GaugeValue},
+		health: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_health_state"),
+			"Health state of the NVDIMM.",
+			[]string{"dimm", "state"}, nil,
+		), prometheus.GaugeValue},
+		temperature: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_media_temperature_celsius"),
+			"Media temperature of the NVDIMM.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		controllerTemp: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_controller_temperature_celsius"),
+			"Controller temperature of the NVDIMM.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		spares: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_spares_ratio"),
+			"Remaining fraction of the spare capacity of the NVDIMM.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		lifeUsed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_life_used_ratio"),
+			"Fraction of the rated endurance of the NVDIMM used.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		shutdownClean: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_last_shutdown_clean"),
+			"Whether the last shutdown of the NVDIMM was clean.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		unsafeShutdowns: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pmemSubsystem, "dimm_unsafe_shutdowns_total"),
+			"Number of unsafe shutdowns of the NVDIMM.",
+			labels, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *pmemCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "list", "--dimms", "--health")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	dimms, err := parseNDCtlDIMMs(pipe)
+	if err != nil {
+		// Stop ndctl, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	for _, d := range dimms {
+		ch <- c.info.mustNewConstMetric(1, d.Dev, d.ID)
+		h := d.Health
+		if h.State != "" {
+			for _, state := range pmemHealthStates {
+				v := 0.0
+				if state == h.State {
+					v = 1.0
+				}
+				ch <- c.health.mustNewConstMetric(v, d.Dev, state)
+			}
+		}
+		if h.ShutdownState != "" {
+			clean := 0.0
+			if h.ShutdownState == "clean" {
+				clean = 1.0
+			}
+			ch <- c.shutdownClean.mustNewConstMetric(clean, d.Dev)
+		}
+		for _, m := range []struct {
+			desc  *typedDesc
+			v     *float64
+			scale float64
+		}{
+			{&c.temperature, h.Temperature, 1},
+			{&c.controllerTemp, h.ControllerTemperature, 1},
+			{&c.spares, h.Spares, 0.01},
+			{&c.lifeUsed, h.LifeUsed, 0.01},
+			{&c.unsafeShutdowns, h.ShutdownCount, 1},
+		} {
+			if m.v != nil {
+				ch <- m.desc.mustNewConstMetric(*m.v*m.scale, d.Dev)
+			}
+		}
+	}
+	return nil
+}
+
+// parseNDCtlDIMMs parses the output of `ndctl list --dimms`, which is
+// empty without DIMMs, an object for a single one and an array otherwise.
+func parseNDCtlDIMMs(r io.Reader) ([]pmemDIMM, error) {
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	out = bytes.TrimSpace(out)
+	switch {
+	case len(out) == 0:
+		return nil, nil
+	case out[0] == '{':
+		var dimm pmemDIMM
+		if err := json.Unmarshal(out, &dimm); err != nil {
+			return nil, err
+		}
+		return []pmemDIMM{dimm}, nil
+	}
+	var dimms []pmemDIMM
+	if err := json.Unmarshal(out, &dimms); err != nil {
+		return nil, err
+	}
+	return dimms, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNDCtlDIMMs(t *testing.T) {
+	file, err := os.Open("fixtures/ndctl_dimms.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	dimms, err := parseNDCtlDIMMs(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(dimms); want != got {
+		t.Fatalf("want %d DIMMs, got %d", want, got)
+	}
+	d := dimms[0]
+	if want, got := "nmem1", d.Dev; want != got {
+		t.Errorf("want DIMM %s, got %s", want, got)
+	}
+	if want, got := "non-critical", d.Health.State; want != got {
+		t.Errorf("want health state %s, got %s", want, got)
+	}
+	if want, got := 12.0, *d.Health.Spares; want != got {
+		t.Errorf("want %f%% spares, got %f", want, got)
+	}
+	if d.Health.LifeUsed != nil {
+		t.Errorf("want no life used, got %f", *d.Health.LifeUsed)
+	}
+	if want, got := 3.0, *d.Health.ShutdownCount; want != got {
+		t.Errorf("want %f unsafe shutdowns, got %f", want, got)
+	}
+
+	// A single DIMM is printed as an object.
+	dimms, err = parseNDCtlDIMMs(strings.NewReader(`{"dev":"nmem0","id":"8089-a2-1838-00000d30"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(dimms); want != got {
+		t.Fatalf("want %d DIMMs, got %d", want, got)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nopowersupply
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const powerSupplySubsystem = "powersupply"
+
+type powerSupply struct {
+	name, typ    string
+	usbType      string
+	manufacturer string
+	model        string
+	online       *float64
+	cycleCount   *float64
+	capacity     *float64
+	health       *float64
+	power        *float64
+}
+
+type powerSupplyCollector struct {
+	info, online, cycleCount typedDesc
+	capacity, health, power  typedDesc
+}
+
+func init() {
+	Factories["powersupply"] = NewPowerSupplyCollector
+}
+
+// NewPowerSupplyCollector returns a new Collector exposing the state of
+// batteries and power adapters of /sys/class/power_supply.
+func NewPowerSupplyCollector() (Collector, error) {
+	labels := []string{"power_supply"}
+	return &powerSupplyCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "info"),
+			"Type and model of the power supply, value is always 1.",
+			[]string{"power_supply", "type", "usb_type", "manufacturer", "model_name"}, nil,
+		), prometheus.GaugeValue},
+		online: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "online"),
+			"Whether the power adapter is connected.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		cycleCount: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "cycle_count"),
+			"Number of charge cycles of the battery.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		capacity: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "capacity_ratio"),
+			"Charge of the battery relative to its full capacity.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		health: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "health_ratio"),
+			"Full capacity of the battery relative to its design capacity.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		power: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, powerSupplySubsystem, "power_watts"),
+			"Power currently drawn from or supplied by the power supply.",
+			labels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *powerSupplyCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	supplies, err := readPowerSupplies(sysFilePath("class/power_supply"))
+	if err != nil {
+		return err
+	}
+	for _, s := range supplies {
+		ch <- c.info.mustNewConstMetric(1, s.name, s.typ, s.usbType, s.manufacturer, s.model)
+		for desc, v := range map[*typedDesc]*float64{
+			&c.online:     s.online,
+			&c.cycleCount: s.cycleCount,
+			&c.capacity:   s.capacity,
+			&c.health:     s.health,
+			&c.power:      s.power,
+		} {
+			if v != nil {
+				ch <- desc.mustNewConstMetric(*v, s.name)
+			}
+		}
+	}
+	return nil
+}
+
+// readPowerSupplies reads the power supplies of /sys/class/power_supply.
+// Attributes depend on the driver, those that aren't provided are left
+// nil.
+func readPowerSupplies(root string) ([]powerSupply, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var supplies []powerSupply
+	for _, d := range dirs {
+		supplyPath := path.Join(root, d.Name())
+		s := powerSupply{name: d.Name()}
+		if s.typ, err = readStringFromFile(path.Join(supplyPath, "type")); err != nil {
+			return nil, err
+		}
+		s.manufacturer, _ = readStringFromFile(path.Join(supplyPath, "manufacturer"))
+		s.model, _ = readStringFromFile(path.Join(supplyPath, "model_name"))
+		if usbType, err := readStringFromFile(path.Join(supplyPath, "usb_type")); err == nil {
+			s.usbType = powerSupplyUSBType(usbType)
+		}
+
+		attr := func(name string) *float64 {
+			v, err := readStringFromFile(path.Join(supplyPath, name))
+			if err != nil {
+				return nil
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil
+			}
+			return &f
+		}
+		ratio := func(a, b *float64) *float64 {
+			if a == nil || b == nil || *b == 0 {
+				return nil
+			}
+			r := *a / *b
+			return &r
+		}
+
+		s.online = attr("online")
+		// Some fuel gauges report -1 or 0 if they don't count cycles.
+		if s.cycleCount = attr("cycle_count"); s.cycleCount != nil && *s.cycleCount <= 0 {
+			s.cycleCount = nil
+		}
+		if capacity := attr("capacity"); capacity != nil {
+			*capacity /= 100
+			s.capacity = capacity
+		}
+		// Batteries report either energy in µWh or charge in µAh.
+		s.health = ratio(attr("energy_full"), attr("energy_full_design"))
+		if s.health == nil {
+			s.health = ratio(attr("charge_full"), attr("charge_full_design"))
+		}
+		if power := attr("power_now"); power != nil {
+			*power /= 1e6
+			s.power = power
+		} else if current, voltage := attr("current_now"), attr("voltage_now"); current != nil && voltage != nil {
+			// µA times µV.
+			p := *current * *voltage / 1e12
+			s.power = &p
+		}
+		supplies = append(supplies, s)
+	}
+	return supplies, nil
+}
+
+// powerSupplyUSBType returns the active type of usb_type, which lists the
+// supported types with the active one in brackets.
+func powerSupplyUSBType(s string) string {
+	for _, t := range strings.Fields(s) {
+		if strings.HasPrefix(t, "[") && strings.HasSuffix(t, "]") {
+			return strings.Trim(t, "[]")
+		}
+	}
+	return s
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestPowerSupplies(t *testing.T) {
+	supplies, err := readPowerSupplies("fixtures/sys/class/power_supply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 4, len(supplies); want != got {
+		t.Fatalf("want %d power supplies, got %d", want, got)
+	}
+
+	// -1 stands for an attribute that isn't provided.
+	value := func(v *float64) float64 {
+		if v == nil {
+			return -1
+		}
+		return *v
+	}
+	for i, want := range []struct {
+		name, usbType                           string
+		online, cycles, capacity, health, power float64
+	}{
+		{"AC", "", 1, -1, -1, -1, -1},
+		{"BAT0", "", -1, 412, 0.87, 0.8, 8.53},
+		{"BAT1", "", -1, -1, 1, 0.95, 3},
+		{"ucsi-source-psy-USBC000:001", "PD", 1, -1, -1, -1, 65},
+	} {
+		s := supplies[i]
+		if want.name != s.name || want.usbType != s.usbType {
+			t.Errorf("want %s with USB type %q, got %s with %q", want.name, want.usbType, s.name, s.usbType)
+		}
+		for _, v := range []struct {
+			attr      string
+			want, got float64
+		}{
+			{"online", want.online, value(s.online)},
+			{"cycle_count", want.cycles, value(s.cycleCount)},
+			{"capacity", want.capacity, value(s.capacity)},
+			{"health", want.health, value(s.health)},
+			{"power", want.power, value(s.power)},
+		} {
+			if v.want != v.got {
+				t.Errorf("want %s %s %f, got %f", want.name, v.attr, v.want, v.got)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noppp
+
+package collector
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	pppSubsystem = "ppp"
+
+	// ARPHRD_PPP from include/uapi/linux/if_arp.h.
+	arphrdPPP = 512
+)
+
+var (
+	pppRunDir = flag.String("collector.ppp.rundir", "/var/run", "Directory pppd writes its <interface>.pid files to.")
+)
+
+type pppSession struct {
+	device    string
+	ifindex   uint64
+	mtu       uint64
+	up        bool
+	startTime float64
+}
+
+type pppCollector struct {
+	up, startTime, mtu, reconnects typedDesc
+
+	mtx        sync.Mutex
+	ifindexes  map[string]uint64
+	reconnectN map[string]float64
+}
+
+func init() {
+	Factories["ppp"] = NewPPPCollector
+}
+
+// NewPPPCollector returns a new Collector exposing PPP session state.
+func NewPPPCollector() (Collector, error) {
+	labels := []string{"device"}
+	return &pppCollector{
+		up: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pppSubsystem, "session_up"),
+			"Whether pppd reports the PPP session as established.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		startTime: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pppSubsystem, "session_start_time_seconds"),
+			"Unix time the PPP session was established.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		mtu: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pppSubsystem, "mtu_bytes"),
+			"MTU negotiated for the PPP interface.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		reconnects: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, pppSubsystem, "reconnects_total"),
+			"Number of times the PPP interface was recreated since the exporter started.",
+			labels, nil,
+		), prometheus.CounterValue},
+		ifindexes:  map[string]uint64{},
+		reconnectN: map[string]float64{},
+	}, nil
+}
+
+func (c *pppCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	sessions, err := readPPPSessions(sysFilePath("class/net"), *pppRunDir)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, s := range sessions {
+		// pppd creates a new interface for every session, so a changed
+		// ifindex means the link was renegotiated.
+		if last, ok := c.ifindexes[s.device]; ok && last != s.ifindex {
+			c.reconnectN[s.device]++
+		}
+		c.ifindexes[s.device] = s.ifindex
+
+		up := 0.0
+		if s.up {
+			up = 1.0
+			ch <- c.startTime.mustNewConstMetric(s.startTime, s.device)
+		}
+		ch <- c.up.mustNewConstMetric(up, s.device)
+		ch <- c.mtu.mustNewConstMetric(float64(s.mtu), s.device)
+		ch <- c.reconnects.mustNewConstMetric(c.reconnectN[s.device], s.device)
+	}
+	return nil
+}
+
+func readPPPSessions(netRoot, runDir string) ([]pppSession, error) {
+	ifaces, err := ioutil.ReadDir(netRoot)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []pppSession
+	for _, iface := range ifaces {
+		ifacePath := path.Join(netRoot, iface.Name())
+		typ, err := readUintFromFile(path.Join(ifacePath, "type"))
+		if err != nil || typ != arphrdPPP {
+			continue
+		}
+		s := pppSession{device: iface.Name()}
+		if s.ifindex, err = readUintFromFile(path.Join(ifacePath, "ifindex")); err != nil {
+			return nil, err
+		}
+		if s.mtu, err = readUintFromFile(path.Join(ifacePath, "mtu")); err != nil {
+			return nil, err
+		}
+		// pppd writes the pid file once IPCP is up and removes it when the
+		// session terminates.
+		fi, err := os.Stat(path.Join(runDir, iface.Name()+".pid"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			s.up = true
+			s.startTime = float64(fi.ModTime().UnixNano()) / 1e9
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestPPPSessions(t *testing.T) {
+	sessions, err := readPPPSessions("fixtures/sys/class/net", "fixtures/run")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want, got := 1, len(sessions); want != got {
+		t.Fatalf("want %d sessions, got %d", want, got)
+	}
+	s := sessions[0]
+	if want, got := "ppp0", s.device; want != got {
+		t.Errorf("want device %s, got %s", want, got)
+	}
+	if want, got := uint64(9), s.ifindex; want != got {
+		t.Errorf("want ifindex %d, got %d", want, got)
+	}
+	if want, got := uint64(1492), s.mtu; want != got {
+		t.Errorf("want mtu %d, got %d", want, got)
+	}
+	if !s.up || s.startTime == 0 {
+		t.Errorf("want session up with start time, got %+v", s)
+	}
+
+	sessions, err = readPPPSessions("fixtures/sys/class/net", "fixtures/nonexistent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].up {
+		t.Errorf("want session down without pid file, got %+v", sessions)
+	}
+}
@@ -0,0 +1,277 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noprocfd
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	procfdSubsystem = "procfd"
+
+	inotifyLink = "anon_inode:inotify"
+)
+
+var (
+	procfdTopCommands = flag.Int("collector.procfd.top-commands", 10, "Number of commands with the most inotify watches and open file descriptors to expose.")
+)
+
+// procfdUsage is the inotify and file descriptor usage of all processes
+// sharing a command name.
+type procfdUsage struct {
+	command          string
+	inotifyInstances float64
+	inotifyWatches   float64
+	fds              float64
+	// Highest ratio of open file descriptors to the soft limit of any of
+	// the processes.
+	fdUsage float64
+}
+
+type procfdCollector struct {
+	instances, watches                         typedDesc
+	maxInstances, maxWatches                   typedDesc
+	cmdInstances, cmdWatches, cmdFds, cmdUsage typedDesc
+}
+
+func init() {
+	Factories["procfd"] = NewProcFDCollector
+}
+
+// NewProcFDCollector returns a new Collector exposing inotify usage and the
+// commands using the most inotify watches and file descriptors.
+func NewProcFDCollector() (Collector, error) {
+	return &procfdCollector{
+		instances: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "inotify_instances"),
+			"Number of inotify instances of all processes.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		watches: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "inotify_watches"),
+			"Number of inotify watches of all processes.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		maxInstances: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "inotify_max_user_instances"),
+			"Maximum number of inotify instances per user.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		maxWatches: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "inotify_max_user_watches"),
+			"Maximum number of inotify watches per user.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		cmdInstances: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "command_inotify_instances"),
+			"Number of inotify instances of the processes of the command.",
+			[]string{"command"}, nil,
+		), prometheus.GaugeValue},
+		cmdWatches: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "command_inotify_watches"),
+			"Number of inotify watches of the processes of the command.",
+			[]string{"command"}, nil,
+		), prometheus.GaugeValue},
+		cmdFds: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "command_open_fds"),
+			"Number of file descriptors opened by the processes of the command.",
+			[]string{"command"}, nil,
+		), prometheus.GaugeValue},
+		cmdUsage: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, procfdSubsystem, "command_max_fd_usage_ratio"),
+			"Highest ratio of open file descriptors to their soft limit of the processes of the command.",
+			[]string{"command"}, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *procfdCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	maxInstances, err := readUintFromFile(procFilePath("sys/fs/inotify/max_user_instances"))
+	if err != nil {
+		return err
+	}
+	maxWatches, err := readUintFromFile(procFilePath("sys/fs/inotify/max_user_watches"))
+	if err != nil {
+		return err
+	}
+	ch <- c.maxInstances.mustNewConstMetric(float64(maxInstances))
+	ch <- c.maxWatches.mustNewConstMetric(float64(maxWatches))
+
+	usage, err := readProcFDUsage(procFilePath(""))
+	if err != nil {
+		return err
+	}
+	var instances, watches float64
+	for _, u := range usage {
+		instances += u.inotifyInstances
+		watches += u.inotifyWatches
+	}
+	ch <- c.instances.mustNewConstMetric(instances)
+	ch <- c.watches.mustNewConstMetric(watches)
+
+	sort.Sort(sort.Reverse(procfdByWatches(usage)))
+	for i, u := range usage {
+		if i >= *procfdTopCommands || u.inotifyWatches == 0 {
+			break
+		}
+		ch <- c.cmdInstances.mustNewConstMetric(u.inotifyInstances, u.command)
+		ch <- c.cmdWatches.mustNewConstMetric(u.inotifyWatches, u.command)
+	}
+	sort.Sort(sort.Reverse(procfdByFds(usage)))
+	for i, u := range usage {
+		if i >= *procfdTopCommands {
+			break
+		}
+		ch <- c.cmdFds.mustNewConstMetric(u.fds, u.command)
+		ch <- c.cmdUsage.mustNewConstMetric(u.fdUsage, u.command)
+	}
+	return nil
+}
+
+type procfdByWatches []procfdUsage
+
+func (u procfdByWatches) Len() int           { return len(u) }
+func (u procfdByWatches) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+func (u procfdByWatches) Less(i, j int) bool { return u[i].inotifyWatches < u[j].inotifyWatches }
+
+type procfdByFds []procfdUsage
+
+func (u procfdByFds) Len() int           { return len(u) }
+func (u procfdByFds) Swap(i, j int)      { u[i], u[j] = u[j], u[i] }
+func (u procfdByFds) Less(i, j int) bool { return u[i].fds < u[j].fds }
+
+// readProcFDUsage walks the file descriptors of all processes and sums
+// their usage by command. Processes that exit or can't be inspected are
+// skipped.
+func readProcFDUsage(procPath string) ([]procfdUsage, error) {
+	dir, err := os.Open(procPath)
+	if err != nil {
+		return nil, err
+	}
+	pids, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	byCommand := map[string]*procfdUsage{}
+	for _, pid := range pids {
+		if _, err := strconv.Atoi(pid); err != nil {
+			continue
+		}
+		pidPath := path.Join(procPath, pid)
+		command, err := readStringFromFile(path.Join(pidPath, "comm"))
+		if err != nil {
+			continue
+		}
+		p, err := readProcessFDs(pidPath)
+		if err != nil {
+			continue
+		}
+		u, ok := byCommand[command]
+		if !ok {
+			u = &procfdUsage{command: command}
+			byCommand[command] = u
+		}
+		u.inotifyInstances += p.inotifyInstances
+		u.inotifyWatches += p.inotifyWatches
+		u.fds += p.fds
+		if limit, err := readProcessFDLimit(pidPath); err == nil && limit > 0 && p.fds/limit > u.fdUsage {
+			u.fdUsage = p.fds / limit
+		}
+	}
+
+	usage := make([]procfdUsage, 0, len(byCommand))
+	for _, u := range byCommand {
+		usage = append(usage, *u)
+	}
+	return usage, nil
+}
+
+func readProcessFDs(pidPath string) (u procfdUsage, err error) {
+	dir, err := os.Open(path.Join(pidPath, "fd"))
+	if err != nil {
+		return u, err
+	}
+	fds, err := dir.Readdirnames(-1)
+	dir.Close()
+	if err != nil {
+		return u, err
+	}
+	u.fds = float64(len(fds))
+	for _, fd := range fds {
+		target, err := os.Readlink(path.Join(pidPath, "fd", fd))
+		if err != nil || target != inotifyLink {
+			continue
+		}
+		u.inotifyInstances++
+		watches, err := countInotifyWatches(path.Join(pidPath, "fdinfo", fd))
+		if err != nil {
+			continue
+		}
+		u.inotifyWatches += watches
+	}
+	return u, nil
+}
+
+func countInotifyWatches(fdinfoPath string) (float64, error) {
+	file, err := os.Open(fdinfoPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var watches float64
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "inotify wd:") {
+			watches++
+		}
+	}
+	return watches, scanner.Err()
+}
+
+// readProcessFDLimit returns the soft limit of open files of the process,
+// or 0 if unlimited.
+func readProcessFDLimit(pidPath string) (float64, error) {
+	file, err := os.Open(path.Join(pidPath, "limits"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) == 0 || fields[0] == "unlimited" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(fields[0], 64)
+	}
+	return 0, scanner.Err()
+}
@@ -0,0 +1,40 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestProcFDUsage(t *testing.T) {
+	usage, err := readProcFDUsage("fixtures/proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Process 10 has neither comm nor fd and is skipped.
+	if want, got := 1, len(usage); want != got {
+		t.Fatalf("want %d commands, got %d", want, got)
+	}
+
+	want := procfdUsage{
+		command:          "kubelet",
+		inotifyInstances: 2,
+		inotifyWatches:   4,
+		fds:              4,
+		fdUsage:          0.5,
+	}
+	if got := usage[0]; want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noquota
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	quotaSubsystem = "quota"
+
+	// Quota types and commands from include/uapi/linux/quota.h.
+	quotaUser       = 0
+	quotaGroup      = 1
+	quotaProject    = 2
+	qGetNextQuota   = 0x800009
+	qcmdShift       = 8
+	nextDqblkSize   = 72
+	quotaBlockBytes = 1024
+)
+
+var (
+	quotaTypeNames = []string{"user", "group", "project"}
+
+	// Mount options enabling each quota type, as shown in /proc/mounts by
+	// ext4 and XFS.
+	quotaMountOptions = map[string]int{
+		"quota":       quotaUser,
+		"usrquota":    quotaUser,
+		"uquota":      quotaUser,
+		"uqnoenforce": quotaUser,
+		"grpquota":    quotaGroup,
+		"gquota":      quotaGroup,
+		"gqnoenforce": quotaGroup,
+		"prjquota":    quotaProject,
+		"pquota":      quotaProject,
+		"pqnoenforce": quotaProject,
+	}
+)
+
+type quotaMount struct {
+	device, mountPoint string
+	types              []int
+}
+
+// quotaEntry is a struct if_nextdqblk.
+type quotaEntry struct {
+	id                           uint32
+	blockHard, blockSoft, space  uint64
+	inodeHard, inodeSoft, inodes uint64
+}
+
+type quotaCollector struct {
+	used, soft, hard                   typedDesc
+	usedInodes, softInodes, hardInodes typedDesc
+}
+
+func init() {
+	Factories["quota"] = NewQuotaCollector
+}
+
+// NewQuotaCollector returns a new Collector exposing user, group and project
+// quota usage and limits.
+func NewQuotaCollector() (Collector, error) {
+	labels := []string{"device", "mountpoint", "type", "id"}
+	desc := func(name, help string) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, quotaSubsystem, name),
+			help, labels, nil,
+		), prometheus.GaugeValue}
+	}
+	return &quotaCollector{
+		used:       desc("used_bytes", "Space used by the quota id."),
+		soft:       desc("soft_limit_bytes", "Soft limit of the space of the quota id, 0 if unlimited."),
+		hard:       desc("hard_limit_bytes", "Hard limit of the space of the quota id, 0 if unlimited."),
+		usedInodes: desc("used_inodes", "Inodes used by the quota id."),
+		softInodes: desc("soft_limit_inodes", "Soft limit of the inodes of the quota id, 0 if unlimited."),
+		hardInodes: desc("hard_limit_inodes", "Hard limit of the inodes of the quota id, 0 if unlimited."),
+	}, nil
+}
+
+func (c *quotaCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("mounts"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	mounts, err := parseQuotaMounts(file)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mounts {
+		for _, typ := range m.types {
+			entries, err := getQuotaEntries(m.device, typ)
+			if err == syscall.ESRCH {
+				log.Debugf("%s quota not enabled on %s", quotaTypeNames[typ], m.mountPoint)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("couldn't get %s quota of %s: %s", quotaTypeNames[typ], m.mountPoint, err)
+			}
+			for _, e := range entries {
+				labels := []string{m.device, m.mountPoint, quotaTypeNames[typ], strconv.FormatUint(uint64(e.id), 10)}
+				ch <- c.used.mustNewConstMetric(float64(e.space), labels...)
+				ch <- c.soft.mustNewConstMetric(float64(e.blockSoft*quotaBlockBytes), labels...)
+				ch <- c.hard.mustNewConstMetric(float64(e.blockHard*quotaBlockBytes), labels...)
+				ch <- c.usedInodes.mustNewConstMetric(float64(e.inodes), labels...)
+				ch <- c.softInodes.mustNewConstMetric(float64(e.inodeSoft), labels...)
+				ch <- c.hardInodes.mustNewConstMetric(float64(e.inodeHard), labels...)
+			}
+		}
+	}
+	return nil
+}
+
+// parseQuotaMounts returns the mounts of /proc/mounts that have quota
+// enabled by their mount options.
+func parseQuotaMounts(r io.Reader) ([]quotaMount, error) {
+	var mounts []quotaMount
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 4 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		m := quotaMount{device: parts[0], mountPoint: parts[1]}
+		seen := map[int]bool{}
+		for _, opt := range strings.Split(parts[3], ",") {
+			// ext4 journaled quota options carry the quota file name.
+			opt = strings.SplitN(opt, "=", 2)[0]
+			if opt == "usrjquota" {
+				opt = "usrquota"
+			} else if opt == "grpjquota" {
+				opt = "grpquota"
+			}
+			if typ, ok := quotaMountOptions[opt]; ok && !seen[typ] {
+				seen[typ] = true
+				m.types = append(m.types, typ)
+			}
+		}
+		if len(m.types) > 0 {
+			mounts = append(mounts, m)
+		}
+	}
+	return mounts, scanner.Err()
+}
+
+// getQuotaEntries iterates over all ids that have a quota on the device
+// with Q_GETNEXTQUOTA, which needs Linux 4.6 or later.
+func getQuotaEntries(device string, typ int) ([]quotaEntry, error) {
+	dev, err := syscall.BytePtrFromString(device)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		entries []quotaEntry
+		id      uint32
+		buf     [nextDqblkSize]byte
+	)
+	for {
+		_, _, e := syscall.Syscall6(syscall.SYS_QUOTACTL, uintptr(uint32(qGetNextQuota)<<qcmdShift|uint32(typ)),
+			uintptr(unsafe.Pointer(dev)), uintptr(id), uintptr(unsafe.Pointer(&buf[0])), 0, 0)
+		if e == syscall.ENOENT {
+			return entries, nil
+		}
+		if e != 0 {
+			return nil, e
+		}
+		entry := parseNextDqblk(buf[:])
+		entries = append(entries, entry)
+		if entry.id == ^uint32(0) {
+			return entries, nil
+		}
+		id = entry.id + 1
+	}
+}
+
+func parseNextDqblk(b []byte) quotaEntry {
+	return quotaEntry{
+		blockHard: nativeEndian.Uint64(b[0:]),
+		blockSoft: nativeEndian.Uint64(b[8:]),
+		space:     nativeEndian.Uint64(b[16:]),
+		inodeHard: nativeEndian.Uint64(b[24:]),
+		inodeSoft: nativeEndian.Uint64(b[32:]),
+		inodes:    nativeEndian.Uint64(b[40:]),
+		// Followed by the grace times and the valid flags.
+		id: nativeEndian.Uint32(b[68:]),
+	}
+}
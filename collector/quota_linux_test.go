@@ -0,0 +1,54 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestQuotaMounts(t *testing.T) {
+	file, err := os.Open("fixtures/proc/mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mounts, err := parseQuotaMounts(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []quotaMount{
+		{device: "/dev/sdb1", mountPoint: "/srv", types: []int{quotaUser, quotaProject}},
+		{device: "/dev/sdc1", mountPoint: "/home", types: []int{quotaUser, quotaGroup}},
+	}
+	if !reflect.DeepEqual(want, mounts) {
+		t.Errorf("want %+v, got %+v", want, mounts)
+	}
+}
+
+func TestParseNextDqblk(t *testing.T) {
+	b := make([]byte, nextDqblkSize)
+	nativeEndian.PutUint64(b[0:], 2048)
+	nativeEndian.PutUint64(b[8:], 1024)
+	nativeEndian.PutUint64(b[16:], 512000)
+	nativeEndian.PutUint64(b[40:], 12)
+	nativeEndian.PutUint32(b[68:], 1000)
+
+	want := quotaEntry{id: 1000, blockHard: 2048, blockSoft: 1024, space: 512000, inodes: 12}
+	if got := parseNextDqblk(b); want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
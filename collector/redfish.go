@@ -0,0 +1,249 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noredfish
+
+package collector
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const redfishSubsystem = "redfish"
+
+var (
+	redfishURL          = flag.String("collector.redfish.url", "https://localhost", "URL of the Redfish API of the local BMC.")
+	redfishUsername     = flag.String("collector.redfish.username", "", "User name to log in to the Redfish API with.")
+	redfishPasswordFile = flag.String("collector.redfish.password-file", "", "File with the password to log in to the Redfish API with.")
+	redfishInsecure     = flag.Bool("collector.redfish.insecure-skip-verify", false, "Skip verifying the TLS certificate of the BMC, which is often self-signed.")
+	redfishTimeout      = flag.Duration("collector.redfish.timeout", 5*time.Second, "Timeout of requests to the Redfish API.")
+)
+
+var redfishHealthStates = []string{"OK", "Warning", "Critical"}
+
+type redfishLink struct {
+	ID string `json:"@odata.id"`
+}
+
+type redfishStatus struct {
+	State        string
+	Health       string
+	HealthRollup string
+}
+
+type redfishChassis struct {
+	ID      string `json:"Id"`
+	Status  redfishStatus
+	Power   redfishLink
+	Thermal redfishLink
+}
+
+type redfishPower struct {
+	PowerControl []struct {
+		Name               string
+		MemberID           string `json:"MemberId"`
+		PowerConsumedWatts *float64
+	}
+	PowerSupplies []struct {
+		Name     string
+		MemberID string `json:"MemberId"`
+		Status   redfishStatus
+	}
+}
+
+type redfishThermal struct {
+	Fans []struct {
+		Name         string
+		FanName      string
+		MemberID     string `json:"MemberId"`
+		Reading      *float64
+		ReadingUnits string
+		Status       redfishStatus
+	}
+}
+
+type redfishCollector struct {
+	url                string
+	username, password string
+	client             *http.Client
+
+	chassisHealth     typedDesc
+	powerConsumed     typedDesc
+	powerSupplyHealth typedDesc
+	fanReading        typedDesc
+	fanHealth         typedDesc
+}
+
+func init() {
+	Factories["redfish"] = NewRedfishCollector
+}
+
+// NewRedfishCollector returns a new Collector exposing the health, power
+// draw, fans and power supplies of the chassis reported by the Redfish API
+// of the local BMC.
+func NewRedfishCollector() (Collector, error) {
+	c := &redfishCollector{
+		url:      strings.TrimSuffix(*redfishURL, "/"),
+		username: *redfishUsername,
+		client: &http.Client{
+			Timeout: *redfishTimeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: *redfishInsecure},
+			},
+		},
+		chassisHealth: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, redfishSubsystem, "chassis_health"),
+			"Health rollup of the chassis and its components, 1 for the current health.",
+			[]string{"chassis", "health"}, nil,
+		), prometheus.GaugeValue},
+		powerConsumed: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, redfishSubsystem, "power_consumed_watts"),
+			"Power drawn by the chassis.",
+			[]string{"chassis", "name"}, nil,
+		), prometheus.GaugeValue},
+		powerSupplyHealth: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, redfishSubsystem, "power_supply_health"),
+			"Health of the power supply, 1 for the current health.",
+			[]string{"chassis", "power_supply", "health"}, nil,
+		), prometheus.GaugeValue},
+		fanReading: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, redfishSubsystem, "fan_reading"),
+			"Speed of the fan in the unit given by the unit label.",
+			[]string{"chassis", "fan", "unit"}, nil,
+		), prometheus.GaugeValue},
+		fanHealth: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, redfishSubsystem, "fan_health"),
+			"Health of the fan, 1 for the current health.",
+			[]string{"chassis", "fan", "health"}, nil,
+		), prometheus.GaugeValue},
+	}
+	if *redfishPasswordFile != "" {
+		password, err := ioutil.ReadFile(*redfishPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read Redfish password: %s", err)
+		}
+		c.password = strings.TrimSpace(string(password))
+	}
+	return c, nil
+}
+
+func (c *redfishCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	var chassisList struct {
+		Members []redfishLink
+	}
+	if err := c.get("/redfish/v1/Chassis", &chassisList); err != nil {
+		return err
+	}
+	for _, link := range chassisList.Members {
+		var chassis redfishChassis
+		if err := c.get(link.ID, &chassis); err != nil {
+			return err
+		}
+		health := chassis.Status.HealthRollup
+		if health == "" {
+			health = chassis.Status.Health
+		}
+		c.health(ch, c.chassisHealth, health, chassis.ID)
+
+		if chassis.Power.ID != "" {
+			var power redfishPower
+			if err := c.get(chassis.Power.ID, &power); err != nil {
+				return err
+			}
+			for _, p := range power.PowerControl {
+				if p.PowerConsumedWatts != nil {
+					ch <- c.powerConsumed.mustNewConstMetric(*p.PowerConsumedWatts, chassis.ID, redfishName(p.Name, p.MemberID))
+				}
+			}
+			for _, p := range power.PowerSupplies {
+				if p.Status.State != "Absent" {
+					c.health(ch, c.powerSupplyHealth, p.Status.Health, chassis.ID, redfishName(p.Name, p.MemberID))
+				}
+			}
+		}
+
+		if chassis.Thermal.ID != "" {
+			var thermal redfishThermal
+			if err := c.get(chassis.Thermal.ID, &thermal); err != nil {
+				return err
+			}
+			for _, f := range thermal.Fans {
+				if f.Status.State == "Absent" {
+					continue
+				}
+				name := redfishName(f.Name, redfishName(f.FanName, f.MemberID))
+				if f.Reading != nil {
+					ch <- c.fanReading.mustNewConstMetric(*f.Reading, chassis.ID, name, f.ReadingUnits)
+				}
+				c.health(ch, c.fanHealth, f.Status.Health, chassis.ID, name)
+			}
+		}
+	}
+	return nil
+}
+
+// health exposes the health as one series per health state, unless the
+// BMC doesn't report it.
+func (c *redfishCollector) health(ch chan<- prometheus.Metric, desc typedDesc, health string, labels ...string) {
+	if health == "" {
+		return
+	}
+	for _, s := range redfishHealthStates {
+		v := 0.0
+		if s == health {
+			v = 1
+		}
+		ch <- desc.mustNewConstMetric(v, append(labels, s)...)
+	}
+}
+
+func (c *redfishCollector) get(path string, v interface{}) error {
+	req, err := http.NewRequest("GET", c.url+path, nil)
+	if err != nil {
+		return err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't query Redfish API: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("couldn't parse %s: %s", path, err)
+	}
+	return nil
+}
+
+// redfishName returns the name of a component, or its member ID if the BMC
+// doesn't name it.
+func redfishName(name, memberID string) string {
+	if name != "" {
+		return name
+	}
+	return memberID
+}
@@ -0,0 +1,169 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nortc
+
+package collector
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	rtcSubsystem = "rtc"
+
+	// RTC_VL_READ and its flags from include/uapi/linux/rtc.h.
+	rtcVLRead        = 0x80047013
+	rtcVLDataInvalid = 0x1
+	rtcVLBackupLow   = 0x2
+)
+
+var (
+	rtcInterval = flag.Duration("collector.rtc.interval", time.Hour, "Interval to compare the RTCs to the system clock in.")
+	rtcDevPath  = flag.String("collector.rtc.dev-path", "/dev", "Directory of the RTC devices, used to read their voltage low flags.")
+)
+
+type rtcState struct {
+	offset float64
+	// Nil if the driver doesn't report voltage low flags.
+	batteryLow, invalid *float64
+}
+
+type rtcCollector struct {
+	offset, batteryLow, invalid typedDesc
+
+	mtx    sync.Mutex
+	last   time.Time
+	states map[string]rtcState
+}
+
+func init() {
+	Factories["rtc"] = NewRTCCollector
+}
+
+// NewRTCCollector returns a new Collector exposing the offset of the
+// hardware clocks to the system clock and their battery state.
+func NewRTCCollector() (Collector, error) {
+	labels := []string{"rtc"}
+	return &rtcCollector{
+		offset: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "offset_seconds"),
+			"Time of the RTC minus system time at the last comparison, with a resolution of one second.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		batteryLow: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "battery_low"),
+			"Whether the RTC reports its backup battery as low.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		invalid: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, rtcSubsystem, "time_invalid"),
+			"Whether the RTC reports its time as invalid after losing power.",
+			labels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *rtcCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.states == nil || time.Since(c.last) >= *rtcInterval {
+		states, err := c.compare()
+		if err != nil {
+			return err
+		}
+		c.states, c.last = states, time.Now()
+	}
+	for rtc, s := range c.states {
+		ch <- c.offset.mustNewConstMetric(s.offset, rtc)
+		if s.batteryLow != nil {
+			ch <- c.batteryLow.mustNewConstMetric(*s.batteryLow, rtc)
+		}
+		if s.invalid != nil {
+			ch <- c.invalid.mustNewConstMetric(*s.invalid, rtc)
+		}
+	}
+	return nil
+}
+
+func (c *rtcCollector) compare() (map[string]rtcState, error) {
+	root := sysFilePath("class/rtc")
+	rtcs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return map[string]rtcState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	states := map[string]rtcState{}
+	for _, rtc := range rtcs {
+		offset, err := readRTCOffset(path.Join(root, rtc.Name()), time.Now())
+		if err != nil {
+			return nil, err
+		}
+		s := rtcState{offset: offset}
+		flags, err := readRTCVoltageLow(path.Join(*rtcDevPath, rtc.Name()))
+		if err != nil {
+			// Not supported by the driver or the device is in use.
+			log.Debugf("Couldn't read voltage low flags of %s: %s", rtc.Name(), err)
+		} else {
+			low, invalid := 0.0, 0.0
+			if flags&rtcVLBackupLow != 0 {
+				low = 1
+			}
+			if flags&rtcVLDataInvalid != 0 {
+				invalid = 1
+			}
+			s.batteryLow, s.invalid = &low, &invalid
+		}
+		states[rtc.Name()] = s
+	}
+	return states, nil
+}
+
+// readRTCOffset returns the difference of the RTC to now. since_epoch
+// interprets the RTC as UTC, so RTCs kept in local time are off by the
+// timezone.
+func readRTCOffset(rtcPath string, now time.Time) (float64, error) {
+	epoch, err := readUintFromFile(path.Join(rtcPath, "since_epoch"))
+	if err != nil {
+		return 0, err
+	}
+	return float64(epoch) - float64(now.UnixNano())/1e9, nil
+}
+
+func readRTCVoltageLow(device string) (uint32, error) {
+	file, err := os.Open(device)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	var flags uint32
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), rtcVLRead, uintptr(unsafe.Pointer(&flags)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return flags, nil
+}
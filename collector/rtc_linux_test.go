@@ -0,0 +1,30 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRTCOffset(t *testing.T) {
+	now := time.Unix(1508227230, 500000000)
+	offset, err := readRTCOffset("fixtures/sys/class/rtc/rtc0", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := -30.5, offset; want != got {
+		t.Errorf("want offset %f, got %f", want, got)
+	}
+}
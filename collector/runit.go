@@ -17,6 +17,7 @@ package collector
 
 import (
 	"flag"
+	"fmt"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/log"
@@ -29,7 +30,7 @@ var runitServiceDir = flag.String(
 	"Path to runit service directory.")
 
 type runitCollector struct {
-	state, stateDesired, stateNormal, stateTimestamp typedDesc
+	state, stateDesired, stateNormal, stateTimestamp, stateDuration typedDesc
 }
 
 func init() {
@@ -64,13 +65,18 @@ func NewRunitCollector() (Collector, error) {
 			"Unix timestamp of the last runit service state change.",
 			labelNames, constLabels,
 		), prometheus.GaugeValue},
+		stateDuration: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "state_duration_seconds"),
+			"Seconds the runit service has been in its current state.",
+			labelNames, constLabels,
+		), prometheus.GaugeValue},
 	}, nil
 }
 
 func (c *runitCollector) Update(ch chan<- prometheus.Metric) error {
 	services, err := runit.GetServices(*runitServiceDir)
 	if err != nil {
-		return err
+		return fmt.Errorf("couldn't get runit services: %s", err)
 	}
 
 	for _, service := range services {
@@ -84,6 +90,7 @@ func (c *runitCollector) Update(ch chan<- prometheus.Metric) error {
 		ch <- c.state.mustNewConstMetric(float64(status.State), service.Name)
 		ch <- c.stateDesired.mustNewConstMetric(float64(status.Want), service.Name)
 		ch <- c.stateTimestamp.mustNewConstMetric(float64(status.Timestamp.Unix()), service.Name)
+		ch <- c.stateDuration.mustNewConstMetric(float64(status.Duration), service.Name)
 		if status.NormallyUp {
 			ch <- c.stateNormal.mustNewConstMetric(1, service.Name)
 		} else {
@@ -0,0 +1,116 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noschedstat
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const schedstatSubsystem = "schedstat"
+
+// schedstat holds the scheduler statistics of a CPU in /proc/schedstat.
+type schedstat struct {
+	cpu string
+	// runningNanoseconds and waitingNanoseconds are the time tasks spent
+	// running on the CPU and waiting in its runqueue.
+	runningNanoseconds uint64
+	waitingNanoseconds uint64
+	timeslices         uint64
+}
+
+type schedstatCollector struct {
+	running    typedDesc
+	waiting    typedDesc
+	timeslices typedDesc
+}
+
+func init() {
+	Factories["schedstat"] = NewSchedstatCollector
+}
+
+// NewSchedstatCollector returns a new Collector exposing the time tasks
+// ran and waited on each CPU.
+func NewSchedstatCollector() (Collector, error) {
+	desc := func(name, help string) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, schedstatSubsystem, name),
+			help, []string{"cpu"}, nil,
+		), prometheus.CounterValue}
+	}
+	return &schedstatCollector{
+		running:    desc("running_seconds_total", "Time tasks spent running on the CPU."),
+		waiting:    desc("waiting_seconds_total", "Time tasks spent waiting in the runqueue of the CPU."),
+		timeslices: desc("timeslices_total", "Timeslices run on the CPU."),
+	}, nil
+}
+
+func (c *schedstatCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("schedstat"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stats, err := parseSchedstat(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse schedstat: %s", err)
+	}
+	for _, s := range stats {
+		ch <- c.running.mustNewConstMetric(float64(s.runningNanoseconds)/1e9, s.cpu)
+		ch <- c.waiting.mustNewConstMetric(float64(s.waitingNanoseconds)/1e9, s.cpu)
+		ch <- c.timeslices.mustNewConstMetric(float64(s.timeslices), s.cpu)
+	}
+	return nil
+}
+
+// parseSchedstat parses the cpu lines of /proc/schedstat. Their last three
+// fields have been the running time, the waiting time and the timeslices
+// since version 10, the domain lines in between are skipped.
+func parseSchedstat(r io.Reader) ([]schedstat, error) {
+	var stats []schedstat
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("invalid line %q", scanner.Text())
+		}
+		values := make([]uint64, 3)
+		for i, f := range fields[len(fields)-3:] {
+			v, err := strconv.ParseUint(f, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		stats = append(stats, schedstat{
+			cpu:                fields[0],
+			runningNanoseconds: values[0],
+			waitingNanoseconds: values[1],
+			timeslices:         values[2],
+		})
+	}
+	return stats, scanner.Err()
+}
@@ -0,0 +1,34 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSchedstat(t *testing.T) {
+	stats, err := parseSchedstat(strings.NewReader("version 15\ntimestamp 1\ncpu3 1 0 2 3 4 5 2000000000 500 7\ndomain0 3 1 2\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := schedstat{cpu: "cpu3", runningNanoseconds: 2e9, waitingNanoseconds: 500, timeslices: 7}
+	if len(stats) != 1 || stats[0] != want {
+		t.Errorf("want %+v, got %+v", want, stats)
+	}
+
+	if _, err := parseSchedstat(strings.NewReader("cpu0 1 2 3\n")); err == nil {
+		t.Error("expected error for short line")
+	}
+}
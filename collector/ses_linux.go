@@ -0,0 +1,233 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noses
+
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const sesSubsystem = "ses"
+
+var (
+	sesCommand = flag.String("collector.ses.command", "sg_ses", "Command to run sg_ses, used for the temperature sensors and power supplies the kernel doesn't expose.")
+	sesBSGPath = flag.String("collector.ses.bsg-path", "/dev/bsg", "Directory of the SCSI generic block devices of the enclosures.")
+)
+
+type sesSlot struct {
+	name string
+	// 1 if the slot is in the state, 0 otherwise.
+	ok, occupied, fault, locate float64
+}
+
+// sesElement is an element of the enclosure status page of sg_ses.
+type sesElement struct {
+	typ, index  string
+	status      string
+	temperature float64
+	hasTemp     bool
+}
+
+type sesCollector struct {
+	cli                        string
+	slotOccupied, slotOK       typedDesc
+	slotFault, slotLocate      typedDesc
+	temperature, powerSupplyOK typedDesc
+}
+
+func init() {
+	Factories["ses"] = NewSESCollector
+}
+
+// NewSESCollector returns a new Collector exposing the slots, temperatures
+// and power supplies of SCSI enclosures.
+func NewSESCollector() (Collector, error) {
+	slotLabels := []string{"enclosure", "slot"}
+	elementLabels := []string{"enclosure", "element"}
+	return &sesCollector{
+		cli: *sesCommand,
+		slotOccupied: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sesSubsystem, "slot_occupied"),
+			"Whether a device is attached to the enclosure slot.",
+			slotLabels, nil,
+		), prometheus.GaugeValue},
+		slotOK: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sesSubsystem, "slot_ok"),
+			"Whether the enclosure reports the slot status as OK.",
+			slotLabels, nil,
+		), prometheus.GaugeValue},
+		slotFault: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sesSubsystem, "slot_fault_led"),
+			"Whether the fault LED of the enclosure slot is on.",
+			slotLabels, nil,
+		), prometheus.GaugeValue},
+		slotLocate: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sesSubsystem, "slot_locate_led"),
+			"Whether the locate LED of the enclosure slot is on.",
+			slotLabels, nil,
+		), prometheus.GaugeValue},
+		temperature: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sesSubsystem, "temperature_celsius"),
+			"Temperature reported by the enclosure sensor.",
+			elementLabels, nil,
+		), prometheus.GaugeValue},
+		powerSupplyOK: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sesSubsystem, "power_supply_ok"),
+			"Whether the enclosure reports the power supply status as OK.",
+			elementLabels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *sesCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	root := sysFilePath("class/enclosure")
+	enclosures, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		// ses not loaded.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, e := range enclosures {
+		slots, err := readSESSlots(path.Join(root, e.Name()))
+		if err != nil {
+			return fmt.Errorf("couldn't get slots of enclosure %s: %s", e.Name(), err)
+		}
+		for _, s := range slots {
+			ch <- c.slotOccupied.mustNewConstMetric(s.occupied, e.Name(), s.name)
+			ch <- c.slotOK.mustNewConstMetric(s.ok, e.Name(), s.name)
+			ch <- c.slotFault.mustNewConstMetric(s.fault, e.Name(), s.name)
+			ch <- c.slotLocate.mustNewConstMetric(s.locate, e.Name(), s.name)
+		}
+
+		// The enclosure is named after the H:C:T:L of its SCSI device.
+		out, err := exec.Command(c.cli, "--page=es", path.Join(*sesBSGPath, e.Name())).Output()
+		if err != nil {
+			return fmt.Errorf("couldn't get status page of enclosure %s: %s", e.Name(), err)
+		}
+		elements, err := parseSESStatus(bytes.NewReader(out))
+		if err != nil {
+			return fmt.Errorf("couldn't parse status page of enclosure %s: %s", e.Name(), err)
+		}
+		for _, el := range elements {
+			name := el.typ + " " + el.index
+			switch {
+			case el.typ == "Temperature sensor" && el.hasTemp:
+				ch <- c.temperature.mustNewConstMetric(el.temperature, e.Name(), name)
+			case el.typ == "Power supply":
+				ok := 0.0
+				if el.status == "OK" {
+					ok = 1.0
+				}
+				ch <- c.powerSupplyOK.mustNewConstMetric(ok, e.Name(), name)
+			}
+		}
+	}
+	return nil
+}
+
+// readSESSlots reads the components the ses driver registers with the
+// enclosure, which are its device slots.
+func readSESSlots(enclosurePath string) ([]sesSlot, error) {
+	dirs, err := ioutil.ReadDir(enclosurePath)
+	if err != nil {
+		return nil, err
+	}
+	var slots []sesSlot
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		slotPath := path.Join(enclosurePath, d.Name())
+		// Components are the subdirectories with a status attribute.
+		status, err := readStringFromFile(path.Join(slotPath, "status"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		s := sesSlot{name: d.Name()}
+		if status == "OK" {
+			s.ok = 1
+		}
+		if _, err := os.Stat(path.Join(slotPath, "device")); err == nil {
+			s.occupied = 1
+		}
+		fault, err := readUintFromFile(path.Join(slotPath, "fault"))
+		if err != nil {
+			return nil, err
+		}
+		s.fault = float64(fault)
+		locate, err := readUintFromFile(path.Join(slotPath, "locate"))
+		if err != nil {
+			return nil, err
+		}
+		s.locate = float64(locate)
+		slots = append(slots, s)
+	}
+	return slots, nil
+}
+
+// parseSESStatus parses the enclosure status page as printed by sg_ses,
+// skipping the overall elements.
+func parseSESStatus(r io.Reader) ([]sesElement, error) {
+	var (
+		elements []sesElement
+		typ      string
+		el       *sesElement
+		scanner  = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "Element type:"):
+			typ = strings.TrimSpace(strings.SplitN(strings.TrimPrefix(line, "Element type:"), ",", 2)[0])
+			el = nil
+		case strings.HasPrefix(line, "Overall descriptor:"):
+			el = nil
+		case strings.HasPrefix(line, "Element ") && strings.HasSuffix(line, "descriptor:"):
+			fields := strings.Fields(line)
+			elements = append(elements, sesElement{typ: typ, index: fields[1]})
+			el = &elements[len(elements)-1]
+		case el == nil:
+			continue
+		case strings.Contains(line, "status: "):
+			el.status = strings.TrimSpace(line[strings.Index(line, "status: ")+len("status: "):])
+		case strings.HasPrefix(line, "Temperature="):
+			v := strings.TrimSuffix(strings.TrimPrefix(line, "Temperature="), " C")
+			t, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				// Reserved values are printed as text.
+				continue
+			}
+			el.temperature, el.hasTemp = t, true
+		}
+	}
+	return elements, scanner.Err()
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSESSlots(t *testing.T) {
+	slots, err := readSESSlots("fixtures/sys/class/enclosure/0:0:8:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sesSlot{
+		{name: "Slot00", ok: 1, occupied: 1},
+		{name: "Slot01", fault: 1, locate: 1},
+	}
+	if want, got := len(want), len(slots); want != got {
+		t.Fatalf("want %d slots, got %d", want, got)
+	}
+	for i, w := range want {
+		if got := slots[i]; w != got {
+			t.Errorf("want %+v, got %+v", w, got)
+		}
+	}
+}
+
+func TestSESStatus(t *testing.T) {
+	file, err := os.Open("fixtures/sg_ses_es.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	elements, err := parseSESStatus(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []sesElement{
+		{typ: "Array device slot", index: "0", status: "OK"},
+		{typ: "Power supply", index: "0", status: "OK"},
+		{typ: "Power supply", index: "1", status: "Critical"},
+		{typ: "Temperature sensor", index: "0", status: "OK", temperature: 31, hasTemp: true},
+		{typ: "Temperature sensor", index: "1", status: "OK", temperature: 38, hasTemp: true},
+	}
+	if want, got := len(want), len(elements); want != got {
+		t.Fatalf("want %d elements, got %d", want, got)
+	}
+	for i, w := range want {
+		if got := elements[i]; w != got {
+			t.Errorf("want %+v, got %+v", w, got)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// On linux/386 socket calls are multiplexed through socketcall(2).
+const sysGetsockopt = 15
+
+// getsockoptBuffer calls getsockopt with buf as both input and output
+// argument and returns the number of bytes written by the kernel.
+func getsockoptBuffer(fd, level, opt int, buf []byte) (int, error) {
+	l := uint32(len(buf))
+	args := [5]uintptr{uintptr(fd), uintptr(level), uintptr(opt),
+		uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&l))}
+	_, _, e := syscall.Syscall(syscall.SYS_SOCKETCALL, sysGetsockopt, uintptr(unsafe.Pointer(&args)), 0)
+	if e != 0 {
+		return 0, e
+	}
+	return int(l), nil
+}
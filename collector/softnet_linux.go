@@ -0,0 +1,128 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosoftnet
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const softnetSubsystem = "softnet"
+
+// softnetStats are the counters of a CPU in /proc/net/softnet_stat.
+type softnetStats struct {
+	cpu          int
+	processed    uint64
+	dropped      uint64
+	timeSqueezed uint64
+	receivedRPS  uint64
+	flowLimit    uint64
+}
+
+type softnetCollector struct {
+	processed    typedDesc
+	dropped      typedDesc
+	timeSqueezed typedDesc
+	receivedRPS  typedDesc
+	flowLimit    typedDesc
+}
+
+func init() {
+	Factories["softnet"] = NewSoftnetCollector
+}
+
+// NewSoftnetCollector returns a new Collector exposing the per CPU packet
+// processing statistics of the kernel network stack.
+func NewSoftnetCollector() (Collector, error) {
+	desc := func(name, help string) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, softnetSubsystem, name),
+			help, []string{"cpu"}, nil,
+		), prometheus.CounterValue}
+	}
+	return &softnetCollector{
+		processed:    desc("processed_total", "Packets processed by the CPU."),
+		dropped:      desc("dropped_total", "Packets dropped because the input queue of the CPU was full."),
+		timeSqueezed: desc("times_squeezed_total", "Times the CPU ran out of budget or time with packets left to process."),
+		receivedRPS:  desc("received_rps_total", "Times the CPU was woken up by receive packet steering."),
+		flowLimit:    desc("flow_limit_count_total", "Times the flow limit was reached on the CPU."),
+	}, nil
+}
+
+func (c *softnetCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("net/softnet_stat"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stats, err := parseSoftnetStats(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse softnet_stat: %s", err)
+	}
+	for _, s := range stats {
+		cpu := fmt.Sprintf("cpu%d", s.cpu)
+		ch <- c.processed.mustNewConstMetric(float64(s.processed), cpu)
+		ch <- c.dropped.mustNewConstMetric(float64(s.dropped), cpu)
+		ch <- c.timeSqueezed.mustNewConstMetric(float64(s.timeSqueezed), cpu)
+		ch <- c.receivedRPS.mustNewConstMetric(float64(s.receivedRPS), cpu)
+		ch <- c.flowLimit.mustNewConstMetric(float64(s.flowLimit), cpu)
+	}
+	return nil
+}
+
+// parseSoftnetStats parses /proc/net/softnet_stat, which has a line of hex
+// counters per online CPU. Kernels since 5.10 give the CPU in the 13th
+// field, older ones skip offline CPUs so the line number may be off.
+func parseSoftnetStats(r io.Reader) ([]softnetStats, error) {
+	var stats []softnetStats
+	scanner := bufio.NewScanner(r)
+	for line := 0; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 9 {
+			return nil, fmt.Errorf("invalid line %q", scanner.Text())
+		}
+		values := make([]uint64, len(fields))
+		for i, f := range fields {
+			v, err := strconv.ParseUint(f, 16, 64)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		s := softnetStats{
+			cpu:          line,
+			processed:    values[0],
+			dropped:      values[1],
+			timeSqueezed: values[2],
+		}
+		if len(values) >= 11 {
+			s.receivedRPS = values[9]
+			s.flowLimit = values[10]
+		}
+		if len(values) >= 13 {
+			s.cpu = int(values[12])
+		}
+		stats = append(stats, s)
+	}
+	return stats, scanner.Err()
+}
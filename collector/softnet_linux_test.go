@@ -0,0 +1,45 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSoftnetStatsOldKernel(t *testing.T) {
+	// Kernels before 5.10 have neither the backlog length nor the CPU.
+	stats, err := parseSoftnetStats(strings.NewReader(
+		"00000010 00000001 00000002 00000000 00000000 00000000 00000000 00000000 00000000 00000005 00000006\n" +
+			"00000020 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []softnetStats{
+		{cpu: 0, processed: 16, dropped: 1, timeSqueezed: 2, receivedRPS: 5, flowLimit: 6},
+		{cpu: 1, processed: 32},
+	}
+	if len(stats) != len(want) {
+		t.Fatalf("want %d CPUs, got %d", len(want), len(stats))
+	}
+	for i := range want {
+		if want[i] != stats[i] {
+			t.Errorf("want %+v, got %+v", want[i], stats[i])
+		}
+	}
+
+	if _, err := parseSoftnetStats(strings.NewReader("0000001 00000002\n")); err == nil {
+		t.Error("expected error for short line")
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nosriov
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const sriovSubsystem = "sriov"
+
+var sriovIPCommand = flag.String("collector.sriov.ip-command", "ip", "Command to run ip of iproute2 to read the MAC, VLAN and traffic of virtual functions, disabled if empty.")
+
+// sriovFunction is a physical function, a network interface of a SR-IOV
+// capable device.
+type sriovFunction struct {
+	iface              string
+	maxVFs, configured uint64
+	vfs                []sriovVF
+}
+
+// sriovVF is a virtual function in sysfs, driver is empty if it isn't
+// bound to one.
+type sriovVF struct {
+	index      string
+	pciAddress string
+	driver     string
+}
+
+// sriovVFLink holds the settings and counters of a virtual function in
+// the output of ip, stats are only exported by some drivers.
+type sriovVFLink struct {
+	VF int `json:"vf"`
+	// Address is called mac before iproute2 5.0.
+	Address  string `json:"address"`
+	MAC      string `json:"mac"`
+	VLAN     int    `json:"vlan"`
+	VLANList []struct {
+		VLAN int `json:"vlan"`
+	} `json:"vlan_list"`
+	Stats *struct {
+		RX struct {
+			Bytes     float64 `json:"bytes"`
+			Packets   float64 `json:"packets"`
+			Multicast float64 `json:"multicast"`
+			Broadcast float64 `json:"broadcast"`
+			Dropped   float64 `json:"dropped"`
+		} `json:"rx"`
+		TX struct {
+			Bytes   float64 `json:"bytes"`
+			Packets float64 `json:"packets"`
+			Dropped float64 `json:"dropped"`
+		} `json:"tx"`
+	} `json:"stats"`
+}
+
+type sriovCollector struct {
+	ip                               string
+	maxVFs, configuredVFs, activeVFs typedDesc
+	vfInfo                           typedDesc
+	rxBytes, rxPackets, rxDropped    typedDesc
+	rxMulticast, rxBroadcast         typedDesc
+	txBytes, txPackets, txDropped    typedDesc
+}
+
+func init() {
+	Factories["sriov"] = NewSRIOVCollector
+}
+
+// NewSRIOVCollector returns a new Collector exposing the virtual functions
+// of SR-IOV network devices.
+func NewSRIOVCollector() (Collector, error) {
+	pfDesc := func(name, help string) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sriovSubsystem, name),
+			help, []string{"device"}, nil,
+		), prometheus.GaugeValue}
+	}
+	vfDesc := func(name, help string) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sriovSubsystem, name),
+			help, []string{"device", "vf"}, nil,
+		), prometheus.CounterValue}
+	}
+	return &sriovCollector{
+		ip:            *sriovIPCommand,
+		maxVFs:        pfDesc("max_vfs", "Virtual functions the device supports."),
+		configuredVFs: pfDesc("configured_vfs", "Virtual functions enabled on the device."),
+		activeVFs:     pfDesc("active_vfs", "Virtual functions bound to a driver, e.g. vfio-pci to pass them to guests."),
+		vfInfo: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, sriovSubsystem, "vf_info"),
+			"PCI address, bound driver, MAC address and VLAN of the virtual function, value is always 1.",
+			[]string{"device", "vf", "pci_address", "driver", "mac", "vlan"}, nil,
+		), prometheus.GaugeValue},
+		rxBytes:     vfDesc("vf_receive_bytes_total", "Bytes received by the virtual function."),
+		rxPackets:   vfDesc("vf_receive_packets_total", "Packets received by the virtual function."),
+		rxDropped:   vfDesc("vf_receive_dropped_total", "Received packets dropped by the virtual function."),
+		rxMulticast: vfDesc("vf_receive_multicast_total", "Multicast packets received by the virtual function."),
+		rxBroadcast: vfDesc("vf_receive_broadcast_total", "Broadcast packets received by the virtual function."),
+		txBytes:     vfDesc("vf_transmit_bytes_total", "Bytes transmitted by the virtual function."),
+		txPackets:   vfDesc("vf_transmit_packets_total", "Packets transmitted by the virtual function."),
+		txDropped:   vfDesc("vf_transmit_dropped_total", "Transmitted packets dropped by the virtual function."),
+	}, nil
+}
+
+func (c *sriovCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	functions, err := readSRIOVFunctions(sysFilePath("class/net"))
+	if err != nil {
+		return fmt.Errorf("couldn't read SR-IOV devices: %s", err)
+	}
+	for _, pf := range functions {
+		active := 0
+		for _, vf := range pf.vfs {
+			if vf.driver != "" {
+				active++
+			}
+		}
+		ch <- c.maxVFs.mustNewConstMetric(float64(pf.maxVFs), pf.iface)
+		ch <- c.configuredVFs.mustNewConstMetric(float64(pf.configured), pf.iface)
+		ch <- c.activeVFs.mustNewConstMetric(float64(active), pf.iface)
+		if len(pf.vfs) == 0 {
+			continue
+		}
+
+		links := map[string]sriovVFLink{}
+		if c.ip != "" {
+			if links, err = c.showVFLinks(pf.iface); err != nil {
+				return fmt.Errorf("couldn't get virtual functions of %s: %s", pf.iface, err)
+			}
+		}
+		for _, vf := range pf.vfs {
+			link := links[vf.index]
+			mac, vlan := link.Address, ""
+			if mac == "" {
+				mac = link.MAC
+			}
+			if len(link.VLANList) > 0 {
+				vlan = strconv.Itoa(link.VLANList[0].VLAN)
+			} else if link.VLAN != 0 {
+				vlan = strconv.Itoa(link.VLAN)
+			}
+			ch <- c.vfInfo.mustNewConstMetric(1, pf.iface, vf.index, vf.pciAddress, vf.driver, mac, vlan)
+
+			s := link.Stats
+			if s == nil {
+				continue
+			}
+			ch <- c.rxBytes.mustNewConstMetric(s.RX.Bytes, pf.iface, vf.index)
+			ch <- c.rxPackets.mustNewConstMetric(s.RX.Packets, pf.iface, vf.index)
+			ch <- c.rxDropped.mustNewConstMetric(s.RX.Dropped, pf.iface, vf.index)
+			ch <- c.rxMulticast.mustNewConstMetric(s.RX.Multicast, pf.iface, vf.index)
+			ch <- c.rxBroadcast.mustNewConstMetric(s.RX.Broadcast, pf.iface, vf.index)
+			ch <- c.txBytes.mustNewConstMetric(s.TX.Bytes, pf.iface, vf.index)
+			ch <- c.txPackets.mustNewConstMetric(s.TX.Packets, pf.iface, vf.index)
+			ch <- c.txDropped.mustNewConstMetric(s.TX.Dropped, pf.iface, vf.index)
+		}
+	}
+	return nil
+}
+
+func (c *sriovCollector) showVFLinks(iface string) (map[string]sriovVFLink, error) {
+	cmd := exec.Command(c.ip, "-j", "-d", "-s", "link", "show", "dev", iface)
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	links, err := parseSRIOVVFLinks(pipe)
+	if err != nil {
+		// Stop ip, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// parseSRIOVVFLinks parses the vfinfo_list of the interface in the output of
+// `ip -j -d -s link show dev <interface>` by virtual function index.
+func parseSRIOVVFLinks(r io.Reader) (map[string]sriovVFLink, error) {
+	var doc []struct {
+		VFInfoList []sriovVFLink `json:"vfinfo_list"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	links := map[string]sriovVFLink{}
+	for _, iface := range doc {
+		for _, vf := range iface.VFInfoList {
+			links[strconv.Itoa(vf.VF)] = vf
+		}
+	}
+	return links, nil
+}
+
+// readSRIOVFunctions reads the network interfaces below root whose device
+// supports SR-IOV and the virtual functions enabled on them.
+func readSRIOVFunctions(root string) ([]sriovFunction, error) {
+	files, err := filepath.Glob(path.Join(root, "*", "device", "sriov_totalvfs"))
+	if err != nil {
+		return nil, err
+	}
+	var functions []sriovFunction
+	for _, file := range files {
+		devPath := path.Dir(file)
+		pf := sriovFunction{iface: path.Base(path.Dir(devPath))}
+		if pf.maxVFs, err = readUintFromFile(file); err != nil {
+			return nil, err
+		}
+		if pf.configured, err = readUintFromFile(path.Join(devPath, "sriov_numvfs")); err != nil {
+			return nil, err
+		}
+
+		links, err := filepath.Glob(path.Join(devPath, "virtfn[0-9]*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			target, err := os.Readlink(link)
+			if err != nil {
+				return nil, err
+			}
+			vf := sriovVF{
+				index:      strings.TrimPrefix(path.Base(link), "virtfn"),
+				pciAddress: path.Base(target),
+			}
+			if driver, err := os.Readlink(path.Join(link, "driver")); err == nil {
+				vf.driver = path.Base(driver)
+			}
+			pf.vfs = append(pf.vfs, vf)
+		}
+		functions = append(functions, pf)
+	}
+	return functions, nil
+}
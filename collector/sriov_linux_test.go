@@ -0,0 +1,38 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSRIOVVFLinksOldIPRoute(t *testing.T) {
+	// iproute2 before 5.0 calls the address mac and has a single vlan.
+	links, err := parseSRIOVVFLinks(strings.NewReader(`[{"ifname":"eth0","vfinfo_list":[{"vf":3,"mac":"52:54:00:6b:a1:04","vlan":42}]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, ok := links["3"]
+	if !ok {
+		t.Fatalf("want vf 3, got %v", links)
+	}
+	if link.MAC != "52:54:00:6b:a1:04" || link.VLAN != 42 || link.Stats != nil {
+		t.Errorf("unexpected link %+v", link)
+	}
+
+	if _, err := parseSRIOVVFLinks(strings.NewReader("Device \"eth9\" does not exist.")); err == nil {
+		t.Error("expected error for invalid output")
+	}
+}
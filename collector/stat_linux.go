@@ -18,10 +18,12 @@ package collector
 import (
 	"bufio"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
 )
 
 const (
@@ -36,6 +38,7 @@ type statCollector struct {
 	btime        *prometheus.Desc
 	procsRunning *prometheus.Desc
 	procsBlocked *prometheus.Desc
+	cpuTopology  *prometheus.Desc
 }
 
 func init() {
@@ -81,6 +84,11 @@ func NewStatCollector() (Collector, error) {
 			"Number of processes blocked waiting for I/O to complete.",
 			nil, nil,
 		),
+		cpuTopology: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "cpu", "topology_info"),
+			"Package, core and thread of the cpus, value is always 1.",
+			[]string{"cpu", "package", "core", "thread"}, nil,
+		),
 	}, nil
 }
 
@@ -105,8 +113,9 @@ func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
 				break
 			}
 			// Only some of these may be present, depending on kernel version.
-			cpuFields := []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal", "guest"}
-			// OpenVZ guests lack the "guest" CPU field, which needs to be ignored.
+			cpuFields := []string{"user", "nice", "system", "idle", "iowait", "irq", "softirq", "steal", "guest", "guest_nice"}
+			// OpenVZ guests lack the "guest" CPU field and kernels before 2.6.33
+			// the "guest_nice" field, which need to be ignored.
 			expectedFieldNum := len(cpuFields) + 1
 			if expectedFieldNum > len(parts) {
 				expectedFieldNum = len(parts)
@@ -159,5 +168,63 @@ func (c *statCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			ch <- prometheus.MustNewConstMetric(c.procsBlocked, prometheus.GaugeValue, value)
 		}
 	}
-	return err
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return c.updateTopology(ch)
+}
+
+// updateTopology exposes the package, core and thread of each cpu, so the
+// per cpu metrics can be aggregated by socket and core.
+func (c *statCollector) updateTopology(ch chan<- prometheus.Metric) error {
+	dirs, err := filepath.Glob(sysFilePath("devices/system/cpu/cpu[0-9]*"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		topology := filepath.Join(dir, "topology")
+		if _, err := os.Stat(topology); os.IsNotExist(err) {
+			// Offline cpus have no topology.
+			continue
+		}
+		cpu, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+		pkg, core, thread, err := readCPUTopology(topology, cpu)
+		if err != nil {
+			log.Debugf("Couldn't get topology of %s: %s", filepath.Base(dir), err)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.cpuTopology, prometheus.GaugeValue, 1,
+			filepath.Base(dir), pkg, core, strconv.Itoa(thread))
+	}
+	return nil
+}
+
+// readCPUTopology returns the package and core of a cpu from its topology
+// directory, and its index among the threads of the core.
+func readCPUTopology(topology string, cpu int) (pkg, core string, thread int, err error) {
+	pkg, err = readStringFromFile(filepath.Join(topology, "physical_package_id"))
+	if err != nil {
+		return "", "", 0, err
+	}
+	core, err = readStringFromFile(filepath.Join(topology, "core_id"))
+	if err != nil {
+		return "", "", 0, err
+	}
+	siblings, err := readStringFromFile(filepath.Join(topology, "thread_siblings_list"))
+	if err != nil {
+		return "", "", 0, err
+	}
+	threads, err := parseCPUList(siblings)
+	if err != nil {
+		return "", "", 0, err
+	}
+	for i, t := range threads {
+		if t == cpu {
+			thread = i
+		}
+	}
+	return pkg, core, thread, nil
 }
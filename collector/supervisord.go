@@ -17,6 +17,7 @@ package collector
 
 import (
 	"flag"
+	"fmt"
 
 	"github.com/kolo/xmlrpc"
 	"github.com/prometheus/client_golang/prometheus"
@@ -106,14 +107,14 @@ func (c *supervisordCollector) Update(ch chan<- prometheus.Metric) error {
 		Now           int    `xmlrpc:"now"`
 		State         int    `xmlrpc:"state"`
 		StateName     string `xmlrpc:"statename"`
-		SpawnErr      string `xmlrpc:"spanerr"`
+		SpawnErr      string `xmlrpc:"spawnerr"`
 		ExitStatus    int    `xmlrpc:"exitstatus"`
-		StdoutLogfile string `xmlrcp:"stdout_logfile"`
-		StderrLogfile string `xmlrcp:"stderr_logfile"`
+		StdoutLogfile string `xmlrpc:"stdout_logfile"`
+		StderrLogfile string `xmlrpc:"stderr_logfile"`
 		PID           int    `xmlrpc:"pid"`
 	}
 	if err := c.client.Call("supervisor.getAllProcessInfo", nil, &infos); err != nil {
-		return err
+		return fmt.Errorf("couldn't get process info from %s: %s", *supervisordURL, err)
 	}
 	for _, info := range infos {
 		lables := []string{info.Name, info.Group}
@@ -0,0 +1,163 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noswaps
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const swapSubsystem = "swap"
+
+type swapDevice struct {
+	device, kind string
+	// size and used are in bytes.
+	size, used, priority float64
+}
+
+type swapsCollector struct {
+	size, used, priority typedDesc
+	in, out              typedDesc
+}
+
+func init() {
+	Factories["swaps"] = NewSwapsCollector
+}
+
+// NewSwapsCollector returns a new Collector exposing the usage of each swap
+// device.
+func NewSwapsCollector() (Collector, error) {
+	labels := []string{"device", "type"}
+	return &swapsCollector{
+		size: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapSubsystem, "size_bytes"),
+			"Size of the swap device.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		used: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapSubsystem, "used_bytes"),
+			"Bytes in use on the swap device.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		priority: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapSubsystem, "priority"),
+			"Priority of the swap device, higher priority devices are used first.",
+			labels, nil,
+		), prometheus.GaugeValue},
+		in: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapSubsystem, "in_bytes_total"),
+			"Bytes swapped in from all swap devices.",
+			nil, nil,
+		), prometheus.CounterValue},
+		out: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, swapSubsystem, "out_bytes_total"),
+			"Bytes swapped out to all swap devices.",
+			nil, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *swapsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("swaps"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	devices, err := parseSwaps(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse swaps: %s", err)
+	}
+	for _, d := range devices {
+		ch <- c.size.mustNewConstMetric(d.size, d.device, d.kind)
+		ch <- c.used.mustNewConstMetric(d.used, d.device, d.kind)
+		ch <- c.priority.mustNewConstMetric(d.priority, d.device, d.kind)
+	}
+
+	// The kernel only counts swap activity across all devices.
+	vmstat, err := os.Open(procFilePath("vmstat"))
+	if err != nil {
+		return err
+	}
+	defer vmstat.Close()
+
+	in, out, err := parseSwapActivity(vmstat)
+	if err != nil {
+		return fmt.Errorf("couldn't parse vmstat: %s", err)
+	}
+	pageSize := float64(os.Getpagesize())
+	ch <- c.in.mustNewConstMetric(in * pageSize)
+	ch <- c.out.mustNewConstMetric(out * pageSize)
+	return nil
+}
+
+// parseSwaps parses /proc/swaps, which lists sizes in KiB.
+func parseSwaps(r io.Reader) ([]swapDevice, error) {
+	var (
+		devices []swapDevice
+		scanner = bufio.NewScanner(r)
+	)
+	scanner.Scan() // Skip the header.
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 5 {
+			return nil, fmt.Errorf("invalid line in swaps: %s", scanner.Text())
+		}
+		d := swapDevice{
+			// Spaces in paths are escaped as in /proc/mounts.
+			device: strings.Replace(parts[0], "\\040", " ", -1),
+			kind:   parts[1],
+		}
+		for i, v := range []*float64{&d.size, &d.used, &d.priority} {
+			f, err := strconv.ParseFloat(parts[i+2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value in swaps: %s", err)
+			}
+			*v = f
+		}
+		d.size *= 1024
+		d.used *= 1024
+		devices = append(devices, d)
+	}
+	return devices, scanner.Err()
+}
+
+// parseSwapActivity returns the pages swapped in and out from /proc/vmstat.
+func parseSwapActivity(r io.Reader) (in, out float64, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 || (parts[0] != "pswpin" && parts[0] != "pswpout") {
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid value in vmstat: %s", err)
+		}
+		if parts[0] == "pswpin" {
+			in = v
+		} else {
+			out = v
+		}
+	}
+	return in, out, scanner.Err()
+}
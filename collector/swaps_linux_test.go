@@ -0,0 +1,55 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSwaps(t *testing.T) {
+	file, err := os.Open("fixtures/proc/swaps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	devices, err := parseSwaps(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []swapDevice{
+		{device: "/dev/zram0", kind: "partition", size: 1040183296, used: 247463936, priority: 100},
+		{device: "/dev/sda3", kind: "partition", size: 4294963200, used: 0, priority: -2},
+		{device: "/var/swap file", kind: "file", size: 1073737728, used: 4194304, priority: -3},
+	}
+	if !reflect.DeepEqual(want, devices) {
+		t.Errorf("want swap devices %v, got %v", want, devices)
+	}
+
+	vmstat, err := os.Open("fixtures/proc/vmstat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vmstat.Close()
+
+	in, out, err := parseSwapActivity(vmstat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in != 12 || out != 117 {
+		t.Errorf("want 12 pages swapped in and 117 out, got %f and %f", in, out)
+	}
+}
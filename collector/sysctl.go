@@ -0,0 +1,102 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// sysctlReader reads the raw value of a sysctl. The args are appended to
+// the MIB the name resolves to, as for the nodes of kern.proc.
+type sysctlReader interface {
+	SysctlRaw(name string, args ...int) ([]byte, error)
+}
+
+// sysctlFixture replays sysctl values from a directory holding a file with
+// the raw value of each sysctl, e.g. as written by sysctlRecorder. It allows
+// testing the BSD collectors on any platform.
+type sysctlFixture string
+
+func (dir sysctlFixture) SysctlRaw(name string, args ...int) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(dir), sysctlFileName(name, args)))
+}
+
+// sysctlRecorder passes reads through to a sysctlReader and dumps the
+// values read into a directory in the format of sysctlFixture.
+type sysctlRecorder struct {
+	sysctlReader
+	dir string
+}
+
+func (r sysctlRecorder) SysctlRaw(name string, args ...int) ([]byte, error) {
+	b, err := r.sysctlReader.SysctlRaw(name, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return nil, err
+	}
+	return b, ioutil.WriteFile(filepath.Join(r.dir, sysctlFileName(name, args)), b, 0644)
+}
+
+func sysctlFileName(name string, args []int) string {
+	for _, a := range args {
+		name += "." + strconv.Itoa(a)
+	}
+	return name
+}
+
+func sysctlUint32(s sysctlReader, name string) (uint32, error) {
+	b, err := s.SysctlRaw(name)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) < 4 {
+		return 0, fmt.Errorf("sysctl %s has %d bytes, expected 4", name, len(b))
+	}
+	return nativeEndian.Uint32(b), nil
+}
+
+func sysctlUint64(s sysctlReader, name string) (uint64, error) {
+	b, err := s.SysctlRaw(name)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) < 8 {
+		return 0, fmt.Errorf("sysctl %s has %d bytes, expected 8", name, len(b))
+	}
+	return nativeEndian.Uint64(b), nil
+}
+
+// sysctlLongs decodes an array of C longs, which have the size of a Go int
+// on all platforms supported by the BSD collectors.
+func sysctlLongs(b []byte) []int64 {
+	size := strconv.IntSize / 8
+	longs := make([]int64, 0, len(b)/size)
+	for ; len(b) >= size; b = b[size:] {
+		longs = append(longs, sysctlLong(b))
+	}
+	return longs
+}
+
+func sysctlLong(b []byte) int64 {
+	if strconv.IntSize == 32 {
+		return int64(int32(nativeEndian.Uint32(b)))
+	}
+	return int64(nativeEndian.Uint64(b))
+}
@@ -0,0 +1,40 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build freebsd dragonfly
+
+package collector
+
+import (
+	"flag"
+
+	"golang.org/x/sys/unix"
+)
+
+var sysctlRecordDir = flag.String("collector.sysctl.record-dir", "", "Directory to dump the raw value of every sysctl read to, for use as test fixtures.")
+
+type unixSysctl struct{}
+
+func (unixSysctl) SysctlRaw(name string, args ...int) ([]byte, error) {
+	return unix.SysctlRaw(name, args...)
+}
+
+// newSysctlReader returns the sysctlReader of the host, recording the values
+// read if -collector.sysctl.record-dir is set. The fixture tests replace it
+// to replay a sysctlFixture.
+var newSysctlReader = func() sysctlReader {
+	if *sysctlRecordDir != "" {
+		return sysctlRecorder{unixSysctl{}, *sysctlRecordDir}
+	}
+	return unixSysctl{}
+}
@@ -0,0 +1,222 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// freebsdSysctl returns the hand-built sysctl values in the layout of a
+// FreeBSD amd64 host, skipping the test on hosts with a different long size
+// or byte order.
+func freebsdSysctl(t *testing.T) sysctlReader {
+	if strconv.IntSize != 64 || nativeEndian != binary.LittleEndian {
+		t.Skip("fixtures are laid out for a little-endian 64-bit host")
+	}
+	return sysctlFixture("fixtures/sysctl/freebsd-amd64")
+}
+
+func TestSysctlCPUTimes(t *testing.T) {
+	cpus, err := sysctlCPUTimes(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(cpus); want != got {
+		t.Fatalf("want %d cpus, got %d", want, got)
+	}
+	if want, got := (cputime{user: 10, nice: 0, sys: 2, intr: 1, idle: 100}), cpus[0]; want != got {
+		t.Errorf("want cpu0 %+v, got %+v", want, got)
+	}
+	if want, got := (cputime{user: 20, nice: 1, sys: 3, intr: 0, idle: 200}), cpus[1]; want != got {
+		t.Errorf("want cpu1 %+v, got %+v", want, got)
+	}
+}
+
+func TestSysctlLoad(t *testing.T) {
+	loads, err := sysctlLoad(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{0.2099609375, 0.5, 1} {
+		if got := loads[i]; want != got {
+			t.Errorf("want load %d %f, got %f", i, want, got)
+		}
+	}
+}
+
+func TestSysctlMemInfo(t *testing.T) {
+	info, err := sysctlMemInfo(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]float64{
+		"active": 4096000,
+		"free":   20480000,
+		"total":  33996800,
+	} {
+		if got := info[k]; want != got {
+			t.Errorf("want %s %f, got %f", k, want, got)
+		}
+	}
+}
+
+func TestSysctlRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sysctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := sysctlRecorder{sysctlFixture("fixtures/sysctl/freebsd-amd64"), dir}
+	want, err := r.SysctlRaw("vm.loadavg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(filepath.Join(dir, "vm.loadavg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("want recorded %x, got %x", want, got)
+	}
+	if _, err := r.SysctlRaw("kern.proc.pid", 1); err == nil {
+		t.Error("expected error for missing sysctl")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "kern.proc.pid.1")); !os.IsNotExist(err) {
+		t.Errorf("expected missing sysctl not to be recorded, got %v", err)
+	}
+}
+
+func TestSysctlCPUTopology(t *testing.T) {
+	topology, err := sysctlCPUTopology(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]cpuTopology{
+		0: {pkg: 0, core: 0, thread: 0},
+		1: {pkg: 0, core: 0, thread: 1},
+		2: {pkg: 0, core: 1, thread: 0},
+		3: {pkg: 0, core: 1, thread: 1},
+		4: {pkg: 1, core: 2, thread: 0},
+		5: {pkg: 1, core: 3, thread: 0},
+	}
+	if len(want) != len(topology) {
+		t.Fatalf("want %d cpus, got %d", len(want), len(topology))
+	}
+	for cpu, w := range want {
+		if got := topology[cpu]; w != got {
+			t.Errorf("want cpu%d %+v, got %+v", cpu, w, got)
+		}
+	}
+}
+
+func TestSysctlDevstat(t *testing.T) {
+	stats, err := sysctlDevstat(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(stats); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	ada := stats[0]
+	if want, got := "ada0", ada.device; want != got {
+		t.Errorf("want device %s, got %s", want, got)
+	}
+	if want, got := uint64(2097152), ada.bytes[devstatWrite]; want != got {
+		t.Errorf("want %d bytes written, got %d", want, got)
+	}
+	if want, got := uint64(3), ada.transfers[devstatNoData]; want != got {
+		t.Errorf("want %d other transfers, got %d", want, got)
+	}
+	if want, got := 1.5, ada.duration[devstatRead]; want != got {
+		t.Errorf("want %f seconds reading, got %f", want, got)
+	}
+	if want, got := 3.5, ada.busyTime; want != got {
+		t.Errorf("want %f seconds busy, got %f", want, got)
+	}
+	if want, got := uint64(6144), ada.blocks; want != got {
+		t.Errorf("want %d blocks, got %d", want, got)
+	}
+	if want, got := "cd0", stats[1].device; want != got {
+		t.Errorf("want device %s, got %s", want, got)
+	}
+}
+
+func TestSysctlNetStats(t *testing.T) {
+	stats, err := sysctlNetStats(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]float64{
+		"Tcp_RetransSegs":   2841,
+		"Tcp_InErrs":        6,
+		"Tcp_ActiveOpens":   1523,
+		"Udp_InErrors":      7,
+		"Udp_NoPorts":       1207,
+		"Ip_InHdrErrors":    3,
+		"Ip_ReasmFails":     3,
+		"Icmp_InMsgs":       379,
+		"Icmp_OutMsgs":      1214,
+		"Icmp_InCsumErrors": 2,
+	} {
+		parts := strings.SplitN(key, "_", 2)
+		if got, ok := stats[parts[0]][parts[1]]; !ok || want != got {
+			t.Errorf("want %s %f, got %f", key, want, got)
+		}
+	}
+}
+
+func TestSysctlNetDevs(t *testing.T) {
+	devs, err := sysctlNetDevs(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Index 2 is missing, as for a removed interface.
+	if want, got := 2, len(devs); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	em := devs[0]
+	if want, got := "em0", em.name; want != got {
+		t.Errorf("want device %s, got %s", want, got)
+	}
+	// UP, BROADCAST, RUNNING, SIMPLEX and MULTICAST.
+	if want, got := uint64(0x8843), em.flags; want != got {
+		t.Errorf("want flags %#x, got %#x", want, got)
+	}
+	if want, got := uint64(2), em.linkState; want != got {
+		t.Errorf("want link state %d, got %d", want, got)
+	}
+	for name, want := range map[string]uint64{
+		"receive_packets": 5313,
+		"receive_bytes":   2997218,
+		"transmit_bytes":  1070382,
+		"transmit_drop":   4,
+		"receive_noproto": 7,
+	} {
+		if got := em.counters[name]; want != got {
+			t.Errorf("want %s %d, got %d", name, want, got)
+		}
+	}
+	if want, got := "lo0", devs[1].name; want != got {
+		t.Errorf("want device %s, got %s", want, got)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"flag"
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/coreos/go-systemd/dbus"
 	"github.com/prometheus/client_golang/prometheus"
@@ -33,6 +34,7 @@ var (
 type systemdCollector struct {
 	unitDesc             *prometheus.Desc
 	systemRunningDesc    *prometheus.Desc
+	unitCounters         []unitCounter
 	unitWhitelistPattern *regexp.Regexp
 	unitBlacklistPattern *regexp.Regexp
 }
@@ -65,12 +67,30 @@ func NewSystemdCollector() (Collector, error) {
 		"Whether the system is operational (see 'systemctl is-system-running')",
 		nil, nil,
 	)
+	unitCounters := []unitCounter{
+		{".socket", "Socket", "NAccepted", typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "socket_accepted_connections_total"),
+			"Total number of connections accepted by the socket unit.",
+			[]string{"name"}, nil,
+		), prometheus.CounterValue}},
+		{".socket", "Socket", "NConnections", typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "socket_current_connections"),
+			"Number of connections of the socket unit currently open.",
+			[]string{"name"}, nil,
+		), prometheus.GaugeValue}},
+		{".service", "Service", "NRestarts", typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "service_restart_total"),
+			"Total number of automatic restarts of the service unit, needs systemd 235 or later.",
+			[]string{"name"}, nil,
+		), prometheus.CounterValue}},
+	}
 	unitWhitelistPattern := regexp.MustCompile(fmt.Sprintf("^(?:%s)$", *unitWhitelist))
 	unitBlacklistPattern := regexp.MustCompile(fmt.Sprintf("^(?:%s)$", *unitBlacklist))
 
 	return &systemdCollector{
 		unitDesc:             unitDesc,
 		systemRunningDesc:    systemRunningDesc,
+		unitCounters:         unitCounters,
 		unitWhitelistPattern: unitWhitelistPattern,
 		unitBlacklistPattern: unitBlacklistPattern,
 	}, nil
@@ -83,6 +103,10 @@ func (c *systemdCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	}
 	c.collectUnitStatusMetrics(ch, units)
 
+	if err := c.collectUnitCounters(ch, units); err != nil {
+		return fmt.Errorf("couldn't get unit counters: %s", err)
+	}
+
 	systemState, err := c.getSystemState()
 	if err != nil {
 		return fmt.Errorf("couldn't get system state: %s", err)
@@ -106,6 +130,56 @@ func (c *systemdCollector) collectUnitStatusMetrics(ch chan<- prometheus.Metric,
 	}
 }
 
+// unitCounter is a numeric property of the units of one type.
+type unitCounter struct {
+	suffix, unitType, property string
+	desc                       typedDesc
+}
+
+// collectUnitCounters exposes the connection counts of sockets and the
+// restarts of services. Properties unknown to the running systemd version
+// are skipped.
+func (c *systemdCollector) collectUnitCounters(ch chan<- prometheus.Metric, units []dbus.UnitStatus) error {
+	conn, err := c.newDbus()
+	if err != nil {
+		return fmt.Errorf("couldn't get dbus connection: %s", err)
+	}
+	defer conn.Close()
+
+	for _, unit := range units {
+		if unit.LoadState != "loaded" {
+			continue
+		}
+		for _, counter := range c.unitCounters {
+			if !strings.HasSuffix(unit.Name, counter.suffix) {
+				continue
+			}
+			prop, err := conn.GetUnitTypeProperty(unit.Name, counter.unitType, counter.property)
+			if err != nil {
+				log.Debugf("Couldn't get %s of %s: %s", counter.property, unit.Name, err)
+				continue
+			}
+			v, ok := unitPropertyValue(prop)
+			if !ok {
+				return fmt.Errorf("unexpected type of %s of %s: %s", counter.property, unit.Name, prop.Value.Signature())
+			}
+			ch <- counter.desc.mustNewConstMetric(v, unit.Name)
+		}
+	}
+	return nil
+}
+
+// unitPropertyValue returns the value of a numeric unit property.
+func unitPropertyValue(prop *dbus.Property) (float64, bool) {
+	switch v := prop.Value.Value().(type) {
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
 func (c *systemdCollector) collectSystemState(ch chan<- prometheus.Metric, systemState string) {
 	isSystemRunning := 0.0
 	if systemState == `"running"` {
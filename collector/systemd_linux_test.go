@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	"github.com/coreos/go-systemd/dbus"
+	godbus "github.com/godbus/dbus"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -121,3 +122,20 @@ func TestSystemdIgnoreFilterDefaultKeepsAll(t *testing.T) {
 		t.Error("Default filters removed units")
 	}
 }
+
+func TestUnitPropertyValue(t *testing.T) {
+	for _, c := range []struct {
+		value interface{}
+		want  float64
+		ok    bool
+	}{
+		{uint32(42), 42, true},
+		{uint64(1 << 40), 1 << 40, true},
+		{"42", 0, false},
+	} {
+		got, ok := unitPropertyValue(&dbus.Property{Name: "NAccepted", Value: godbus.MakeVariant(c.value)})
+		if c.want != got || c.ok != ok {
+			t.Errorf("%#v: want %f, %t, got %f, %t", c.value, c.want, c.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !notc
+
+package collector
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	tcCommand = flag.String("collector.tc.command", "tc", "Command to run tc.")
+	tcActions = flag.String("collector.tc.actions", "gact,police,mirred", "Comma-separated list of tc action kinds to expose counters for.")
+)
+
+type tcAction struct {
+	Kind  string `json:"kind"`
+	Index uint32 `json:"index"`
+	Bind  int    `json:"bind"`
+	Stats struct {
+		Bytes      float64 `json:"bytes"`
+		Packets    float64 `json:"packets"`
+		Drops      float64 `json:"drops"`
+		Overlimits float64 `json:"overlimits"`
+	} `json:"stats"`
+}
+
+type tcCollector struct {
+	cli                       string
+	kinds                     []string
+	packets, bytes            typedDesc
+	drops, overlimits, filter typedDesc
+}
+
+func init() {
+	Factories["tc"] = NewTCCollector
+}
+
+// NewTCCollector returns a new Collector exposing counters of tc actions.
+func NewTCCollector() (Collector, error) {
+	const subsystem = "tc"
+
+	labels := []string{"kind", "index"}
+	return &tcCollector{
+		cli:   *tcCommand,
+		kinds: strings.Split(*tcActions, ","),
+		packets: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "action_packets_total"),
+			"Packets the tc action was applied to.",
+			labels, nil,
+		), prometheus.CounterValue},
+		bytes: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "action_bytes_total"),
+			"Bytes the tc action was applied to.",
+			labels, nil,
+		), prometheus.CounterValue},
+		drops: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "action_drops_total"),
+			"Packets dropped by the tc action.",
+			labels, nil,
+		), prometheus.CounterValue},
+		overlimits: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "action_overlimits_total"),
+			"Packets that exceeded the rate of the tc action.",
+			labels, nil,
+		), prometheus.CounterValue},
+		filter: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "action_bound_filters"),
+			"Number of filters the tc action is bound to.",
+			labels, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *tcCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	for _, kind := range c.kinds {
+		actions, err := c.listActions(kind)
+		if err != nil {
+			return fmt.Errorf("couldn't list %s actions: %s", kind, err)
+		}
+		for _, a := range actions {
+			index := strconv.FormatUint(uint64(a.Index), 10)
+			ch <- c.packets.mustNewConstMetric(a.Stats.Packets, a.Kind, index)
+			ch <- c.bytes.mustNewConstMetric(a.Stats.Bytes, a.Kind, index)
+			ch <- c.drops.mustNewConstMetric(a.Stats.Drops, a.Kind, index)
+			ch <- c.overlimits.mustNewConstMetric(a.Stats.Overlimits, a.Kind, index)
+			ch <- c.filter.mustNewConstMetric(float64(a.Bind), a.Kind, index)
+		}
+	}
+	return nil
+}
+
+func (c *tcCollector) listActions(kind string) ([]tcAction, error) {
+	cmd := exec.Command(c.cli, "-s", "-j", "actions", "list", "action", kind)
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	actions, err := parseTCActions(pipe)
+	if err != nil {
+		// Stop tc, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
+
+// parseTCActions parses the output of `tc -s -j actions list action <kind>`,
+// which is a list of an object holding the total followed by one holding
+// the actions.
+func parseTCActions(r io.Reader) ([]tcAction, error) {
+	var doc []struct {
+		Actions []tcAction `json:"actions"`
+	}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	var actions []tcAction
+	for _, obj := range doc {
+		actions = append(actions, obj.Actions...)
+	}
+	return actions, nil
+}
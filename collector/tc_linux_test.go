@@ -0,0 +1,57 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTCActions(t *testing.T) {
+	file, err := os.Open("fixtures/tc/police.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	actions, err := parseTCActions(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(actions); want != got {
+		t.Fatalf("want %d actions, got %d", want, got)
+	}
+	a := actions[0]
+	if want, got := uint32(7), a.Index; want != got {
+		t.Errorf("want index %d, got %d", want, got)
+	}
+	if want, got := 200.0, a.Stats.Packets; want != got {
+		t.Errorf("want %f packets, got %f", want, got)
+	}
+	if want, got := 15.0, a.Stats.Drops; want != got {
+		t.Errorf("want %f drops, got %f", want, got)
+	}
+	if want, got := 1, a.Bind; want != got {
+		t.Errorf("want %d bound filters, got %d", want, got)
+	}
+
+	actions, err = parseTCActions(strings.NewReader("[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 0 {
+		t.Errorf("want no actions, got %d", len(actions))
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -33,86 +34,179 @@ import (
 )
 
 var (
-	textFileDirectory = flag.String("collector.textfile.directory", "", "Directory to read text files with metrics from.")
+	textFileDirectory = flag.String("collector.textfile.directory", "", "Directories or glob patterns of text files with metrics to read, separated by commas or colons.")
 )
 
 type textFileCollector struct {
-	path string
+	paths []string
 }
 
 func init() {
 	Factories["textfile"] = NewTextFileCollector
 }
 
-// Takes a registers a
-// SetMetricFamilyInjectionHook.
+// NewTextFileCollector returns a new Collector exposing the metrics of the
+// *.prom files in the textfile directory.
 func NewTextFileCollector() (Collector, error) {
 	c := &textFileCollector{
-		path: *textFileDirectory,
+		paths: splitTextFilePaths(*textFileDirectory),
 	}
 
-	if c.path == "" {
+	if len(c.paths) == 0 {
 		// This collector is enabled by default, so do not fail if
 		// the flag is not passed.
 		log.Infof("No directory specified, see --collector.textfile.directory")
-	} else {
-		prometheus.SetMetricFamilyInjectionHook(c.parseTextFiles)
 	}
 
 	return c, nil
 }
 
-// textFile collector works via SetMetricFamilyInjectionHook in parseTextFiles.
+// Update reads the text files on every scrape and passes their samples on.
 func (c *textFileCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	if len(c.paths) == 0 {
+		return nil
+	}
+	for _, mf := range c.parseTextFiles() {
+		convertMetricFamily(mf, ch)
+	}
 	return nil
 }
 
-func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
-	error := 0.0
-	var metricFamilies []*dto.MetricFamily
-	mtimes := map[string]time.Time{}
-
-	// Iterate over files and accumulate their metrics.
-	files, err := ioutil.ReadDir(c.path)
-	if err != nil && c.path != "" {
-		log.Errorf("Error reading textfile collector directory %s: %s", c.path, err)
-		error = 1.0
-	}
-	for _, f := range files {
-		if !strings.HasSuffix(f.Name(), ".prom") {
-			continue
+// convertMetricFamily turns a parsed metric family back into metrics.
+func convertMetricFamily(mf *dto.MetricFamily, ch chan<- prometheus.Metric) {
+	// All metrics of a family need the same label names, the ones a metric
+	// lacks are set to the empty value, which is the same as not set.
+	names := map[string]bool{}
+	for _, m := range mf.Metric {
+		for _, l := range m.Label {
+			names[l.GetName()] = true
+		}
+	}
+	labelNames := make([]string, 0, len(names))
+	for n := range names {
+		labelNames = append(labelNames, n)
+	}
+	sort.Strings(labelNames)
+	desc := prometheus.NewDesc(mf.GetName(), mf.GetHelp(), labelNames, nil)
+
+	for _, m := range mf.Metric {
+		values := map[string]string{}
+		for _, l := range m.Label {
+			values[l.GetName()] = l.GetValue()
+		}
+		labelValues := make([]string, len(labelNames))
+		for i, n := range labelNames {
+			labelValues[i] = values[n]
+		}
+
+		var (
+			metric prometheus.Metric
+			err    error
+		)
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			metric, err = prometheus.NewConstMetric(desc, prometheus.CounterValue, m.GetCounter().GetValue(), labelValues...)
+		case dto.MetricType_GAUGE:
+			metric, err = prometheus.NewConstMetric(desc, prometheus.GaugeValue, m.GetGauge().GetValue(), labelValues...)
+		case dto.MetricType_SUMMARY:
+			quantiles := map[float64]float64{}
+			for _, q := range m.GetSummary().GetQuantile() {
+				quantiles[q.GetQuantile()] = q.GetValue()
+			}
+			metric, err = prometheus.NewConstSummary(desc, m.GetSummary().GetSampleCount(), m.GetSummary().GetSampleSum(), quantiles, labelValues...)
+		case dto.MetricType_HISTOGRAM:
+			buckets := map[float64]uint64{}
+			for _, b := range m.GetHistogram().GetBucket() {
+				buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+			}
+			metric, err = prometheus.NewConstHistogram(desc, m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum(), buckets, labelValues...)
+		default:
+			metric, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, m.GetUntyped().GetValue(), labelValues...)
 		}
-		path := filepath.Join(c.path, f.Name())
-		file, err := os.Open(path)
 		if err != nil {
-			log.Errorf("Error opening %s: %v", path, err)
-			error = 1.0
+			log.Errorf("Couldn't convert metric %s: %s", mf.GetName(), err)
 			continue
 		}
-		var parser expfmt.TextParser
-		parsedFamilies, err := parser.TextToMetricFamilies(file)
-		file.Close()
+		if m.TimestampMs != nil {
+			metric = timestampedMetric{metric, m.GetTimestampMs()}
+		}
+		ch <- metric
+	}
+}
+
+// timestampedMetric keeps the timestamp a sample has in its text file.
+type timestampedMetric struct {
+	prometheus.Metric
+	timestampMs int64
+}
+
+func (m timestampedMetric) Write(out *dto.Metric) error {
+	if err := m.Metric.Write(out); err != nil {
+		return err
+	}
+	out.TimestampMs = proto.Int64(m.timestampMs)
+	return nil
+}
+
+func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
+	var (
+		scrapeError float64
+		families    = map[string]*dto.MetricFamily{}
+		names       []string
+		series      = map[string]bool{}
+		read        = map[string]bool{}
+		mtimes      = map[string]time.Time{}
+		pathErrors  = map[string]float64{}
+	)
+	for _, p := range c.paths {
+		pathErrors[p] = 0
+		files, err := textFilesForPath(p)
 		if err != nil {
-			log.Errorf("Error parsing %s: %v", path, err)
-			error = 1.0
+			log.Errorf("Error reading textfile collector path %s: %s", p, err)
+			pathErrors[p], scrapeError = 1, 1
 			continue
 		}
-		// Only set this once it has been parsed, so that
-		// a failure does not appear fresh.
-		mtimes[f.Name()] = f.ModTime()
-		for _, mf := range parsedFamilies {
-			if mf.Help == nil {
-				help := fmt.Sprintf("Metric read from %s", path)
-				mf.Help = &help
+		for _, path := range files {
+			// Files matched by several paths are only read once.
+			if read[path] {
+				continue
+			}
+			read[path] = true
+
+			mtime, parsedFamilies, err := parseTextFile(path)
+			if err != nil {
+				log.Errorf("Error parsing %s: %v", path, err)
+				pathErrors[p], scrapeError = 1, 1
+				continue
+			}
+			// Only set this once it has been parsed, so that
+			// a failure does not appear fresh.
+			mtimes[c.fileLabel(path)] = mtime
+			for _, mf := range parsedFamilies {
+				if mf.Help == nil {
+					help := fmt.Sprintf("Metric read from %s", path)
+					mf.Help = &help
+				}
+				if _, ok := families[mf.GetName()]; !ok {
+					names = append(names, mf.GetName())
+				}
+				if err := mergeMetricFamily(families, series, mf); err != nil {
+					log.Errorf("Error merging %s: %s", path, err)
+					pathErrors[p], scrapeError = 1, 1
+				}
 			}
-			metricFamilies = append(metricFamilies, mf)
 		}
 	}
 
+	metricFamilies := make([]*dto.MetricFamily, 0, len(names)+3)
+	for _, name := range names {
+		metricFamilies = append(metricFamilies, families[name])
+	}
+
 	// Export the mtimes of the successful files.
 	if len(mtimes) > 0 {
 		mtimeMetricFamily := dto.MetricFamily{
-			Name:   proto.String("node_textfile_mtime"),
+			Name:   proto.String("node_textfile_mtime_seconds"),
 			Help:   proto.String("Unixtime mtime of textfiles successfully read."),
 			Type:   dto.MetricType_GAUGE.Enum(),
 			Metric: []*dto.Metric{},
@@ -140,6 +234,29 @@ func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
 		}
 		metricFamilies = append(metricFamilies, &mtimeMetricFamily)
 	}
+
+	// Export errors per configured path.
+	pathErrorMetricFamily := dto.MetricFamily{
+		Name:   proto.String("node_textfile_path_error"),
+		Help:   proto.String("1 if there was an error reading the directory or pattern or a file it matched, 0 otherwise"),
+		Type:   dto.MetricType_GAUGE.Enum(),
+		Metric: []*dto.Metric{},
+	}
+	for _, p := range c.paths {
+		pathErrorMetricFamily.Metric = append(pathErrorMetricFamily.Metric,
+			&dto.Metric{
+				Label: []*dto.LabelPair{
+					{
+						Name:  proto.String("path"),
+						Value: proto.String(p),
+					},
+				},
+				Gauge: &dto.Gauge{Value: proto.Float64(pathErrors[p])},
+			},
+		)
+	}
+	metricFamilies = append(metricFamilies, &pathErrorMetricFamily)
+
 	// Export if there were errors.
 	metricFamilies = append(metricFamilies, &dto.MetricFamily{
 		Name: proto.String("node_textfile_scrape_error"),
@@ -147,10 +264,132 @@ func (c *textFileCollector) parseTextFiles() []*dto.MetricFamily {
 		Type: dto.MetricType_GAUGE.Enum(),
 		Metric: []*dto.Metric{
 			{
-				Gauge: &dto.Gauge{Value: &error},
+				Gauge: &dto.Gauge{Value: &scrapeError},
 			},
 		},
 	})
 
 	return metricFamilies
 }
+
+// fileLabel returns the file label of the mtime metric. It is the base name
+// if a single directory is configured, and the full path otherwise.
+func (c *textFileCollector) fileLabel(path string) string {
+	if len(c.paths) == 1 && !isGlob(c.paths[0]) {
+		return filepath.Base(path)
+	}
+	return path
+}
+
+// splitTextFilePaths splits the value of --collector.textfile.directory,
+// which separates directories and patterns by commas or colons.
+func splitTextFilePaths(s string) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, p := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ':' }) {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// textFilesForPath returns the *.prom files in a directory, or the files
+// matching a glob pattern, sorted by name.
+func textFilesForPath(path string) ([]string, error) {
+	if isGlob(path) {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, err
+		}
+		var files []string
+		for _, m := range matches {
+			if fi, err := os.Stat(m); err == nil && !fi.IsDir() {
+				files = append(files, filepath.Clean(m))
+			}
+		}
+		return files, nil
+	}
+
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, fi := range infos {
+		if !strings.HasSuffix(fi.Name(), ".prom") || fi.IsDir() {
+			continue
+		}
+		files = append(files, filepath.Join(path, fi.Name()))
+	}
+	return files, nil
+}
+
+func parseTextFile(path string) (time.Time, map[string]*dto.MetricFamily, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(file)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return fi.ModTime(), families, nil
+}
+
+// mergeMetricFamily adds mf to families, merging it into a family of the
+// same name read from another file. Series that were already read from
+// another file are dropped.
+func mergeMetricFamily(families map[string]*dto.MetricFamily, series map[string]bool, mf *dto.MetricFamily) error {
+	existing, ok := families[mf.GetName()]
+	if ok && existing.GetType() != mf.GetType() {
+		return fmt.Errorf("metric %s has type %s, but %s elsewhere", mf.GetName(), mf.GetType(), existing.GetType())
+	}
+
+	var (
+		metrics    []*dto.Metric
+		duplicates int
+	)
+	for _, m := range mf.Metric {
+		key := seriesKey(mf.GetName(), m)
+		if series[key] {
+			duplicates++
+			continue
+		}
+		series[key] = true
+		metrics = append(metrics, m)
+	}
+	if ok {
+		existing.Metric = append(existing.Metric, metrics...)
+	} else {
+		mf.Metric = metrics
+		families[mf.GetName()] = mf
+	}
+	if duplicates > 0 {
+		return fmt.Errorf("dropped %d series of %s that were read before", duplicates, mf.GetName())
+	}
+	return nil
+}
+
+func seriesKey(name string, m *dto.Metric) string {
+	labels := make([]string, 0, len(m.Label))
+	for _, l := range m.Label {
+		labels = append(labels, l.GetName()+"="+strconv.Quote(l.GetValue()))
+	}
+	sort.Strings(labels)
+	return name + "{" + strings.Join(labels, ",") + "}"
+}
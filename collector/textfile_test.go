@@ -14,6 +14,7 @@
 package collector
 
 import (
+	"bytes"
 	"flag"
 	"io/ioutil"
 	"sort"
@@ -21,6 +22,8 @@ import (
 	"testing"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
 )
 
 func TestParseTextFiles(t *testing.T) {
@@ -40,11 +43,17 @@ func TestParseTextFiles(t *testing.T) {
 			path: "fixtures/textfile/nonexistent_path",
 			out:  "fixtures/textfile/nonexistent_path.out",
 		},
+		{
+			// The files of two_metric_files are matched twice but read once,
+			// duplicate.prom repeats a series of metrics1.prom.
+			path: "fixtures/textfile/two_metric_files,fixtures/textfile/two_metric_files/*.prom:fixtures/textfile/multiple_paths/*.prom,fixtures/textfile/nonexistent_path",
+			out:  "fixtures/textfile/multiple_paths.out",
+		},
 	}
 
 	for i, test := range tests {
 		c := textFileCollector{
-			path: test.path,
+			paths: splitTextFilePaths(test.path),
 		}
 
 		// Suppress a log message about `nonexistent_path` not existing, this is
@@ -57,14 +66,21 @@ func TestParseTextFiles(t *testing.T) {
 		mfs := c.parseTextFiles()
 		textMFs := make([]string, 0, len(mfs))
 		for _, mf := range mfs {
-			if mf.GetName() == "node_textfile_mtime" {
-				mf.GetMetric()[0].GetGauge().Value = proto.Float64(1)
-				mf.GetMetric()[1].GetGauge().Value = proto.Float64(2)
+			if mf.GetName() == "node_textfile_mtime_seconds" {
+				for i, m := range mf.GetMetric() {
+					m.GetGauge().Value = proto.Float64(float64(i + 1))
+				}
 			}
 			textMFs = append(textMFs, proto.MarshalTextString(mf))
 		}
 		sort.Strings(textMFs)
 		got := strings.Join(textMFs, "")
+		if *updateGolden {
+			if err := ioutil.WriteFile(test.out, []byte(got), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
 
 		want, err := ioutil.ReadFile(test.out)
 		if err != nil {
@@ -76,3 +92,57 @@ func TestParseTextFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestConvertMetricFamily(t *testing.T) {
+	in := `# HELP http_requests_total Requests handled.
+# TYPE http_requests_total counter
+http_requests_total{code="200"} 10 1441205977284
+http_requests_total{code="500",method="post"} 1
+# HELP job_duration_seconds Job duration.
+# TYPE job_duration_seconds summary
+job_duration_seconds{quantile="0.5"} 3
+job_duration_seconds_sum 12
+job_duration_seconds_count 4
+# HELP request_size_bytes Request size.
+# TYPE request_size_bytes histogram
+request_size_bytes_bucket{le="100"} 1
+request_size_bytes_bucket{le="+Inf"} 2
+request_size_bytes_sum 300
+request_size_bytes_count 2
+`
+	var parser expfmt.TextParser
+	mfs, err := parser.TextToMetricFamilies(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := make(chan prometheus.Metric)
+	go func() {
+		for _, mf := range mfs {
+			convertMetricFamily(mf, ch)
+		}
+		close(ch)
+	}()
+	var metrics fixtureMetrics
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(metrics); err != nil {
+		t.Fatal(err)
+	}
+	gathered, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	for _, mf := range gathered {
+		if _, err := expfmt.MetricFamilyToText(&buf, mf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := strings.Replace(in, `{code="200"}`, `{code="200",method=""}`, 1)
+	if got := buf.String(); want != got {
+		t.Errorf("want:\n%s\ngot:\n%s", want, got)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !notpm
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const tpmSubsystem = "tpm"
+
+var tpmGetcapCommand = flag.String("collector.tpm.getcap-command", "", "Command to run tpm2_getcap of tpm2-tools to read the dictionary attack lockout state, disabled if empty.")
+
+// tpmDevice is a TPM in /sys/class/tpm.
+type tpmDevice struct {
+	name    string
+	version string
+	// pcrBanks holds the number of PCRs by hash algorithm.
+	pcrBanks map[string]int
+	// flags holds the enabled, active and owned flags of TPM 1.2 devices.
+	flags map[string]uint64
+}
+
+// tpmLockout is the dictionary attack protection state of a TPM 2.0.
+type tpmLockout struct {
+	inLockout bool
+	counter   uint64
+	maxTries  uint64
+}
+
+type tpmCollector struct {
+	getcap          string
+	devices         typedDesc
+	info            typedDesc
+	pcrBank         typedDesc
+	flag            typedDesc
+	inLockout       typedDesc
+	lockoutCounter  typedDesc
+	lockoutMaxTries typedDesc
+}
+
+func init() {
+	Factories["tpm"] = NewTPMCollector
+}
+
+// NewTPMCollector returns a new Collector exposing the TPMs of the system,
+// their PCR banks and, if configured, their lockout state.
+func NewTPMCollector() (Collector, error) {
+	return &tpmCollector{
+		getcap: *tpmGetcapCommand,
+		devices: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "devices"),
+			"Number of TPM devices.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "info"),
+			"TPM device and its specification version, value is always 1.",
+			[]string{"device", "version"}, nil,
+		), prometheus.GaugeValue},
+		pcrBank: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "pcr_bank_pcrs"),
+			"Number of PCRs in the bank of the hash algorithm.",
+			[]string{"device", "algorithm"}, nil,
+		), prometheus.GaugeValue},
+		flag: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "flag"),
+			"Enabled, active and owned flags of TPM 1.2 devices.",
+			[]string{"device", "flag"}, nil,
+		), prometheus.GaugeValue},
+		inLockout: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "in_lockout"),
+			"Whether the TPM refuses authorizations after too many failures.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		lockoutCounter: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "lockout_failed_tries"),
+			"Failed authorizations, e.g. unseal attempts, counted towards the lockout. It decreases by one every lockout interval.",
+			nil, nil,
+		), prometheus.GaugeValue},
+		lockoutMaxTries: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, tpmSubsystem, "lockout_max_tries"),
+			"Failed authorizations after which the TPM locks out.",
+			nil, nil,
+		), prometheus.GaugeValue},
+	}, nil
+}
+
+func (c *tpmCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readTPMDevices(sysFilePath("class/tpm"))
+	if err != nil {
+		return fmt.Errorf("couldn't read TPM devices: %s", err)
+	}
+	ch <- c.devices.mustNewConstMetric(float64(len(devices)))
+	for _, d := range devices {
+		ch <- c.info.mustNewConstMetric(1, d.name, d.version)
+		for alg, n := range d.pcrBanks {
+			ch <- c.pcrBank.mustNewConstMetric(float64(n), d.name, alg)
+		}
+		for f, v := range d.flags {
+			ch <- c.flag.mustNewConstMetric(float64(v), d.name, f)
+		}
+	}
+
+	if c.getcap == "" || len(devices) == 0 {
+		return nil
+	}
+	out, err := exec.Command(c.getcap, "properties-variable").Output()
+	if err != nil {
+		return fmt.Errorf("couldn't run %s: %s", c.getcap, err)
+	}
+	lockout, err := parseTPMLockout(strings.NewReader(string(out)))
+	if err != nil {
+		return err
+	}
+	inLockout := 0.0
+	if lockout.inLockout {
+		inLockout = 1
+	}
+	ch <- c.inLockout.mustNewConstMetric(inLockout)
+	ch <- c.lockoutCounter.mustNewConstMetric(float64(lockout.counter))
+	ch <- c.lockoutMaxTries.mustNewConstMetric(float64(lockout.maxTries))
+	return nil
+}
+
+// readTPMDevices reads the TPMs of /sys/class/tpm. The PCR banks of TPM
+// 2.0 devices are only exposed by kernels since 5.12.
+func readTPMDevices(root string) ([]tpmDevice, error) {
+	dirs, err := filepath.Glob(path.Join(root, "tpm[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+	var devices []tpmDevice
+	for _, dir := range dirs {
+		d := tpmDevice{
+			name:     path.Base(dir),
+			pcrBanks: map[string]int{},
+			flags:    map[string]uint64{},
+		}
+		major, err := readStringFromFile(path.Join(dir, "tpm_version_major"))
+		switch {
+		case err == nil:
+			d.version = major + ".0"
+			if major == "1" {
+				d.version = "1.2"
+			}
+		case os.IsNotExist(err):
+			// Kernels before 5.6 only expose the caps of TPM 1.2.
+			d.version = "2.0"
+			if _, err := os.Stat(path.Join(dir, "device", "caps")); err == nil {
+				d.version = "1.2"
+			}
+		default:
+			return nil, err
+		}
+
+		banks, err := filepath.Glob(path.Join(dir, "pcr-*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, bank := range banks {
+			pcrs, err := ioutil.ReadDir(bank)
+			if err != nil {
+				return nil, err
+			}
+			d.pcrBanks[strings.TrimPrefix(path.Base(bank), "pcr-")] = len(pcrs)
+		}
+		if pcrs, err := ioutil.ReadFile(path.Join(dir, "device", "pcrs")); err == nil {
+			d.pcrBanks["sha1"] = strings.Count(string(pcrs), "PCR-")
+		}
+
+		for _, f := range []string{"enabled", "active", "owned"} {
+			v, err := readUintFromFile(path.Join(dir, "device", f))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			d.flags[f] = v
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// parseTPMLockout parses the output of `tpm2_getcap properties-variable`.
+func parseTPMLockout(r io.Reader) (tpmLockout, error) {
+	var (
+		lockout tpmLockout
+		found   int
+		scanner = bufio.NewScanner(r)
+	)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		var target *uint64
+		switch key {
+		case "inLockout":
+			lockout.inLockout = value == "1"
+			found++
+			continue
+		case "TPM2_PT_LOCKOUT_COUNTER":
+			target = &lockout.counter
+		case "TPM2_PT_MAX_AUTH_FAIL":
+			target = &lockout.maxTries
+		default:
+			continue
+		}
+		v, err := strconv.ParseUint(value, 0, 64)
+		if err != nil {
+			return lockout, fmt.Errorf("invalid value of %s: %s", key, err)
+		}
+		*target = v
+		found++
+	}
+	if err := scanner.Err(); err != nil {
+		return lockout, err
+	}
+	if found != 3 {
+		return lockout, fmt.Errorf("lockout state missing in tpm2_getcap output")
+	}
+	return lockout, nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTPMLockout(t *testing.T) {
+	lockout, err := parseTPMLockout(strings.NewReader(`TPM2_PT_PERMANENT:
+  ownerAuthSet:              1
+  inLockout:                 1
+TPM2_PT_LOCKOUT_COUNTER: 0x20
+TPM2_PT_MAX_AUTH_FAIL: 0x20
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (tpmLockout{inLockout: true, counter: 32, maxTries: 32}); want != lockout {
+		t.Errorf("want %+v, got %+v", want, lockout)
+	}
+
+	if _, err := parseTPMLockout(strings.NewReader("TPM2_PT_MAX_AUTH_FAIL: 0x20\n")); err == nil {
+		t.Error("expected error for missing lockout state")
+	}
+	if _, err := parseTPMLockout(strings.NewReader("inLockout: 0\nTPM2_PT_LOCKOUT_COUNTER: x\nTPM2_PT_MAX_AUTH_FAIL: 0x20\n")); err == nil {
+		t.Error("expected error for invalid counter")
+	}
+}
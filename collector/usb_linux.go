@@ -0,0 +1,151 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nousb
+
+package collector
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const usbSubsystem = "usb"
+
+type usbDevice struct {
+	// Name of the device in /sys/bus/usb/devices, the bus number followed
+	// by the port path, e.g. 1-1.2.
+	port         string
+	vendorID     string
+	productID    string
+	class        string
+	speed        string
+	manufacturer string
+	product      string
+}
+
+type usbCollector struct {
+	info, connects, disconnects typedDesc
+
+	mtx          sync.Mutex
+	ports        map[string]bool
+	connectsN    map[string]float64
+	disconnectsN map[string]float64
+}
+
+func init() {
+	Factories["usb"] = NewUSBCollector
+}
+
+// NewUSBCollector returns a new Collector exposing the connected USB
+// devices.
+func NewUSBCollector() (Collector, error) {
+	return &usbCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, usbSubsystem, "device_info"),
+			"USB device connected to the port, value is always 1.",
+			[]string{"port", "vendor_id", "product_id", "class", "speed", "manufacturer", "product"}, nil,
+		), prometheus.GaugeValue},
+		connects: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, usbSubsystem, "connects_total"),
+			"Number of times a device was seen connected to the port since the exporter started.",
+			[]string{"port"}, nil,
+		), prometheus.CounterValue},
+		disconnects: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, usbSubsystem, "disconnects_total"),
+			"Number of times a device was seen disconnected from the port since the exporter started.",
+			[]string{"port"}, nil,
+		), prometheus.CounterValue},
+		connectsN:    map[string]float64{},
+		disconnectsN: map[string]float64{},
+	}, nil
+}
+
+func (c *usbCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readUSBDevices(sysFilePath("bus/usb/devices"))
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	ports := map[string]bool{}
+	for _, d := range devices {
+		ports[d.port] = true
+		ch <- c.info.mustNewConstMetric(1, d.port, d.vendorID, d.productID, d.class, d.speed, d.manufacturer, d.product)
+	}
+	// Events are only counted from the second scrape on, and a device
+	// replugged between two scrapes is missed.
+	if c.ports != nil {
+		for port := range ports {
+			if !c.ports[port] {
+				c.connectsN[port]++
+			}
+		}
+		for port := range c.ports {
+			if !ports[port] {
+				c.disconnectsN[port]++
+			}
+		}
+	}
+	c.ports = ports
+
+	for port, n := range c.connectsN {
+		ch <- c.connects.mustNewConstMetric(n, port)
+	}
+	for port, n := range c.disconnectsN {
+		ch <- c.disconnects.mustNewConstMetric(n, port)
+	}
+	return nil
+}
+
+// readUSBDevices reads the devices of /sys/bus/usb/devices, skipping root
+// hubs and interfaces.
+func readUSBDevices(root string) ([]usbDevice, error) {
+	dirs, err := ioutil.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var devices []usbDevice
+	for _, d := range dirs {
+		if strings.HasPrefix(d.Name(), "usb") || strings.Contains(d.Name(), ":") {
+			continue
+		}
+		devPath := path.Join(root, d.Name())
+		dev := usbDevice{port: d.Name()}
+		for file, v := range map[string]*string{
+			"idVendor":     &dev.vendorID,
+			"idProduct":    &dev.productID,
+			"bDeviceClass": &dev.class,
+			"speed":        &dev.speed,
+		} {
+			if *v, err = readStringFromFile(path.Join(devPath, file)); err != nil {
+				return nil, err
+			}
+		}
+		// String descriptors are optional.
+		dev.manufacturer, _ = readStringFromFile(path.Join(devPath, "manufacturer"))
+		dev.product, _ = readStringFromFile(path.Join(devPath, "product"))
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestUSBDevices(t *testing.T) {
+	devices, err := readUSBDevices("fixtures/sys/bus/usb/devices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The root hub usb1 and interface 1-1:1.0 are skipped.
+	want := []usbDevice{
+		{port: "1-1", vendorID: "0bda", productID: "5411", class: "09", speed: "480", manufacturer: "Generic", product: "4-Port USB 2.0 Hub"},
+		{port: "1-1.4", vendorID: "096e", productID: "0006", class: "00", speed: "12"},
+	}
+	if want, got := len(want), len(devices); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+	for i, w := range want {
+		if got := devices[i]; w != got {
+			t.Errorf("want %+v, got %+v", w, got)
+		}
+	}
+}
@@ -17,8 +17,10 @@ package collector
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -29,7 +31,13 @@ const (
 	vmStatSubsystem = "vmstat"
 )
 
-type vmStatCollector struct{}
+var vmStatFields = flag.String("collector.vmstat.fields",
+	"^(oom_kill|pgpg|pswp|pg.*fault|pgscan|pgsteal|thp_|compact_)",
+	"Regexp of fields of /proc/vmstat to expose.")
+
+type vmStatCollector struct {
+	fieldPattern *regexp.Regexp
+}
 
 func init() {
 	Factories["vmstat"] = NewvmStatCollector
@@ -38,7 +46,11 @@ func init() {
 // Takes a prometheus registry and returns a new Collector exposing
 // vmstat stats.
 func NewvmStatCollector() (Collector, error) {
-	return &vmStatCollector{}, nil
+	pattern, err := regexp.Compile(*vmStatFields)
+	if err != nil {
+		return nil, fmt.Errorf("invalid collector.vmstat.fields: %s", err)
+	}
+	return &vmStatCollector{fieldPattern: pattern}, nil
 }
 
 func (c *vmStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
@@ -51,6 +63,9 @@ func (c *vmStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 || !c.fieldPattern.MatchString(parts[0]) {
+			continue
+		}
 		value, err := strconv.ParseFloat(parts[1], 64)
 		if err != nil {
 			return err
@@ -65,5 +80,5 @@ func (c *vmStatCollector) Update(ch chan<- prometheus.Metric) (err error) {
 			value,
 		)
 	}
-	return err
+	return scanner.Err()
 }
@@ -0,0 +1,416 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowifi
+
+package collector
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	wifiSubsystem = "wifi"
+
+	// Generic netlink controller, include/uapi/linux/genetlink.h.
+	genlIDCtrl           = 0x10
+	genlHdrLen           = 4
+	ctrlCmdGetFamily     = 3
+	ctrlAttrFamilyID     = 1
+	ctrlAttrFamilyName   = 2
+	nl80211GenlName      = "nl80211"
+	nl80211GenlVersion   = 1
+	nl80211CmdGetIface   = 5
+	nl80211CmdGetStation = 17
+
+	// Attributes from include/uapi/linux/nl80211.h.
+	nl80211AttrIfindex = 3
+	nl80211AttrIfname  = 4
+	nl80211AttrIftype  = 5
+	nl80211AttrMAC     = 6
+	nl80211AttrStaInfo = 21
+
+	nl80211StaInfoInactiveTime  = 1
+	nl80211StaInfoRxBytes       = 2
+	nl80211StaInfoTxBytes       = 3
+	nl80211StaInfoSignal        = 7
+	nl80211StaInfoTxBitrate     = 8
+	nl80211StaInfoRxPackets     = 9
+	nl80211StaInfoTxPackets     = 10
+	nl80211StaInfoTxRetries     = 11
+	nl80211StaInfoTxFailed      = 12
+	nl80211StaInfoRxBitrate     = 14
+	nl80211StaInfoConnectedTime = 16
+	nl80211StaInfoBeaconLoss    = 18
+	nl80211StaInfoRxBytes64     = 23
+	nl80211StaInfoTxBytes64     = 24
+
+	nl80211RateInfoBitrate   = 1
+	nl80211RateInfoBitrate32 = 5
+)
+
+// Values of NL80211_ATTR_IFTYPE.
+var wifiIfaceTypes = []string{"unspecified", "adhoc", "station", "ap", "ap_vlan", "wds", "monitor", "mesh_point", "p2p_client", "p2p_go", "p2p_device", "ocb", "nan"}
+
+type wifiInterface struct {
+	index       uint32
+	name, kind  string
+	hardwareMAC string
+}
+
+type wifiStation struct {
+	mac string
+	// Bitrates are in bits per second, times in seconds.
+	signal, txBitrate, rxBitrate           float64
+	connected, inactive                    float64
+	rxBytes, txBytes, rxPackets, txPackets float64
+	txRetries, txFailed, beaconLoss        float64
+}
+
+type wifiCollector struct {
+	info, stations                         typedDesc
+	signal, txBitrate, rxBitrate           typedDesc
+	connected, inactive                    typedDesc
+	rxBytes, txBytes, rxPackets, txPackets typedDesc
+	txRetries, txFailed, beaconLoss        typedDesc
+}
+
+func init() {
+	Factories["wifi"] = NewWifiCollector
+}
+
+// NewWifiCollector returns a new Collector exposing the stations of wireless
+// interfaces, read from nl80211.
+func NewWifiCollector() (Collector, error) {
+	labels := []string{"device", "station"}
+	desc := func(name, help string, valueType prometheus.ValueType) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, wifiSubsystem, name),
+			help, labels, nil,
+		), valueType}
+	}
+	return &wifiCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, wifiSubsystem, "interface_info"),
+			"Type and hardware address of the wireless interface, value is always 1.",
+			[]string{"device", "type", "mac"}, nil,
+		), prometheus.GaugeValue},
+		stations: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, wifiSubsystem, "stations"),
+			"Number of stations associated with the interface, the access point for clients.",
+			[]string{"device"}, nil,
+		), prometheus.GaugeValue},
+		signal:     desc("station_signal_dbm", "Signal strength of the last frame received from the station.", prometheus.GaugeValue),
+		txBitrate:  desc("station_transmit_bitrate_bits_per_second", "Bitrate of the last frame sent to the station.", prometheus.GaugeValue),
+		rxBitrate:  desc("station_receive_bitrate_bits_per_second", "Bitrate of the last frame received from the station.", prometheus.GaugeValue),
+		connected:  desc("station_connected_seconds", "Seconds the station has been connected.", prometheus.GaugeValue),
+		inactive:   desc("station_inactive_seconds", "Seconds since the last activity of the station.", prometheus.GaugeValue),
+		rxBytes:    desc("station_receive_bytes_total", "Bytes received from the station.", prometheus.CounterValue),
+		txBytes:    desc("station_transmit_bytes_total", "Bytes sent to the station.", prometheus.CounterValue),
+		rxPackets:  desc("station_receive_packets_total", "Packets received from the station.", prometheus.CounterValue),
+		txPackets:  desc("station_transmit_packets_total", "Packets sent to the station.", prometheus.CounterValue),
+		txRetries:  desc("station_transmit_retries_total", "Retries of frames sent to the station.", prometheus.CounterValue),
+		txFailed:   desc("station_transmit_failed_total", "Frames sent to the station that failed.", prometheus.CounterValue),
+		beaconLoss: desc("station_beacon_loss_total", "Beacons of the station that were lost.", prometheus.CounterValue),
+	}, nil
+}
+
+func (c *wifiCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	conn, err := newGenlConn()
+	if err != nil {
+		return fmt.Errorf("couldn't open generic netlink socket: %s", err)
+	}
+	defer conn.close()
+
+	family, err := conn.family(nl80211GenlName)
+	if err == syscall.ENOENT {
+		log.Debugf("No nl80211 support, skipping wifi collector")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't resolve nl80211 family: %s", err)
+	}
+
+	msgs, err := conn.execute(family, syscall.NLM_F_DUMP, nl80211CmdGetIface, nil)
+	if err != nil {
+		return fmt.Errorf("couldn't get wireless interfaces: %s", err)
+	}
+	ifaces, err := parseWifiInterfaces(msgs)
+	if err != nil {
+		return fmt.Errorf("couldn't parse wireless interfaces: %s", err)
+	}
+
+	for _, iface := range ifaces {
+		ch <- c.info.mustNewConstMetric(1, iface.name, iface.kind, iface.hardwareMAC)
+
+		index := make([]byte, 4)
+		nativeEndian.PutUint32(index, iface.index)
+		msgs, err := conn.execute(family, syscall.NLM_F_DUMP, nl80211CmdGetStation, netlinkAttr(nl80211AttrIfindex, index))
+		if err != nil {
+			return fmt.Errorf("couldn't get stations of %s: %s", iface.name, err)
+		}
+		stations, err := parseWifiStations(msgs)
+		if err != nil {
+			return fmt.Errorf("couldn't parse stations of %s: %s", iface.name, err)
+		}
+		ch <- c.stations.mustNewConstMetric(float64(len(stations)), iface.name)
+		for _, s := range stations {
+			ch <- c.signal.mustNewConstMetric(s.signal, iface.name, s.mac)
+			ch <- c.txBitrate.mustNewConstMetric(s.txBitrate, iface.name, s.mac)
+			ch <- c.rxBitrate.mustNewConstMetric(s.rxBitrate, iface.name, s.mac)
+			ch <- c.connected.mustNewConstMetric(s.connected, iface.name, s.mac)
+			ch <- c.inactive.mustNewConstMetric(s.inactive, iface.name, s.mac)
+			ch <- c.rxBytes.mustNewConstMetric(s.rxBytes, iface.name, s.mac)
+			ch <- c.txBytes.mustNewConstMetric(s.txBytes, iface.name, s.mac)
+			ch <- c.rxPackets.mustNewConstMetric(s.rxPackets, iface.name, s.mac)
+			ch <- c.txPackets.mustNewConstMetric(s.txPackets, iface.name, s.mac)
+			ch <- c.txRetries.mustNewConstMetric(s.txRetries, iface.name, s.mac)
+			ch <- c.txFailed.mustNewConstMetric(s.txFailed, iface.name, s.mac)
+			ch <- c.beaconLoss.mustNewConstMetric(s.beaconLoss, iface.name, s.mac)
+		}
+	}
+	return nil
+}
+
+// parseWifiInterfaces parses the replies to NL80211_CMD_GET_INTERFACE.
+func parseWifiInterfaces(msgs []syscall.NetlinkMessage) ([]wifiInterface, error) {
+	var ifaces []wifiInterface
+	for _, m := range msgs {
+		if len(m.Data) < genlHdrLen {
+			return nil, fmt.Errorf("short message of %d bytes", len(m.Data))
+		}
+		var iface wifiInterface
+		for _, a := range parseNetlinkAttrs(m.Data[genlHdrLen:]) {
+			switch a.typ {
+			case nl80211AttrIfindex:
+				if len(a.value) >= 4 {
+					iface.index = nativeEndian.Uint32(a.value)
+				}
+			case nl80211AttrIfname:
+				iface.name = cString(a.value)
+			case nl80211AttrIftype:
+				if len(a.value) >= 4 {
+					if t := int(nativeEndian.Uint32(a.value)); t < len(wifiIfaceTypes) {
+						iface.kind = wifiIfaceTypes[t]
+					}
+				}
+			case nl80211AttrMAC:
+				iface.hardwareMAC = net.HardwareAddr(a.value).String()
+			}
+		}
+		// P2P devices have no netdev.
+		if iface.name == "" {
+			continue
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// parseWifiStations parses the replies to NL80211_CMD_GET_STATION.
+func parseWifiStations(msgs []syscall.NetlinkMessage) ([]wifiStation, error) {
+	var stations []wifiStation
+	for _, m := range msgs {
+		if len(m.Data) < genlHdrLen {
+			return nil, fmt.Errorf("short message of %d bytes", len(m.Data))
+		}
+		var s wifiStation
+		for _, a := range parseNetlinkAttrs(m.Data[genlHdrLen:]) {
+			switch a.typ {
+			case nl80211AttrMAC:
+				s.mac = net.HardwareAddr(a.value).String()
+			case nl80211AttrStaInfo:
+				parseWifiStaInfo(&s, a.value)
+			}
+		}
+		stations = append(stations, s)
+	}
+	return stations, nil
+}
+
+func parseWifiStaInfo(s *wifiStation, b []byte) {
+	// The 64-bit byte counters follow the 32-bit ones where supported.
+	var rxBytes64, txBytes64 bool
+	for _, a := range parseNetlinkAttrs(b) {
+		u32 := func() float64 {
+			if len(a.value) < 4 {
+				return 0
+			}
+			return float64(nativeEndian.Uint32(a.value))
+		}
+		switch a.typ {
+		case nl80211StaInfoInactiveTime:
+			s.inactive = u32() / 1000
+		case nl80211StaInfoConnectedTime:
+			s.connected = u32()
+		case nl80211StaInfoSignal:
+			if len(a.value) >= 1 {
+				s.signal = float64(int8(a.value[0]))
+			}
+		case nl80211StaInfoTxBitrate:
+			s.txBitrate = parseWifiBitrate(a.value)
+		case nl80211StaInfoRxBitrate:
+			s.rxBitrate = parseWifiBitrate(a.value)
+		case nl80211StaInfoRxBytes:
+			if !rxBytes64 {
+				s.rxBytes = u32()
+			}
+		case nl80211StaInfoTxBytes:
+			if !txBytes64 {
+				s.txBytes = u32()
+			}
+		case nl80211StaInfoRxBytes64:
+			if len(a.value) >= 8 {
+				s.rxBytes, rxBytes64 = float64(nativeEndian.Uint64(a.value)), true
+			}
+		case nl80211StaInfoTxBytes64:
+			if len(a.value) >= 8 {
+				s.txBytes, txBytes64 = float64(nativeEndian.Uint64(a.value)), true
+			}
+		case nl80211StaInfoRxPackets:
+			s.rxPackets = u32()
+		case nl80211StaInfoTxPackets:
+			s.txPackets = u32()
+		case nl80211StaInfoTxRetries:
+			s.txRetries = u32()
+		case nl80211StaInfoTxFailed:
+			s.txFailed = u32()
+		case nl80211StaInfoBeaconLoss:
+			s.beaconLoss = u32()
+		}
+	}
+}
+
+// parseWifiBitrate returns the bitrate of a nested rate info in bits per
+// second, the kernel reports units of 100 kbit/s.
+func parseWifiBitrate(b []byte) float64 {
+	var rate float64
+	for _, a := range parseNetlinkAttrs(b) {
+		switch {
+		case a.typ == nl80211RateInfoBitrate32 && len(a.value) >= 4:
+			return float64(nativeEndian.Uint32(a.value)) * 100000
+		case a.typ == nl80211RateInfoBitrate && len(a.value) >= 2:
+			rate = float64(nativeEndian.Uint16(a.value)) * 100000
+		}
+	}
+	return rate
+}
+
+// genlConn is a generic netlink socket. The syscall package only provides
+// requests to the route family.
+type genlConn struct {
+	fd  int
+	seq uint32
+}
+
+func newGenlConn() (*genlConn, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, syscall.NETLINK_GENERIC)
+	if err != nil {
+		return nil, err
+	}
+	// Don't block scrapes forever on a kernel that doesn't reply.
+	tv := syscall.Timeval{Sec: 5}
+	if err := syscall.SetsockoptTimeval(fd, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return &genlConn{fd: fd}, nil
+}
+
+func (c *genlConn) close() {
+	syscall.Close(c.fd)
+}
+
+// family resolves the id of a generic netlink family.
+func (c *genlConn) family(name string) (uint16, error) {
+	msgs, err := c.execute(genlIDCtrl, 0, ctrlCmdGetFamily, netlinkAttr(ctrlAttrFamilyName, append([]byte(name), 0)))
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range msgs {
+		if len(m.Data) < genlHdrLen {
+			continue
+		}
+		for _, a := range parseNetlinkAttrs(m.Data[genlHdrLen:]) {
+			if a.typ == ctrlAttrFamilyID && len(a.value) >= 2 {
+				return nativeEndian.Uint16(a.value), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no id in reply for family %s", name)
+}
+
+// execute sends a command and returns the replies, all parts of them for
+// dumps.
+func (c *genlConn) execute(family, flags uint16, cmd uint8, attrs []byte) ([]syscall.NetlinkMessage, error) {
+	c.seq++
+	req := make([]byte, syscall.NLMSG_HDRLEN+genlHdrLen, syscall.NLMSG_HDRLEN+genlHdrLen+len(attrs))
+	req = append(req, attrs...)
+	hdr := (*syscall.NlMsghdr)(unsafe.Pointer(&req[0]))
+	hdr.Len = uint32(len(req))
+	hdr.Type = family
+	hdr.Flags = syscall.NLM_F_REQUEST | flags
+	hdr.Seq = c.seq
+	req[syscall.NLMSG_HDRLEN] = cmd
+	req[syscall.NLMSG_HDRLEN+1] = nl80211GenlVersion
+	if err := syscall.Sendto(c.fd, req, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+
+	var replies []syscall.NetlinkMessage
+	buf := make([]byte, os.Getpagesize()*8)
+	for {
+		n, _, err := syscall.Recvfrom(c.fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			if m.Header.Seq != c.seq {
+				continue
+			}
+			switch m.Header.Type {
+			case syscall.NLMSG_DONE:
+				return replies, nil
+			case syscall.NLMSG_ERROR:
+				if len(m.Data) < 4 {
+					return nil, fmt.Errorf("short netlink error of %d bytes", len(m.Data))
+				}
+				if errno := int32(nativeEndian.Uint32(m.Data)); errno != 0 {
+					return nil, syscall.Errno(-errno)
+				}
+				return replies, nil
+			}
+			// Copy the message, buf is reused for the next parts.
+			m.Data = append([]byte(nil), m.Data...)
+			replies = append(replies, m)
+			if m.Header.Flags&syscall.NLM_F_MULTI == 0 {
+				return replies, nil
+			}
+		}
+	}
+}
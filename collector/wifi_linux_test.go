@@ -0,0 +1,121 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseWifi(t *testing.T) {
+	genl := func(attrs ...[]byte) syscall.NetlinkMessage {
+		data := make([]byte, genlHdrLen)
+		for _, a := range attrs {
+			data = append(data, a...)
+		}
+		return syscall.NetlinkMessage{Data: data}
+	}
+	nested := func(typ uint16, attrs ...[]byte) []byte {
+		var b []byte
+		for _, a := range attrs {
+			b = append(b, a...)
+		}
+		return netlinkAttr(typ, b)
+	}
+	u16 := func(v uint16) []byte {
+		b := make([]byte, 2)
+		nativeEndian.PutUint16(b, v)
+		return b
+	}
+	u32 := func(v uint32) []byte {
+		b := make([]byte, 4)
+		nativeEndian.PutUint32(b, v)
+		return b
+	}
+	u64 := func(v uint64) []byte {
+		b := make([]byte, 8)
+		nativeEndian.PutUint64(b, v)
+		return b
+	}
+	mac := []byte{0x02, 0x00, 0x5e, 0x10, 0x00, 0x01}
+
+	ifaces, err := parseWifiInterfaces([]syscall.NetlinkMessage{
+		genl(
+			netlinkAttr(nl80211AttrIfindex, u32(3)),
+			netlinkAttr(nl80211AttrIfname, []byte("wlan0\x00")),
+			netlinkAttr(nl80211AttrIftype, u32(3)),
+			netlinkAttr(nl80211AttrMAC, mac),
+		),
+		// P2P device without a netdev.
+		genl(netlinkAttr(nl80211AttrIftype, u32(10))),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(ifaces); want != got {
+		t.Fatalf("want %d interfaces, got %d", want, got)
+	}
+	if want, got := (wifiInterface{3, "wlan0", "ap", "02:00:5e:10:00:01"}), ifaces[0]; want != got {
+		t.Errorf("want interface %+v, got %+v", want, got)
+	}
+
+	stations, err := parseWifiStations([]syscall.NetlinkMessage{
+		genl(
+			netlinkAttr(nl80211AttrMAC, mac),
+			nested(nl80211AttrStaInfo,
+				netlinkAttr(nl80211StaInfoInactiveTime, u32(1500)),
+				netlinkAttr(nl80211StaInfoRxBytes, u32(1024)),
+				netlinkAttr(nl80211StaInfoRxBytes64, u64(1<<33)),
+				netlinkAttr(nl80211StaInfoTxBytes, u32(2048)),
+				netlinkAttr(nl80211StaInfoSignal, []byte{0xc4}),
+				nested(nl80211StaInfoTxBitrate, netlinkAttr(nl80211RateInfoBitrate, u16(1300))),
+				nested(nl80211StaInfoRxBitrate,
+					netlinkAttr(nl80211RateInfoBitrate, u16(0xffff)),
+					netlinkAttr(nl80211RateInfoBitrate32, u32(24019)),
+				),
+				netlinkAttr(nl80211StaInfoTxRetries, u32(17)),
+				netlinkAttr(nl80211StaInfoTxFailed, u32(2)),
+				netlinkAttr(nl80211StaInfoConnectedTime, u32(3600)),
+			),
+		),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1, len(stations); want != got {
+		t.Fatalf("want %d stations, got %d", want, got)
+	}
+	s := stations[0]
+	for _, c := range []struct {
+		name      string
+		want, got float64
+	}{
+		{"signal", -60, s.signal},
+		{"transmit bitrate", 130e6, s.txBitrate},
+		{"receive bitrate", 2401.9e6, s.rxBitrate},
+		{"receive bytes", 1 << 33, s.rxBytes},
+		{"transmit bytes", 2048, s.txBytes},
+		{"retries", 17, s.txRetries},
+		{"failed", 2, s.txFailed},
+		{"connected", 3600, s.connected},
+		{"inactive", 1.5, s.inactive},
+	} {
+		if c.want != c.got {
+			t.Errorf("want %s %f, got %f", c.name, c.want, c.got)
+		}
+	}
+	if want, got := "02:00:5e:10:00:01", s.mac; want != got {
+		t.Errorf("want station %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nowriteback
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const writebackSubsystem = "writeback"
+
+// writebackStat maps a counter of the kernel to a metric. Values are in
+// pages in /proc/vmstat and in KiB in the bdi stats.
+type writebackStat struct {
+	key, name, help string
+	valueType       prometheus.ValueType
+}
+
+var (
+	writebackVmstats = []writebackStat{
+		{"nr_dirty", "dirty_bytes", "Page cache waiting to be written back.", prometheus.GaugeValue},
+		{"nr_writeback", "writeback_bytes", "Page cache being written back.", prometheus.GaugeValue},
+		{"nr_dirty_threshold", "dirty_threshold_bytes", "Dirty page cache above which writers are throttled.", prometheus.GaugeValue},
+		{"nr_dirty_background_threshold", "dirty_background_threshold_bytes", "Dirty page cache above which background writeback starts.", prometheus.GaugeValue},
+		{"nr_dirtied", "dirtied_bytes_total", "Page cache dirtied.", prometheus.CounterValue},
+		{"nr_written", "written_bytes_total", "Page cache written back.", prometheus.CounterValue},
+	}
+
+	// Fields of the bdi stats in debugfs, see mm/backing-dev.c.
+	writebackBdiStats = []writebackStat{
+		{"BdiWriteback", "bdi_writeback_bytes", "Page cache of the backing device being written back.", prometheus.GaugeValue},
+		{"BdiReclaimable", "bdi_reclaimable_bytes", "Dirty page cache of the backing device.", prometheus.GaugeValue},
+		{"BdiDirtyThresh", "bdi_dirty_threshold_bytes", "Share of the dirty threshold of the backing device, based on its writeback bandwidth.", prometheus.GaugeValue},
+		{"BdiDirtied", "bdi_dirtied_bytes_total", "Page cache of the backing device dirtied.", prometheus.CounterValue},
+		{"BdiWritten", "bdi_written_bytes_total", "Page cache of the backing device written back.", prometheus.CounterValue},
+		{"BdiWriteBandwidth", "bdi_write_bandwidth_bytes_per_second", "Writeback bandwidth of the backing device estimated by the kernel.", prometheus.GaugeValue},
+	}
+)
+
+type writebackCollector struct {
+	vmstat, bdi []typedDesc
+}
+
+func init() {
+	Factories["writeback"] = NewWritebackCollector
+}
+
+// NewWritebackCollector returns a new Collector exposing dirty page cache,
+// the thresholds at which it is written back and the writeback bandwidth of
+// each backing device.
+func NewWritebackCollector() (Collector, error) {
+	c := &writebackCollector{}
+	for _, s := range writebackVmstats {
+		c.vmstat = append(c.vmstat, typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, writebackSubsystem, s.name),
+			s.help, nil, nil,
+		), s.valueType})
+	}
+	for _, s := range writebackBdiStats {
+		c.bdi = append(c.bdi, typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, writebackSubsystem, s.name),
+			s.help, []string{"bdi", "device"}, nil,
+		), s.valueType})
+	}
+	return c, nil
+}
+
+func (c *writebackCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	file, err := os.Open(procFilePath("vmstat"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	vmstat, err := parseWritebackStats(file)
+	if err != nil {
+		return fmt.Errorf("couldn't parse vmstat: %s", err)
+	}
+	pageSize := float64(os.Getpagesize())
+	for i, s := range writebackVmstats {
+		if v, ok := vmstat[s.key]; ok {
+			ch <- c.vmstat[i].mustNewConstMetric(v * pageSize)
+		}
+	}
+
+	// The per device stats are only in debugfs, which is usually readable
+	// by root only.
+	dirs, err := ioutil.ReadDir(sysFilePath("kernel/debug/bdi"))
+	if err != nil {
+		log.Debugf("Couldn't read bdi stats from debugfs: %s", err)
+		return nil
+	}
+	for _, dir := range dirs {
+		bdi := dir.Name()
+		stats, err := readBdiStats(filepath.Join(sysFilePath("kernel/debug/bdi"), bdi, "stats"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("couldn't get stats of bdi %s: %s", bdi, err)
+		}
+		device := bdiDevice(sysFilePath("dev/block"), bdi)
+		for i, s := range writebackBdiStats {
+			if v, ok := stats[s.key]; ok {
+				ch <- c.bdi[i].mustNewConstMetric(v*1024, bdi, device)
+			}
+		}
+	}
+	return nil
+}
+
+func readBdiStats(path string) (map[string]float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseWritebackStats(file)
+}
+
+// parseWritebackStats parses lines of a name and a value, as in /proc/vmstat.
+// In the bdi stats names end in a colon and values may be followed by a
+// unit.
+func parseWritebackStats(r io.Reader) (map[string]float64, error) {
+	stats := map[string]float64{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 2 || len(parts) > 3 {
+			continue
+		}
+		v, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in line: %s", scanner.Text())
+		}
+		stats[strings.TrimSuffix(parts[0], ":")] = v
+	}
+	return stats, scanner.Err()
+}
+
+// bdiDevice returns the block device of a bdi named by major:minor number
+// from the links in root, and an empty string for bdis without one, e.g. of
+// network filesystems.
+func bdiDevice(root, bdi string) string {
+	target, err := filepath.EvalSymlinks(filepath.Join(root, bdi))
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(target)
+}
@@ -0,0 +1,38 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import "testing"
+
+func TestBdiStats(t *testing.T) {
+	stats, err := readBdiStats("fixtures/sys/kernel/debug/bdi/8:0/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]float64{
+		"BdiWriteback":      256,
+		"BdiReclaimable":    12480,
+		"BdiWriteBandwidth": 102400,
+		"b_dirty":           14,
+	} {
+		if got := stats[key]; want != got {
+			t.Errorf("want %s %f, got %f", key, want, got)
+		}
+	}
+
+	if want, got := "sda", bdiDevice("fixtures/sys/dev/block", "8:0"); want != got {
+		t.Errorf("want bdi 8:0 on %s, got %q", want, got)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noxdp
+
+package collector
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+const (
+	xdpSubsystem = "xdp"
+
+	// Link attributes from include/uapi/linux/if_link.h.
+	iflaXDP         = 43
+	iflaXDPAttached = 2
+	iflaXDPProgID   = 4
+
+	// ethtool ioctl commands from include/uapi/linux/ethtool.h.
+	siocEthtool       = 0x8946
+	ethtoolGDrvInfo   = 0x03
+	ethtoolGStrings   = 0x1b
+	ethtoolGStats     = 0x1d
+	ethSSStats        = 1
+	ethGStringLen     = 32
+	ethtoolDrvInfoLen = 196
+	// Offset of n_stats in struct ethtool_drvinfo.
+	ethtoolNStatsOffset = 180
+)
+
+// Values of IFLA_XDP_ATTACHED.
+var xdpAttachModes = []string{"none", "native", "generic", "offload", "multi"}
+
+type xdpLink struct {
+	device string
+	mode   string
+	progID uint32
+}
+
+type xdpCollector struct {
+	attached, stats typedDesc
+}
+
+func init() {
+	Factories["xdp"] = NewXDPCollector
+}
+
+// NewXDPCollector returns a new Collector exposing attached XDP programs and
+// XDP statistics of network drivers.
+func NewXDPCollector() (Collector, error) {
+	return &xdpCollector{
+		attached: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xdpSubsystem, "program_attached"),
+			"XDP program attached to the network device, value is always 1.",
+			[]string{"device", "mode", "program_id"}, nil,
+		), prometheus.GaugeValue},
+		stats: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xdpSubsystem, "driver_stat_total"),
+			"XDP statistic reported by the network driver through ethtool.",
+			[]string{"device", "stat"}, nil,
+		), prometheus.CounterValue},
+	}, nil
+}
+
+func (c *xdpCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	links, err := getXDPLinks()
+	if err != nil {
+		return fmt.Errorf("couldn't get links: %s", err)
+	}
+	for _, link := range links {
+		if link.mode != "none" {
+			ch <- c.attached.mustNewConstMetric(1, link.device, link.mode, strconv.FormatUint(uint64(link.progID), 10))
+		}
+
+		stats, err := getEthtoolStats(link.device)
+		if err != nil {
+			// Most virtual devices don't support ethtool statistics.
+			log.Debugf("Couldn't get ethtool stats of %s: %s", link.device, err)
+			continue
+		}
+		for name, value := range stats {
+			if strings.Contains(strings.ToLower(name), "xdp") {
+				ch <- c.stats.mustNewConstMetric(float64(value), link.device, name)
+			}
+		}
+	}
+	return nil
+}
+
+func getXDPLinks() ([]xdpLink, error) {
+	tab, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(tab)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []xdpLink
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWLINK {
+			continue
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			return nil, err
+		}
+		link := xdpLink{mode: xdpAttachModes[0]}
+		for _, a := range attrs {
+			switch a.Attr.Type & nlaTypeMask {
+			case syscall.IFLA_IFNAME:
+				link.device = cString(a.Value)
+			case iflaXDP:
+				link.mode, link.progID = parseXDPAttr(a.Value)
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// parseXDPAttr parses the attributes nested in IFLA_XDP.
+func parseXDPAttr(b []byte) (mode string, progID uint32) {
+	mode = xdpAttachModes[0]
+	for _, a := range parseNetlinkAttrs(b) {
+		switch {
+		case a.typ == iflaXDPAttached && len(a.value) >= 1:
+			if int(a.value[0]) < len(xdpAttachModes) {
+				mode = xdpAttachModes[a.value[0]]
+			}
+		case a.typ == iflaXDPProgID && len(a.value) >= 4:
+			progID = nativeEndian.Uint32(a.value)
+		}
+	}
+	return mode, progID
+}
+
+// getEthtoolStats returns the statistics the driver of the device reports
+// with `ethtool -S`.
+func getEthtoolStats(device string) (map[string]uint64, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer syscall.Close(fd)
+
+	drvinfo := make([]byte, ethtoolDrvInfoLen)
+	nativeEndian.PutUint32(drvinfo, ethtoolGDrvInfo)
+	if err := ethtoolIoctl(fd, device, drvinfo); err != nil {
+		return nil, err
+	}
+	n := int(nativeEndian.Uint32(drvinfo[ethtoolNStatsOffset:]))
+	if n == 0 {
+		return nil, nil
+	}
+
+	strs := make([]byte, 12+n*ethGStringLen)
+	nativeEndian.PutUint32(strs, ethtoolGStrings)
+	nativeEndian.PutUint32(strs[4:], ethSSStats)
+	nativeEndian.PutUint32(strs[8:], uint32(n))
+	if err := ethtoolIoctl(fd, device, strs); err != nil {
+		return nil, err
+	}
+
+	values := make([]byte, 8+n*8)
+	nativeEndian.PutUint32(values, ethtoolGStats)
+	nativeEndian.PutUint32(values[4:], uint32(n))
+	if err := ethtoolIoctl(fd, device, values); err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]uint64, n)
+	for i := 0; i < n; i++ {
+		name := cString(strs[12+i*ethGStringLen : 12+(i+1)*ethGStringLen])
+		stats[name] = nativeEndian.Uint64(values[8+i*8:])
+	}
+	return stats, nil
+}
+
+func ethtoolIoctl(fd int, device string, data []byte) error {
+	// struct ifreq: the interface name followed by a union, of which
+	// ifr_data points to the ethtool command.
+	var ifr [40]byte
+	copy(ifr[:syscall.IFNAMSIZ-1], device)
+	*(*uintptr)(unsafe.Pointer(&ifr[syscall.IFNAMSIZ])) = uintptr(unsafe.Pointer(&data[0]))
+	_, _, e := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), siocEthtool, uintptr(unsafe.Pointer(&ifr)))
+	runtime.KeepAlive(data)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestParseXDPAttr(t *testing.T) {
+	// IFLA_XDP_ATTACHED (u8, padded) followed by IFLA_XDP_PROG_ID (u32).
+	b := make([]byte, 16)
+	nativeEndian.PutUint16(b[0:], 5)
+	nativeEndian.PutUint16(b[2:], iflaXDPAttached)
+	b[4] = 2
+	nativeEndian.PutUint16(b[8:], 8)
+	nativeEndian.PutUint16(b[10:], iflaXDPProgID)
+	nativeEndian.PutUint32(b[12:], 42)
+
+	mode, progID := parseXDPAttr(b)
+	if want, got := "generic", mode; want != got {
+		t.Errorf("want mode %s, got %s", want, got)
+	}
+	if want, got := uint32(42), progID; want != got {
+		t.Errorf("want program id %d, got %d", want, got)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !noxen
+
+package collector
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	xenSubsystem = "xen"
+
+	xenSectorSize = 512
+)
+
+var (
+	xlCommand = flag.String("collector.xen.command", "xl", "Command to run xl of the Xen toolstack.")
+)
+
+type xenDomain struct {
+	name    string
+	id      string
+	memory  float64
+	vcpus   float64
+	cpuTime float64
+}
+
+type xenCollector struct {
+	cli                string
+	cpu, memory, vcpus typedDesc
+	vbdStats, vifStats []xenDeviceStat
+}
+
+// xenDeviceStat is a counter of the backend devices of a domain in sysfs.
+type xenDeviceStat struct {
+	file  string
+	desc  *typedDesc
+	scale float64
+}
+
+func init() {
+	Factories["xen"] = NewXenCollector
+}
+
+// NewXenCollector returns a new Collector exposing the CPU, memory and
+// virtual block and network devices of the domains of a Xen dom0.
+func NewXenCollector() (Collector, error) {
+	c := &xenCollector{
+		cli: *xlCommand,
+		cpu: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xenSubsystem, "domain_cpu_seconds_total"),
+			"CPU time used by the domain.",
+			[]string{"domain"}, nil,
+		), prometheus.CounterValue},
+		memory: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xenSubsystem, "domain_memory_bytes"),
+			"Memory allocated to the domain.",
+			[]string{"domain"}, nil,
+		), prometheus.GaugeValue},
+		vcpus: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xenSubsystem, "domain_vcpus"),
+			"Number of virtual CPUs of the domain.",
+			[]string{"domain"}, nil,
+		), prometheus.GaugeValue},
+	}
+	for _, s := range []struct {
+		file, name, help string
+		scale            float64
+	}{
+		{"rd_req", "read_requests_total", "Read requests of the virtual block device.", 1},
+		{"rd_sect", "read_bytes_total", "Bytes read from the virtual block device.", xenSectorSize},
+		{"wr_req", "write_requests_total", "Write requests of the virtual block device.", 1},
+		{"wr_sect", "written_bytes_total", "Bytes written to the virtual block device.", xenSectorSize},
+		{"f_req", "flush_requests_total", "Flush requests of the virtual block device.", 1},
+		{"oo_req", "out_of_requests_total", "Times the backend of the virtual block device ran out of requests.", 1},
+	} {
+		c.vbdStats = append(c.vbdStats, xenDeviceStat{path.Join("statistics", s.file), &typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xenSubsystem, "vbd_"+s.name),
+			s.help, []string{"domain", "device"}, nil,
+		), prometheus.CounterValue}, s.scale})
+	}
+	// The backend interface receives what the domain transmits.
+	for _, s := range []struct{ file, name, help string }{
+		{"rx_bytes", "transmit_bytes_total", "Bytes transmitted by the domain on the virtual interface."},
+		{"rx_packets", "transmit_packets_total", "Packets transmitted by the domain on the virtual interface."},
+		{"tx_bytes", "receive_bytes_total", "Bytes received by the domain on the virtual interface."},
+		{"tx_packets", "receive_packets_total", "Packets received by the domain on the virtual interface."},
+		{"tx_dropped", "receive_drop_total", "Packets for the domain dropped by the backend of the virtual interface."},
+	} {
+		c.vifStats = append(c.vifStats, xenDeviceStat{path.Join("statistics", s.file), &typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, xenSubsystem, "vif_"+s.name),
+			s.help, []string{"domain", "device"}, nil,
+		), prometheus.CounterValue}, 1})
+	}
+	return c, nil
+}
+
+func (c *xenCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	cmd := exec.Command(c.cli, "list")
+	pipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	domains, err := parseXLList(pipe)
+	if err != nil {
+		// Stop xl, which may still be writing, and reap it.
+		cmd.Process.Kill()
+		cmd.Wait()
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		return err
+	}
+
+	for _, d := range domains {
+		ch <- c.cpu.mustNewConstMetric(d.cpuTime, d.name)
+		ch <- c.memory.mustNewConstMetric(d.memory, d.name)
+		ch <- c.vcpus.mustNewConstMetric(d.vcpus, d.name)
+
+		// Backends are named vbd-<domid>-<devid> and vif<domid>.<n>.
+		vbds, err := filepath.Glob(sysFilePath(fmt.Sprintf("bus/xen-backend/devices/vbd-%s-*", d.id)))
+		if err != nil {
+			return err
+		}
+		if err := c.collectDevices(ch, d.name, vbds, "vbd-"+d.id+"-", c.vbdStats); err != nil {
+			return err
+		}
+		vifs, err := filepath.Glob(sysFilePath(fmt.Sprintf("class/net/vif%s.*", d.id)))
+		if err != nil {
+			return err
+		}
+		if err := c.collectDevices(ch, d.name, vifs, "", c.vifStats); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *xenCollector) collectDevices(ch chan<- prometheus.Metric, domain string, devices []string, prefix string, stats []xenDeviceStat) error {
+	for _, dev := range devices {
+		name := strings.TrimPrefix(path.Base(dev), prefix)
+		for _, s := range stats {
+			v, err := readUintFromFile(path.Join(dev, s.file))
+			if err != nil {
+				return fmt.Errorf("couldn't get statistics of %s: %s", path.Base(dev), err)
+			}
+			ch <- s.desc.mustNewConstMetric(float64(v)*s.scale, domain, name)
+		}
+	}
+	return nil
+}
+
+// parseXLList parses the output of xl list, a header followed by a line of
+// name, ID, memory in MiB, vCPUs, state and CPU time per domain.
+func parseXLList(r io.Reader) ([]xenDomain, error) {
+	var (
+		domains []xenDomain
+		scanner = bufio.NewScanner(r)
+	)
+	scanner.Scan() // Skip header.
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// The name may contain spaces, the other columns don't.
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+		}
+		n := len(fields)
+		d := xenDomain{
+			name: strings.Join(fields[:n-5], " "),
+			id:   fields[n-5],
+		}
+		var errs [3]error
+		d.memory, errs[0] = strconv.ParseFloat(fields[n-4], 64)
+		d.vcpus, errs[1] = strconv.ParseFloat(fields[n-3], 64)
+		d.cpuTime, errs[2] = strconv.ParseFloat(fields[n-1], 64)
+		for _, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("invalid line: %s", scanner.Text())
+			}
+		}
+		d.memory *= 1024 * 1024
+		domains = append(domains, d)
+	}
+	return domains, scanner.Err()
+}
@@ -0,0 +1,44 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestXLList(t *testing.T) {
+	file, err := os.Open("fixtures/xl_list.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	domains, err := parseXLList(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []xenDomain{
+		{name: "Domain-0", id: "0", memory: 4096 * 1024 * 1024, vcpus: 4, cpuTime: 1234.5},
+		{name: "web1", id: "3", memory: 2048 * 1024 * 1024, vcpus: 2, cpuTime: 567.8},
+	}
+	if want, got := len(want), len(domains); want != got {
+		t.Fatalf("want %d domains, got %d", want, got)
+	}
+	for i, w := range want {
+		if got := domains[i]; w != got {
+			t.Errorf("want %+v, got %+v", w, got)
+		}
+	}
+}
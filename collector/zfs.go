@@ -0,0 +1,109 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozfs
+// +build linux freebsd solaris
+
+package collector
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Arch-dependent implementation must define:
+// * zfsCollector.getArcstats
+// * zfsCollector.getPoolStates
+
+const zfsSubsystem = "zfs"
+
+// zfsArcstats are the ARC statistics exposed, by their name in the arcstats
+// kstat, which is the same on all platforms.
+var zfsArcstats = []struct {
+	stat, name, help string
+	valueType        prometheus.ValueType
+}{
+	{"hits", "arc_hits_total", "Reads served from the ARC.", prometheus.CounterValue},
+	{"misses", "arc_misses_total", "Reads not served from the ARC.", prometheus.CounterValue},
+	{"size", "arc_size_bytes", "Current size of the ARC.", prometheus.GaugeValue},
+	{"c", "arc_target_size_bytes", "Size the ARC is adapting to.", prometheus.GaugeValue},
+	{"c_min", "arc_min_size_bytes", "Minimum size of the ARC.", prometheus.GaugeValue},
+	{"c_max", "arc_max_size_bytes", "Maximum size of the ARC.", prometheus.GaugeValue},
+	{"memory_throttle_count", "arc_memory_throttles_total", "Writes throttled because of low memory.", prometheus.CounterValue},
+	{"l2_hits", "l2arc_hits_total", "Reads served from the L2ARC.", prometheus.CounterValue},
+	{"l2_misses", "l2arc_misses_total", "Reads not served from the L2ARC.", prometheus.CounterValue},
+	{"l2_read_bytes", "l2arc_read_bytes_total", "Bytes read from the L2ARC devices.", prometheus.CounterValue},
+	{"l2_write_bytes", "l2arc_written_bytes_total", "Bytes written to the L2ARC devices.", prometheus.CounterValue},
+	{"l2_size", "l2arc_size_bytes", "Size of the data in the L2ARC before compression.", prometheus.GaugeValue},
+	{"l2_asize", "l2arc_allocated_bytes", "Space allocated for the data on the L2ARC devices.", prometheus.GaugeValue},
+}
+
+// zfsPoolStates are the states a pool can be in, see zpoolconcepts(7).
+var zfsPoolStates = []string{"ONLINE", "DEGRADED", "FAULTED", "OFFLINE", "UNAVAIL", "REMOVED", "SUSPENDED"}
+
+type zfsCollector struct {
+	arcstats  []typedDesc
+	poolState typedDesc
+}
+
+func init() {
+	Factories["zfs"] = NewZFSCollector
+}
+
+// NewZFSCollector returns a new Collector exposing ZFS ARC statistics and
+// pool health.
+func NewZFSCollector() (Collector, error) {
+	c := &zfsCollector{
+		poolState: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zfsSubsystem, "pool_state"),
+			"State of the pool, 1 for the current state and 0 for the others.",
+			[]string{"pool", "state"}, nil,
+		), prometheus.GaugeValue},
+	}
+	for _, s := range zfsArcstats {
+		c.arcstats = append(c.arcstats, typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zfsSubsystem, s.name),
+			s.help, nil, nil,
+		), s.valueType})
+	}
+	return c, nil
+}
+
+func (c *zfsCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	arcstats, err := c.getArcstats()
+	if err != nil {
+		return fmt.Errorf("couldn't get arcstats: %s", err)
+	}
+	for i, s := range zfsArcstats {
+		// L2ARC statistics are missing on older releases.
+		if v, ok := arcstats[s.stat]; ok {
+			ch <- c.arcstats[i].mustNewConstMetric(float64(v))
+		}
+	}
+
+	pools, err := c.getPoolStates()
+	if err != nil {
+		return fmt.Errorf("couldn't get pool states: %s", err)
+	}
+	for pool, current := range pools {
+		for _, state := range zfsPoolStates {
+			v := 0.0
+			if state == current {
+				v = 1
+			}
+			ch <- c.poolState.mustNewConstMetric(v, pool, state)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozfs
+
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func (c *zfsCollector) getArcstats() (map[string]uint64, error) {
+	file, err := os.Open(procFilePath("spl/kstat/zfs/arcstats"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseZFSKstat(file)
+}
+
+// getPoolStates reads the state of each pool, which ZFS on Linux exposes
+// since 0.8.
+func (c *zfsCollector) getPoolStates() (map[string]string, error) {
+	files, err := filepath.Glob(procFilePath("spl/kstat/zfs/*/state"))
+	if err != nil {
+		return nil, err
+	}
+	pools := map[string]string{}
+	for _, f := range files {
+		state, err := readStringFromFile(f)
+		if err != nil {
+			return nil, err
+		}
+		pools[path.Base(path.Dir(f))] = state
+	}
+	return pools, nil
+}
+
+// parseZFSKstat parses a named kstat, a header line followed by the column
+// names and a line of name, type and value for every statistic.
+func parseZFSKstat(r io.Reader) (map[string]uint64, error) {
+	var (
+		stats   = map[string]uint64{}
+		scanner = bufio.NewScanner(r)
+	)
+	for i := 0; i < 2; i++ {
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("kstat header missing")
+		}
+	}
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("invalid line in kstat: %s", scanner.Text())
+		}
+		v, err := strconv.ParseUint(parts[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in kstat: %s", err)
+		}
+		stats[parts[0]] = v
+	}
+	return stats, scanner.Err()
+}
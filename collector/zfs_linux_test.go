@@ -0,0 +1,60 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"os"
+	"testing"
+)
+
+func TestZFSKstat(t *testing.T) {
+	file, err := os.Open("fixtures/proc/spl/kstat/zfs/arcstats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	stats, err := parseZFSKstat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 22, len(stats); want != got {
+		t.Errorf("want %d stats, got %d", want, got)
+	}
+	if want, got := uint64(8338165760), stats["c_max"]; want != got {
+		t.Errorf("want c_max %d, got %d", want, got)
+	}
+}
+
+func TestSysctlArcstats(t *testing.T) {
+	stats, err := sysctlArcstats(freebsdSysctl(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := len(zfsArcstats), len(stats); want != got {
+		t.Errorf("want %d stats, got %d", want, got)
+	}
+	if want, got := uint64(8772612), stats["hits"]; want != got {
+		t.Errorf("want %d hits, got %d", want, got)
+	}
+
+	// Older releases lack the L2ARC statistics.
+	stats, err = sysctlArcstats(sysctlFixture("fixtures/sysctl/missing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 0 {
+		t.Errorf("want no stats, got %v", stats)
+	}
+}
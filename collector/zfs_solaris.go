@@ -0,0 +1,26 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozfs
+
+package collector
+
+func (c *zfsCollector) getArcstats() (map[string]uint64, error) {
+	return kstatArcstats(newKstatReader())
+}
+
+// getPoolStates returns no pools, as illumos doesn't expose their state
+// through kstats.
+func (c *zfsCollector) getPoolStates() (map[string]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,36 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozfs
+// +build linux freebsd
+
+package collector
+
+import "os"
+
+// sysctlArcstats reads the ARC statistics from the kstat.zfs.misc.arcstats
+// sysctls.
+func sysctlArcstats(s sysctlReader) (map[string]uint64, error) {
+	stats := map[string]uint64{}
+	for _, a := range zfsArcstats {
+		v, err := sysctlUint64(s, "kstat.zfs.misc.arcstats."+a.stat)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		stats[a.stat] = v
+	}
+	return stats, nil
+}
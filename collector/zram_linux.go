@@ -0,0 +1,193 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nozram
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const zramSubsystem = "zram"
+
+var zramAlgorithmRE = regexp.MustCompile(`\[(.*)\]`)
+
+type zramDevice struct {
+	name, algorithm string
+	diskSize        float64
+	// stats maps the fields of mm_stat and io_stat to their values, see
+	// Documentation/admin-guide/blockdev/zram.rst.
+	stats map[string]float64
+}
+
+// zramMMStatFields are the fields of mm_stat in order. Older kernels
+// without mm_stat have a file for each of the first three.
+var zramMMStatFields = []string{
+	"orig_data_size", "compr_data_size", "mem_used_total", "mem_limit",
+	"mem_used_max", "same_pages", "pages_compacted", "huge_pages",
+}
+
+var zramIOStatFields = []string{"failed_reads", "failed_writes", "invalid_io", "notify_free"}
+
+type zramCollector struct {
+	info, diskSize                       typedDesc
+	originalData, compressedData, ratio  typedDesc
+	memUsed, memLimit, memUsedMax        typedDesc
+	samePages, pagesCompacted, hugePages typedDesc
+	failedReads, failedWrites            typedDesc
+}
+
+func init() {
+	Factories["zram"] = NewZramCollector
+}
+
+// NewZramCollector returns a new Collector exposing the compression and
+// memory usage of zram devices.
+func NewZramCollector() (Collector, error) {
+	labels := []string{"device"}
+	desc := func(name, help string, t prometheus.ValueType) typedDesc {
+		return typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zramSubsystem, name),
+			help, labels, nil,
+		), t}
+	}
+	return &zramCollector{
+		info: typedDesc{prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, zramSubsystem, "info"),
+			"Compression algorithm of the zram device, value is always 1.",
+			[]string{"device", "algorithm"}, nil,
+		), prometheus.GaugeValue},
+		diskSize:       desc("disk_size_bytes", "Size of the zram device.", prometheus.GaugeValue),
+		originalData:   desc("original_data_bytes", "Uncompressed size of the data stored in the zram device.", prometheus.GaugeValue),
+		compressedData: desc("compressed_data_bytes", "Compressed size of the data stored in the zram device.", prometheus.GaugeValue),
+		ratio:          desc("compression_ratio", "Ratio of the uncompressed to the compressed size of the data stored.", prometheus.GaugeValue),
+		memUsed:        desc("memory_used_bytes", "Memory used by the zram device, including allocator overhead.", prometheus.GaugeValue),
+		memLimit:       desc("memory_limit_bytes", "Maximum memory the zram device may use, 0 if unlimited.", prometheus.GaugeValue),
+		memUsedMax:     desc("memory_used_max_bytes", "Maximum memory the zram device has used.", prometheus.GaugeValue),
+		samePages:      desc("same_pages", "Pages filled with the same value, which use no memory.", prometheus.GaugeValue),
+		pagesCompacted: desc("pages_compacted_total", "Pages freed by compaction.", prometheus.CounterValue),
+		hugePages:      desc("huge_pages", "Pages that didn't compress and are stored uncompressed.", prometheus.GaugeValue),
+		failedReads:    desc("failed_reads_total", "Failed reads from the zram device.", prometheus.CounterValue),
+		failedWrites:   desc("failed_writes_total", "Failed writes to the zram device.", prometheus.CounterValue),
+	}, nil
+}
+
+func (c *zramCollector) Update(ch chan<- prometheus.Metric) (err error) {
+	devices, err := readZramDevices(sysFilePath("block"))
+	if err != nil {
+		return err
+	}
+	for _, d := range devices {
+		ch <- c.info.mustNewConstMetric(1, d.name, d.algorithm)
+		ch <- c.diskSize.mustNewConstMetric(d.diskSize, d.name)
+		for field, desc := range map[string]*typedDesc{
+			"orig_data_size":  &c.originalData,
+			"compr_data_size": &c.compressedData,
+			"mem_used_total":  &c.memUsed,
+			"mem_limit":       &c.memLimit,
+			"mem_used_max":    &c.memUsedMax,
+			"same_pages":      &c.samePages,
+			"pages_compacted": &c.pagesCompacted,
+			"huge_pages":      &c.hugePages,
+			"failed_reads":    &c.failedReads,
+			"failed_writes":   &c.failedWrites,
+		} {
+			if v, ok := d.stats[field]; ok {
+				ch <- desc.mustNewConstMetric(v, d.name)
+			}
+		}
+		if compressed := d.stats["compr_data_size"]; compressed > 0 {
+			ch <- c.ratio.mustNewConstMetric(d.stats["orig_data_size"]/compressed, d.name)
+		}
+	}
+	return nil
+}
+
+// readZramDevices returns the initialized zram devices, which have a
+// non-zero disk size.
+func readZramDevices(root string) ([]zramDevice, error) {
+	dirs, err := filepath.Glob(path.Join(root, "zram*"))
+	if err != nil {
+		return nil, err
+	}
+	var devices []zramDevice
+	for _, dir := range dirs {
+		d := zramDevice{name: path.Base(dir), stats: map[string]float64{}}
+		size, err := readUintFromFile(path.Join(dir, "disksize"))
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			continue
+		}
+		d.diskSize = float64(size)
+
+		algorithm, err := readStringFromFile(path.Join(dir, "comp_algorithm"))
+		if err != nil {
+			return nil, err
+		}
+		if m := zramAlgorithmRE.FindStringSubmatch(algorithm); m != nil {
+			d.algorithm = m[1]
+		} else {
+			d.algorithm = algorithm
+		}
+
+		if err := readZramStat(path.Join(dir, "mm_stat"), zramMMStatFields, d.stats); os.IsNotExist(err) {
+			// Before Linux 4.2 the fields were in separate files.
+			for _, field := range zramMMStatFields[:3] {
+				v, err := readUintFromFile(path.Join(dir, field))
+				if err != nil {
+					return nil, err
+				}
+				d.stats[field] = float64(v)
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		if err := readZramStat(path.Join(dir, "io_stat"), zramIOStatFields, d.stats); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// readZramStat reads a file of space separated values into stats. Fields
+// that the kernel doesn't report yet are left out.
+func readZramStat(file string, fields []string, stats map[string]float64) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	for i, f := range strings.Fields(string(b)) {
+		if i >= len(fields) {
+			break
+		}
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return fmt.Errorf("invalid value in %s: %s", file, err)
+		}
+		stats[fields[i]] = v
+	}
+	return nil
+}
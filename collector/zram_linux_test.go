@@ -0,0 +1,55 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collector
+
+import (
+	"testing"
+)
+
+func TestZramDevices(t *testing.T) {
+	devices, err := readZramDevices("fixtures/sys/block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 2, len(devices); want != got {
+		t.Fatalf("want %d devices, got %d", want, got)
+	}
+
+	d := devices[0]
+	if want, got := "zstd", d.algorithm; want != got {
+		t.Errorf("want algorithm %s, got %s", want, got)
+	}
+	for field, want := range map[string]float64{
+		"orig_data_size":  262746112,
+		"compr_data_size": 67110953,
+		"huge_pages":      127,
+		"notify_free":     1544,
+	} {
+		if got := d.stats[field]; want != got {
+			t.Errorf("want %s %f, got %f", field, want, got)
+		}
+	}
+
+	// zram1 has the layout of kernels before mm_stat.
+	d = devices[1]
+	if want, got := "lzo", d.algorithm; want != got {
+		t.Errorf("want algorithm %s, got %s", want, got)
+	}
+	if want, got := 14680064.0, d.stats["mem_used_total"]; want != got {
+		t.Errorf("want memory used %f, got %f", want, got)
+	}
+	if _, ok := d.stats["mem_limit"]; ok {
+		t.Error("unexpected mem_limit without mm_stat")
+	}
+}
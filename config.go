@@ -0,0 +1,146 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/node_exporter/collector"
+	"gopkg.in/yaml.v2"
+)
+
+// config is the content of the file given with -config.file. Settings left
+// out of it keep the values of the command line flags.
+type config struct {
+	Web struct {
+		ListenAddress string `yaml:"listen_address"`
+	} `yaml:"web"`
+	Collectors struct {
+		Enabled []string `yaml:"enabled"`
+		// Flags overrides collector flags, e.g. the filters of
+		// collector.filesystem.ignored-mount-points.
+		Flags map[string]string `yaml:"flags"`
+	} `yaml:"collectors"`
+}
+
+func loadConfig(filename string) (*config, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &config{}
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %s", filename, err)
+	}
+	for name := range c.Collectors.Flags {
+		if !strings.HasPrefix(name, "collector.") || flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown collector flag '%s' in %s", name, filename)
+		}
+	}
+	return c, nil
+}
+
+// configLoader creates the collectors from the config file, on top of the
+// collectors and flags given on the command line.
+type configLoader struct {
+	filename string
+	// collectors is the value of -collectors.enabled.
+	collectors string
+	// flags holds the command line values of the flags overridden by the
+	// config file.
+	flags   map[string]string
+	current *config
+}
+
+func newConfigLoader(filename, collectors string) *configLoader {
+	return &configLoader{
+		filename:   filename,
+		collectors: collectors,
+		flags:      map[string]string{},
+	}
+}
+
+// load reads the config file and creates the collectors it enables. On
+// errors the flags of the previously loaded config stay in effect.
+func (l *configLoader) load() (*config, map[string]collector.Collector, error) {
+	c, err := loadConfig(l.filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	collectors, err := l.apply(c)
+	if err != nil {
+		if l.current != nil {
+			l.apply(l.current)
+		}
+		return nil, nil, err
+	}
+	l.current = c
+	return c, collectors, nil
+}
+
+func (l *configLoader) apply(c *config) (map[string]collector.Collector, error) {
+	for name, value := range l.flags {
+		flag.Set(name, value)
+	}
+	for name, value := range c.Collectors.Flags {
+		if _, ok := l.flags[name]; !ok {
+			l.flags[name] = flag.Lookup(name).Value.String()
+		}
+		if err := flag.Set(name, value); err != nil {
+			return nil, fmt.Errorf("couldn't set flag %s: %s", name, err)
+		}
+	}
+	list := l.collectors
+	if len(c.Collectors.Enabled) > 0 {
+		list = strings.Join(c.Collectors.Enabled, ",")
+	}
+	return loadCollectors(list)
+}
+
+// reloadOnSIGHUP reloads the config file on every SIGHUP and swaps the new
+// collectors into the handler. The flags are set while no scrape is in
+// flight. A changed listen address needs a restart.
+func (l *configLoader) reloadOnSIGHUP(h *metricsHandler, listenAddress string) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			log.Infoln("Reloading config file", l.filename)
+			var (
+				c          *config
+				collectors map[string]collector.Collector
+			)
+			err := h.reload(func() (map[string]collector.Collector, error) {
+				var err error
+				c, collectors, err = l.load()
+				return collectors, err
+			})
+			if err != nil {
+				log.Errorf("Couldn't reload config file: %s", err)
+				continue
+			}
+			if c.Web.ListenAddress != "" && c.Web.ListenAddress != listenAddress {
+				log.Warnf("Listen address changed to %s, restart to apply it", c.Web.ListenAddress)
+			}
+			logCollectors(collectors)
+		}
+	}()
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+func TestConfigLoader(t *testing.T) {
+	collector.Factories["fake"] = func() (collector.Collector, error) { return fakeCollector{}, nil }
+	collector.Factories["other"] = func() (collector.Collector, error) { return otherCollector{}, nil }
+	defer delete(collector.Factories, "fake")
+	defer delete(collector.Factories, "other")
+	flag.String("collector.fake.filter", "default", "Filter of the fake collector.")
+
+	f, err := ioutil.TempFile("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	write := func(s string) {
+		if err := ioutil.WriteFile(f.Name(), []byte(s), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := newConfigLoader(f.Name(), "fake")
+	for _, c := range []struct {
+		config     string
+		collectors []string
+		filter     string
+		err        bool
+	}{
+		{"web:\n  listen_address: ':9101'\n", []string{"fake"}, "default", false},
+		{"collectors:\n  enabled: [fake, other]\n  flags:\n    collector.fake.filter: '^sd'\n", []string{"fake", "other"}, "^sd", false},
+		// Failed reloads keep the flags of the last good config.
+		{"collectors:\n  enabled: [missing]\n  flags:\n    collector.fake.filter: '^hd'\n", nil, "^sd", true},
+		{"collectors:\n  flags:\n    web.listen-address: ':9101'\n", nil, "^sd", true},
+		{"collector:\n  enabled: [fake]\n", nil, "^sd", true},
+		// Flags left out of the config go back to the command line value.
+		{"collectors:\n  enabled: [other]\n", []string{"other"}, "default", false},
+	} {
+		write(c.config)
+		_, collectors, err := l.load()
+		if got := flag.Lookup("collector.fake.filter").Value.String(); c.filter != got {
+			t.Errorf("%q: want filter %q, got %q", c.config, c.filter, got)
+		}
+		if c.err {
+			if err == nil {
+				t.Errorf("%q: expected error", c.config)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.config, err)
+			continue
+		}
+		if want, got := len(c.collectors), len(collectors); want != got {
+			t.Errorf("%q: want %d collectors, got %d", c.config, want, got)
+		}
+		for _, name := range c.collectors {
+			if _, ok := collectors[name]; !ok {
+				t.Errorf("%q: collector %s not loaded", c.config, name)
+			}
+		}
+	}
+
+	cfg, err := loadConfig(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Collectors.Enabled) != 1 || cfg.Web.ListenAddress != "" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+}
+
+var reloadFlag = flag.String("collector.reload.value", "a", "Value read by reloadCollector.")
+
+// reloadCollector reads its flag during Update, like many collectors do.
+type reloadCollector struct{}
+
+func (reloadCollector) Update(ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("node_reload", "Reload metric.", []string{"value"}, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, *reloadFlag)
+	return nil
+}
+
+// TestReloadDuringScrape is meant to be run with -race.
+func TestReloadDuringScrape(t *testing.T) {
+	h := &metricsHandler{collectors: map[string]collector.Collector{"reload": reloadCollector{}}}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+			}
+		}()
+	}
+	for _, v := range []string{"b", "c", "a"} {
+		err := h.reload(func() (map[string]collector.Collector, error) {
+			flag.Set("collector.reload.value", v)
+			return map[string]collector.Collector{"reload": reloadCollector{}}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
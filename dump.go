@@ -0,0 +1,121 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// replayCollector exposes a fixed set of already collected metrics.
+type replayCollector []prometheus.Metric
+
+// Describe implements the prometheus.Collector interface.
+func (r replayCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range r {
+		ch <- m.Desc()
+	}
+}
+
+// Collect implements the prometheus.Collector interface.
+func (r replayCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range r {
+		ch <- m
+	}
+}
+
+// dumpCollector runs the named collector once and writes its metrics in
+// the text exposition format to w, sorted the same way as on /metrics.
+func dumpCollector(name string, w io.Writer) error {
+	collectors, err := loadCollectors(name)
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan prometheus.Metric)
+	errc := make(chan error, 1)
+	go func() {
+		errc <- collectors[name].Update(ch)
+		close(ch)
+	}()
+	var metrics replayCollector
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if err := <-errc; err != nil {
+		return fmt.Errorf("%s collector failed: %s", name, err)
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(metrics); err != nil {
+		return err
+	}
+	mfs, err := registry.Gather()
+	if err != nil {
+		return err
+	}
+	enc := expfmt.NewEncoder(w, expfmt.FmtText)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compareGolden compares the dumped output against the golden file and
+// returns an error listing the lines missing (-) and unexpected (+).
+func compareGolden(golden string, got []byte) error {
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(want, got) {
+		return nil
+	}
+
+	wantLines := strings.Split(strings.TrimSpace(string(want)), "\n")
+	gotLines := strings.Split(strings.TrimSpace(string(got)), "\n")
+	seen := map[string]int{}
+	for _, l := range gotLines {
+		seen[l]++
+	}
+	var diff []string
+	for _, l := range wantLines {
+		if seen[l] > 0 {
+			seen[l]--
+			continue
+		}
+		diff = append(diff, "-"+l)
+	}
+	for _, l := range gotLines {
+		if seen[l] > 0 {
+			seen[l]--
+			diff = append(diff, "+"+l)
+		}
+	}
+	if len(diff) == 0 {
+		diff = append(diff, "metrics are in a different order")
+	}
+	return fmt.Errorf("output differs from %s:\n%s", golden, strings.Join(diff, "\n"))
+}
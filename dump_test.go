@@ -0,0 +1,77 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+type fakeCollector struct{}
+
+func (fakeCollector) Update(ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("node_fake", "Fake metric.", []string{"id"}, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 2, "b")
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1, "a")
+	return nil
+}
+
+func TestDumpCollector(t *testing.T) {
+	collector.Factories["fake"] = func() (collector.Collector, error) { return fakeCollector{}, nil }
+	defer delete(collector.Factories, "fake")
+
+	var buf bytes.Buffer
+	if err := dumpCollector("fake", &buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP node_fake Fake metric.
+# TYPE node_fake gauge
+node_fake{id="a"} 1
+node_fake{id="b"} 2
+`
+	if got := buf.String(); want != got {
+		t.Fatalf("want %q, got %q", want, got)
+	}
+
+	golden, err := ioutil.TempFile("", "golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(golden.Name())
+	golden.WriteString(strings.Replace(want, `{id="b"} 2`, `{id="b"} 3`, 1))
+	golden.Close()
+
+	err = compareGolden(golden.Name(), buf.Bytes())
+	if err == nil {
+		t.Fatal("expected golden file mismatch")
+	}
+	for _, l := range []string{`-node_fake{id="b"} 3`, `+node_fake{id="b"} 2`} {
+		if !strings.Contains(err.Error(), l) {
+			t.Errorf("expected %q in %q", l, err)
+		}
+	}
+	if err := compareGolden(golden.Name(), []byte(strings.Replace(want, "} 2", "} 3", 1))); err != nil {
+		t.Errorf("unexpected mismatch: %s", err)
+	}
+
+	if err := dumpCollector("missing", &buf); err == nil {
+		t.Error("expected error for unknown collector")
+	}
+}
@@ -30,7 +30,7 @@ cd "$(dirname $0)"
 port="$((10000 + (RANDOM % 10000)))"
 tmpdir=$(mktemp -d /tmp/node_exporter_e2e_test.XXXXXX)
 
-skip_re="^(go_|node_exporter_|process_|node_textfile_mtime)"
+skip_re="^(go_|node_exporter_|process_|node_textfile_mtime|node_scrape_collector_duration_seconds)"
 
 keep=0; update=0; verbose=0
 while getopts 'hkuv' opt
@@ -68,6 +68,7 @@ fi
   -collectors.enabled="$(echo ${collectors} | tr ' ' ',')" \
   -collector.textfile.directory="collector/fixtures/textfile/two_metric_files/" \
   -collector.megacli.command="collector/fixtures/megacli" \
+  -maintenance.enabled \
   -web.listen-address "127.0.0.1:${port}" \
   -log.level="debug" > "${tmpdir}/node_exporter.log" 2>&1 &
 
@@ -0,0 +1,258 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// metricsHandler serves the metrics of the enabled collectors. Scrapers can
+// select a subset of them with collect[] parameters, e.g.
+// /metrics?collect[]=cpu&collect[]=meminfo, and a subset of the metric
+// families with include[] and exclude[] glob patterns of their names, e.g.
+// /metrics?include[]=node_memory_*&exclude[]=node_memory_Hugepages*. They
+// can also attach labels to the series of the response with label[]
+// parameters, e.g. /metrics?label[]=tenant=a, for the label names in
+// scrapeLabels.
+type metricsHandler struct {
+	mtx          sync.RWMutex
+	collectors   map[string]collector.Collector
+	scrapeLabels map[string]bool
+	// history records the outcome of the scrapes if not nil.
+	history *scrapeHistory
+}
+
+// reload replaces the enabled collectors with the ones load returns. It
+// waits for scrapes in flight, as load may change the flags collectors read
+// during Update.
+func (h *metricsHandler) reload(load func() (map[string]collector.Collector, error)) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	collectors, err := load()
+	if err != nil {
+		return err
+	}
+	h.collectors = collectors
+	return nil
+}
+
+// withCollectors calls f with the enabled collectors, holding off reloads
+// until it returns.
+func (h *metricsHandler) withCollectors(f func(enabled map[string]collector.Collector)) {
+	h.mtx.RLock()
+	defer h.mtx.RUnlock()
+	f(h.collectors)
+}
+
+func (h *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	labels, err := h.requestLabels(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	include, exclude := r.URL.Query()["include[]"], r.URL.Query()["exclude[]"]
+	for _, pattern := range append(include, exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			http.Error(w, fmt.Sprintf("invalid pattern '%s': %s", pattern, err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var (
+		begin   = time.Now()
+		errors  = &scrapeErrors{}
+		unknown string
+		mfs     []*dto.MetricFamily
+	)
+	h.withCollectors(func(enabled map[string]collector.Collector) {
+		collectors := enabled
+		if filters := r.URL.Query()["collect[]"]; len(filters) > 0 {
+			collectors = map[string]collector.Collector{}
+			for _, name := range filters {
+				c, ok := enabled[name]
+				if !ok {
+					unknown = name
+					return
+				}
+				collectors[name] = c
+			}
+		}
+		mfs, err = gather(collectors, errors)
+	})
+	if unknown != "" {
+		http.Error(w, fmt.Sprintf("collector '%s' not enabled", unknown), http.StatusBadRequest)
+		return
+	}
+	summary := scrapeSummary{Time: begin, Duration: time.Since(begin).Seconds(), Errors: errors.errors}
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	h.history.add(summary)
+	if err != nil {
+		http.Error(w, "An error has occurred during metrics collection:\n\n"+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(include) > 0 || len(exclude) > 0 {
+		mfs = filterFamilies(mfs, include, exclude)
+	}
+	if len(labels) > 0 {
+		addLabels(mfs, labels)
+	}
+
+	writeMetrics(w, r, mfs)
+}
+
+// writeMetrics encodes the metric families in the format and encoding the
+// request accepts.
+func writeMetrics(w http.ResponseWriter, r *http.Request, mfs []*dto.MetricFamily) {
+	contentType := expfmt.Negotiate(r.Header)
+	var buf bytes.Buffer
+	var writer io.Writer = &buf
+	if gzipAccepted(r) {
+		writer = gzip.NewWriter(&buf)
+	}
+	enc := expfmt.NewEncoder(writer, contentType)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			http.Error(w, "An error has occurred during metrics encoding:\n\n"+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if gz, ok := writer.(*gzip.Writer); ok {
+		gz.Close()
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	w.Header().Set("Content-Type", string(contentType))
+	w.Header().Set("Content-Length", fmt.Sprint(buf.Len()))
+	w.Write(buf.Bytes())
+}
+
+// gather collects the metrics of the collectors, which are registered per
+// call, next to the metrics of the exporter itself in the default registry.
+func gather(collectors map[string]collector.Collector, errors *scrapeErrors) ([]*dto.MetricFamily, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(NodeCollector{collectors: collectors, errors: errors}); err != nil {
+		return nil, err
+	}
+	return prometheus.Gatherers{prometheus.DefaultGatherer, registry}.Gather()
+}
+
+// requestLabels returns the labels given with label[]=name=value
+// parameters.
+func (h *metricsHandler) requestLabels(r *http.Request) ([]*dto.LabelPair, error) {
+	var labels []*dto.LabelPair
+	seen := map[string]bool{}
+	for _, param := range r.URL.Query()["label[]"] {
+		parts := strings.SplitN(param, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid label '%s', expected name=value", param)
+		}
+		name, value := parts[0], parts[1]
+		if !h.scrapeLabels[name] {
+			return nil, fmt.Errorf("label '%s' not allowed", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("label '%s' given more than once", name)
+		}
+		seen[name] = true
+		labels = append(labels, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+	}
+	return labels, nil
+}
+
+// filterFamilies returns the metric families whose names match one of the
+// include patterns, or all if there are none, and none of the exclude
+// patterns.
+func filterFamilies(mfs []*dto.MetricFamily, include, exclude []string) []*dto.MetricFamily {
+	matches := func(patterns []string, name string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	var filtered []*dto.MetricFamily
+	for _, mf := range mfs {
+		if len(include) > 0 && !matches(include, mf.GetName()) {
+			continue
+		}
+		if matches(exclude, mf.GetName()) {
+			continue
+		}
+		filtered = append(filtered, mf)
+	}
+	return filtered
+}
+
+// addLabels attaches the labels to all series. Labels the series already
+// have keep their value.
+func addLabels(mfs []*dto.MetricFamily, labels []*dto.LabelPair) {
+	for _, mf := range mfs {
+		for _, m := range mf.Metric {
+			have := make(map[string]bool, len(m.Label))
+			for _, l := range m.Label {
+				have[l.GetName()] = true
+			}
+			for _, l := range labels {
+				if !have[l.GetName()] {
+					m.Label = append(m.Label, l)
+				}
+			}
+			sort.Sort(prometheus.LabelPairSorter(m.Label))
+		}
+	}
+}
+
+// parseScrapeLabels parses the comma separated label names scrapers may
+// attach.
+func parseScrapeLabels(names string) (map[string]bool, error) {
+	labels := map[string]bool{}
+	for _, name := range strings.Split(names, ",") {
+		if name == "" {
+			continue
+		}
+		if !model.LabelName(name).IsValid() {
+			return nil, fmt.Errorf("invalid label name '%s'", name)
+		}
+		labels[name] = true
+	}
+	return labels, nil
+}
+
+func gzipAccepted(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "gzip" || strings.HasPrefix(part, "gzip;") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+type otherCollector struct{}
+
+func (otherCollector) Update(ch chan<- prometheus.Metric) error {
+	desc := prometheus.NewDesc("node_other", "Other metric.", nil, nil)
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+	return nil
+}
+
+func TestMetricsHandler(t *testing.T) {
+	h := metricsHandler{collectors: map[string]collector.Collector{
+		"fake":  fakeCollector{},
+		"other": otherCollector{},
+	}, scrapeLabels: map[string]bool{"tenant": true, "id": true}}
+
+	for _, c := range []struct {
+		url           string
+		status        int
+		want, notWant []string
+	}{
+		{"/metrics", http.StatusOK, []string{"node_fake{", "node_other 1", `node_scrape_collector_success{collector="other"} 1`}, nil},
+		{"/metrics?collect[]=fake", http.StatusOK, []string{"node_fake{", `node_scrape_collector_duration_seconds{collector="fake"}`}, []string{"node_other", `node_scrape_collector_success{collector="other"}`}},
+		{"/metrics?collect[]=fake&collect[]=other", http.StatusOK, []string{"node_fake{", "node_other 1"}, nil},
+		{"/metrics?collect[]=missing", http.StatusBadRequest, nil, nil},
+		{"/metrics?include[]=node_fak*", http.StatusOK, []string{"node_fake{"}, []string{"node_other", "node_scrape_collector"}},
+		{"/metrics?include[]=node_*&exclude[]=node_scrape_*&exclude[]=node_fake", http.StatusOK, []string{"node_other 1"}, []string{"node_fake", "node_scrape_collector", "go_goroutines"}},
+		{"/metrics?exclude[]=node_other", http.StatusOK, []string{"node_fake{", "go_goroutines"}, []string{"node_other"}},
+		{"/metrics?include[]=node_[", http.StatusBadRequest, nil, nil},
+		{"/metrics?label[]=tenant=a", http.StatusOK, []string{`node_fake{id="a",tenant="a"} 1`, `node_other{tenant="a"} 1`}, nil},
+		// Labels of the series win over the ones of the scraper.
+		{"/metrics?label[]=id=x&label[]=tenant=b", http.StatusOK, []string{`node_fake{id="b",tenant="b"} 2`, `node_other{id="x",tenant="b"} 1`}, []string{`node_fake{id="x"`}},
+		{"/metrics?label[]=job=a", http.StatusBadRequest, nil, nil},
+		{"/metrics?label[]=tenant", http.StatusBadRequest, nil, nil},
+		{"/metrics?label[]=tenant=a&label[]=tenant=b", http.StatusBadRequest, nil, nil},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", c.url, nil))
+		if want, got := c.status, w.Code; want != got {
+			t.Errorf("%s: want status %d, got %d", c.url, want, got)
+			continue
+		}
+		body := w.Body.String()
+		for _, s := range c.want {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s: expected %q in output", c.url, s)
+			}
+		}
+		for _, s := range c.notWant {
+			if strings.Contains(body, s) {
+				t.Errorf("%s: unexpected %q in output", c.url, s)
+			}
+		}
+	}
+
+	r := httptest.NewRequest("GET", "/metrics", nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if want, got := "gzip", w.Header().Get("Content-Encoding"); want != got {
+		t.Fatalf("want encoding %q, got %q", want, got)
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "node_other 1") {
+		t.Errorf("expected node_other in gzipped output")
+	}
+}
+
+func TestParseScrapeLabels(t *testing.T) {
+	labels, err := parseScrapeLabels("tenant,team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !labels["tenant"] || !labels["team"] || len(labels) != 2 {
+		t.Errorf("unexpected labels %v", labels)
+	}
+	if labels, err := parseScrapeLabels(""); err != nil || len(labels) != 0 {
+		t.Errorf("want no labels, got %v, %v", labels, err)
+	}
+	if _, err := parseScrapeLabels("tenant,1team"); err == nil {
+		t.Error("expected error for invalid label name")
+	}
+}
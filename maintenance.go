@@ -0,0 +1,162 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+var (
+	maintenanceModeDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "", "maintenance_mode"),
+		"Whether the node is in maintenance, set through /-/maintenance.",
+		[]string{"reason"}, nil,
+	)
+	maintenanceEndDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "", "maintenance_end_time_seconds"),
+		"Unix time the maintenance of the node expires.",
+		[]string{"reason"}, nil,
+	)
+)
+
+// maintenanceState is the state persisted across restarts.
+type maintenanceState struct {
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+// Maintenance implements the prometheus.Collector interface and the
+// /-/maintenance endpoint. Maintenance ends automatically once its
+// duration passed.
+type Maintenance struct {
+	stateFile string
+	now       func() time.Time
+
+	mtx   sync.Mutex
+	state maintenanceState
+}
+
+// NewMaintenance returns a Maintenance persisting its state in stateFile,
+// or only in memory if it is empty.
+func NewMaintenance(stateFile string) (*Maintenance, error) {
+	m := &Maintenance{stateFile: stateFile, now: time.Now}
+	if stateFile == "" {
+		return m, nil
+	}
+	buf, err := ioutil.ReadFile(stateFile)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(buf, &m.state); err != nil {
+		return nil, fmt.Errorf("invalid maintenance state file %s: %s", stateFile, err)
+	}
+	return m, nil
+}
+
+// Describe implements the prometheus.Collector interface.
+func (m *Maintenance) Describe(ch chan<- *prometheus.Desc) {
+	ch <- maintenanceModeDesc
+	ch <- maintenanceEndDesc
+}
+
+// Collect implements the prometheus.Collector interface.
+func (m *Maintenance) Collect(ch chan<- prometheus.Metric) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if !m.active() {
+		ch <- prometheus.MustNewConstMetric(maintenanceModeDesc, prometheus.GaugeValue, 0, "")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(maintenanceModeDesc, prometheus.GaugeValue, 1, m.state.Reason)
+	ch <- prometheus.MustNewConstMetric(maintenanceEndDesc, prometheus.GaugeValue,
+		float64(m.state.Until.UnixNano())/1e9, m.state.Reason)
+}
+
+func (m *Maintenance) active() bool {
+	return m.now().Before(m.state.Until)
+}
+
+// ServeHTTP starts maintenance for the duration and reason given as query
+// parameters on POST and ends it on DELETE.
+func (m *Maintenance) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var state maintenanceState
+	switch r.Method {
+	case "POST":
+		d, err := time.ParseDuration(r.FormValue("duration"))
+		if err != nil || d <= 0 {
+			http.Error(w, "duration must be a positive duration like 2h", http.StatusBadRequest)
+			return
+		}
+		state = maintenanceState{Reason: r.FormValue("reason"), Until: m.now().Add(d)}
+	case "DELETE":
+	default:
+		w.Header().Set("Allow", "POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if err := m.save(state); err != nil {
+		http.Error(w, fmt.Sprintf("couldn't persist maintenance state: %s", err), http.StatusInternalServerError)
+		return
+	}
+	m.state = state
+	if m.active() {
+		fmt.Fprintf(w, "Maintenance until %s\n", state.Until.Format(time.RFC3339))
+	} else {
+		fmt.Fprintln(w, "Maintenance ended")
+	}
+}
+
+// save writes the state to a temporary file renamed over the state file,
+// so a crash doesn't leave it truncated.
+func (m *Maintenance) save(state maintenanceState) error {
+	if m.stateFile == "" {
+		return nil
+	}
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(m.stateFile), filepath.Base(m.stateFile))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), m.stateFile)
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "node_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	stateFile := filepath.Join(dir, "maintenance.json")
+
+	now := time.Unix(1500000000, 0)
+	m, err := NewMaintenance(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return now }
+
+	for _, c := range []struct {
+		method, url string
+		status      int
+	}{
+		{"GET", "/-/maintenance", http.StatusMethodNotAllowed},
+		{"POST", "/-/maintenance?duration=-1h", http.StatusBadRequest},
+		{"POST", "/-/maintenance?duration=2h&reason=kernel+upgrade", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		m.ServeHTTP(w, httptest.NewRequest(c.method, c.url, nil))
+		if want, got := c.status, w.Code; want != got {
+			t.Errorf("%s %s: want status %d, got %d", c.method, c.url, want, got)
+		}
+	}
+
+	// The state survives a restart and expires.
+	m, err = NewMaintenance(stateFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.now = func() time.Time { return now.Add(time.Hour) }
+	if !m.active() {
+		t.Error("want maintenance to be active after an hour")
+	}
+	if want, got := "kernel upgrade", m.state.Reason; want != got {
+		t.Errorf("want reason %q, got %q", want, got)
+	}
+	m.now = func() time.Time { return now.Add(3 * time.Hour) }
+	if m.active() {
+		t.Error("want maintenance to have expired after three hours")
+	}
+
+	w := httptest.NewRecorder()
+	m.now = func() time.Time { return now }
+	m.ServeHTTP(w, httptest.NewRequest("DELETE", "/-/maintenance", nil))
+	if m.active() {
+		t.Error("want maintenance to end on DELETE")
+	}
+}
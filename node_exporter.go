@@ -14,6 +14,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
 	"net/http"
@@ -25,6 +26,7 @@ import (
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/log"
 	"github.com/prometheus/common/version"
 	"github.com/prometheus/node_exporter/collector"
@@ -44,16 +46,30 @@ var (
 		},
 		[]string{"collector", "result"},
 	)
+	scrapeDurationDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "scrape", "collector_duration_seconds"),
+		"node_exporter: Duration of a collector scrape.",
+		[]string{"collector"}, nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(collector.Namespace, "scrape", "collector_success"),
+		"node_exporter: Whether a collector succeeded.",
+		[]string{"collector"}, nil,
+	)
 )
 
 // NodeCollector implements the prometheus.Collector interface.
 type NodeCollector struct {
 	collectors map[string]collector.Collector
+	// errors records the errors of the collectors if not nil.
+	errors *scrapeErrors
 }
 
 // Describe implements the prometheus.Collector interface.
 func (n NodeCollector) Describe(ch chan<- *prometheus.Desc) {
 	scrapeDurations.Describe(ch)
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
 }
 
 // Collect implements the prometheus.Collector interface.
@@ -62,7 +78,7 @@ func (n NodeCollector) Collect(ch chan<- prometheus.Metric) {
 	wg.Add(len(n.collectors))
 	for name, c := range n.collectors {
 		go func(name string, c collector.Collector) {
-			execute(name, c, ch)
+			n.errors.add(name, execute(name, c, ch))
 			wg.Done()
 		}(name, c)
 	}
@@ -81,11 +97,12 @@ func filterAvailableCollectors(collectors string) string {
 	return strings.Join(availableCollectors, ",")
 }
 
-func execute(name string, c collector.Collector, ch chan<- prometheus.Metric) {
+func execute(name string, c collector.Collector, ch chan<- prometheus.Metric) error {
 	begin := time.Now()
 	err := c.Update(ch)
 	duration := time.Since(begin)
 	var result string
+	var success float64
 
 	if err != nil {
 		log.Errorf("ERROR: %s collector failed after %fs: %s", name, duration.Seconds(), err)
@@ -93,8 +110,12 @@ func execute(name string, c collector.Collector, ch chan<- prometheus.Metric) {
 	} else {
 		log.Debugf("OK: %s collector succeeded after %fs.", name, duration.Seconds())
 		result = "success"
+		success = 1
 	}
 	scrapeDurations.WithLabelValues(name, result).Observe(duration.Seconds())
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+	return err
 }
 
 func loadCollectors(list string) (map[string]collector.Collector, error) {
@@ -108,11 +129,21 @@ func loadCollectors(list string) (map[string]collector.Collector, error) {
 		if err != nil {
 			return nil, err
 		}
+		if c, err = collector.NewCompatibilityCollector(c); err != nil {
+			return nil, err
+		}
 		collectors[name] = c
 	}
 	return collectors, nil
 }
 
+func logCollectors(collectors map[string]collector.Collector) {
+	log.Infof("Enabled collectors:")
+	for n := range collectors {
+		log.Infof(" - %s", n)
+	}
+}
+
 func init() {
 	prometheus.MustRegister(version.NewCollector("node_exporter"))
 }
@@ -122,8 +153,23 @@ func main() {
 		showVersion       = flag.Bool("version", false, "Print version information.")
 		listenAddress     = flag.String("web.listen-address", ":9100", "Address on which to expose metrics and web interface.")
 		metricsPath       = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics.")
+		webConfigFile     = flag.String("web.config", "", "YAML file with the TLS and basic auth settings of the web server.")
+		scrapeLabels      = flag.String("web.scrape-labels", "", "Comma-separated label names scrapers may attach to all series of a scrape with label[]=name=value parameters.")
+		scrapeHistorySize = flag.Int("web.scrape-history-size", 20, "Number of recent scrapes to show at /debug/scrapes, 0 disables it.")
 		enabledCollectors = flag.String("collectors.enabled", filterAvailableCollectors(defaultCollectors), "Comma-separated list of collectors to use.")
 		printCollectors   = flag.Bool("collectors.print", false, "If true, print available collectors and exit.")
+		dumpCollectorName = flag.String("collect.dump", "", "Run the given collector once, print its metrics to stdout and exit.")
+		dumpGolden        = flag.String("collect.golden", "", "File to compare the output of -collect.dump against, exits non-zero on differences.")
+		maintenanceOn     = flag.Bool("maintenance.enabled", false, "Serve /-/maintenance to flag the node as under maintenance, protect it with basic auth of -web.config.")
+		maintenanceFile   = flag.String("maintenance.state-file", "", "File to persist the maintenance mode in across restarts, kept in memory only if empty.")
+		configFile        = flag.String("config.file", "", "YAML file with the enabled collectors, collector flags and listen address, reloaded on SIGHUP.")
+		summaryOn         = flag.Bool("web.summary.enabled", false, "Serve a small subset of the metrics on <web.telemetry-path>/summary.")
+		summaryMountpoint = flag.String("web.summary.mountpoint", "/", "Mount point of the filesystem shown in the summary metrics.")
+		summaryDevice     = flag.String("web.summary.device", "", "Network device shown in the summary metrics, the one that received the most bytes if empty.")
+		pushURL           = flag.String("push.url", "", "Remote write URL to push the metrics to, next to serving them.")
+		pushInterval      = flag.Duration("push.interval", 15*time.Second, "Interval to push the metrics at.")
+		pushSpoolDir      = flag.String("push.spool-dir", "", "Directory to keep the pushes in while the remote write URL is unreachable, pushes are dropped if empty.")
+		pushSpoolBytes    = flag.Int64("push.spool-max-bytes", 100<<20, "Maximum size of the push spool, the oldest pushes are dropped beyond it.")
 	)
 	flag.Parse()
 
@@ -132,6 +178,21 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *dumpCollectorName != "" {
+		var buf bytes.Buffer
+		if err := dumpCollector(*dumpCollectorName, &buf); err != nil {
+			log.Fatalf("Couldn't dump collector: %s", err)
+		}
+		os.Stdout.Write(buf.Bytes())
+		if *dumpGolden != "" {
+			if err := compareGolden(*dumpGolden, buf.Bytes()); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
 	log.Infoln("Starting node_exporter", version.Info())
 	log.Infoln("Build context", version.BuildContext())
 
@@ -147,34 +208,95 @@ func main() {
 		}
 		return
 	}
-	collectors, err := loadCollectors(*enabledCollectors)
-	if err != nil {
-		log.Fatalf("Couldn't load collectors: %s", err)
+	var (
+		collectors map[string]collector.Collector
+		loader     *configLoader
+		err        error
+	)
+	if *configFile != "" {
+		var c *config
+		loader = newConfigLoader(*configFile, *enabledCollectors)
+		c, collectors, err = loader.load()
+		if err != nil {
+			log.Fatalf("Couldn't load config file: %s", err)
+		}
+		if c.Web.ListenAddress != "" {
+			*listenAddress = c.Web.ListenAddress
+		}
+	} else {
+		collectors, err = loadCollectors(*enabledCollectors)
+		if err != nil {
+			log.Fatalf("Couldn't load collectors: %s", err)
+		}
 	}
+	logCollectors(collectors)
 
-	log.Infof("Enabled collectors:")
-	for n := range collectors {
-		log.Infof(" - %s", n)
+	if *maintenanceOn {
+		maintenance, err := NewMaintenance(*maintenanceFile)
+		if err != nil {
+			log.Fatalf("Couldn't load maintenance state: %s", err)
+		}
+		prometheus.MustRegister(maintenance)
+		http.Handle("/-/maintenance", maintenance)
 	}
 
-	nodeCollector := NodeCollector{collectors: collectors}
-	prometheus.MustRegister(nodeCollector)
+	labels, err := parseScrapeLabels(*scrapeLabels)
+	if err != nil {
+		log.Fatalf("Couldn't parse scrape labels: %s", err)
+	}
+	history := newScrapeHistory(*scrapeHistorySize)
+	handler := &metricsHandler{collectors: collectors, scrapeLabels: labels, history: history}
+	if loader != nil {
+		loader.reloadOnSIGHUP(handler, *listenAddress)
+	}
 
-	handler := prometheus.Handler()
+	if *pushURL != "" {
+		p := &pusher{
+			url:    *pushURL,
+			client: &http.Client{Timeout: *pushInterval},
+			gather: func() (mfs []*dto.MetricFamily, err error) {
+				handler.withCollectors(func(enabled map[string]collector.Collector) {
+					mfs, err = gather(enabled, nil)
+				})
+				return mfs, err
+			},
+			now: time.Now,
+		}
+		if *pushSpoolDir != "" {
+			if p.spool, err = newSpool(*pushSpoolDir, *pushSpoolBytes); err != nil {
+				log.Fatalf("Couldn't open push spool: %s", err)
+			}
+		}
+		log.Infoln("Pushing metrics to", *pushURL)
+		go p.run(*pushInterval)
+	}
 
-	http.Handle(*metricsPath, handler)
+	http.Handle(*metricsPath, prometheus.InstrumentHandler("prometheus", handler))
+	summaryLink := ""
+	if *summaryOn {
+		http.Handle(*metricsPath+"/summary", prometheus.InstrumentHandler("summary", &summaryHandler{
+			metrics:    handler,
+			mountpoint: *summaryMountpoint,
+			device:     *summaryDevice,
+		}))
+		summaryLink = `<p><a href="` + *metricsPath + `/summary">Summary metrics</a></p>`
+	}
+	if history != nil {
+		http.Handle("/debug/scrapes", history)
+	}
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 			<head><title>Node Exporter</title></head>
 			<body>
 			<h1>Node Exporter</h1>
 			<p><a href="` + *metricsPath + `">Metrics</a></p>
+			` + summaryLink + `
 			</body>
 			</html>`))
 	})
 
 	log.Infoln("Listening on", *listenAddress)
-	err = http.ListenAndServe(*listenAddress, nil)
+	err = listenAndServe(*listenAddress, http.DefaultServeMux, *webConfigFile)
 	if err != nil {
 		log.Fatal(err)
 	}
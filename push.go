@@ -0,0 +1,131 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/version"
+)
+
+// pusher sends the metrics to a remote write endpoint at a fixed interval.
+// Requests failing while the endpoint is unreachable go to the spool, if
+// any, and are replayed before the next request once it is back.
+type pusher struct {
+	url    string
+	client *http.Client
+	spool  *spool
+	gather func() ([]*dto.MetricFamily, error)
+	now    func() time.Time
+}
+
+// remoteWriteError is an error response of the remote write endpoint.
+type remoteWriteError struct {
+	status int
+	body   string
+}
+
+func (e *remoteWriteError) Error() string {
+	return fmt.Sprintf("server returned HTTP status %d: %s", e.status, e.body)
+}
+
+// recoverable returns whether sending the request again can succeed. The
+// server rejecting the request itself can't.
+func recoverable(err error) bool {
+	e, ok := err.(*remoteWriteError)
+	return !ok || e.status/100 == 5 || e.status == http.StatusTooManyRequests
+}
+
+func (p *pusher) run(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := p.push(); err != nil {
+			log.Errorf("Couldn't push metrics: %s", err)
+		}
+	}
+}
+
+func (p *pusher) push() error {
+	ts := p.now()
+	mfs, err := p.gather()
+	if err != nil {
+		log.Errorf("Error gathering metrics to push: %s", err)
+	}
+	body, err := encodeWriteRequest(familiesToSeries(mfs), ts.UnixNano()/int64(time.Millisecond))
+	if err != nil {
+		return fmt.Errorf("couldn't encode remote write request: %s", err)
+	}
+
+	if p.spool != nil {
+		if err := p.spool.replay(p.sendSpooled); err != nil {
+			return p.keep(body, err)
+		}
+	}
+	if err := p.send(body); err != nil {
+		if !recoverable(err) {
+			return err
+		}
+		return p.keep(body, err)
+	}
+	return nil
+}
+
+// keep spools the request that couldn't be sent.
+func (p *pusher) keep(body []byte, err error) error {
+	if p.spool == nil {
+		return err
+	}
+	if serr := p.spool.add(body); serr != nil {
+		return fmt.Errorf("%s, couldn't spool request: %s", err, serr)
+	}
+	return fmt.Errorf("%s, spooled request", err)
+}
+
+// sendSpooled sends a spooled request, dropping it if the server rejects it.
+func (p *pusher) sendSpooled(body []byte) error {
+	err := p.send(body)
+	if err != nil && !recoverable(err) {
+		log.Errorf("Dropping spooled request: %s", err)
+		return nil
+	}
+	return err
+}
+
+func (p *pusher) send(body []byte) error {
+	req, err := http.NewRequest("POST", p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "node_exporter/"+version.Version)
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 256))
+		return &remoteWriteError{status: resp.StatusCode, body: string(bytes.TrimSpace(msg))}
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/prompb"
+)
+
+// decodeWriteRequest returns the series of a remote write request as
+// {labels} value@timestamp strings.
+func decodeWriteRequest(t *testing.T, body []byte) []string {
+	buf, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req prompb.WriteRequest
+	if err := proto.Unmarshal(buf, &req); err != nil {
+		t.Fatal(err)
+	}
+	var series []string
+	for _, ts := range req.Timeseries {
+		var labels []string
+		for _, l := range ts.Labels {
+			labels = append(labels, fmt.Sprintf("%s=%s", l.Name, l.Value))
+		}
+		for _, s := range ts.Samples {
+			series = append(series, fmt.Sprintf("{%s} %v@%d", strings.Join(labels, ","), s.Value, s.Timestamp))
+		}
+	}
+	return series
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	mfs := []*dto.MetricFamily{
+		{
+			Name: proto.String("node_load1"),
+			Type: dto.MetricType_GAUGE.Enum(),
+			Metric: []*dto.Metric{
+				{Gauge: &dto.Gauge{Value: proto.Float64(0.5)}},
+			},
+		},
+		{
+			Name: proto.String("node_cpu"),
+			Type: dto.MetricType_COUNTER.Enum(),
+			Metric: []*dto.Metric{{
+				Label:   []*dto.LabelPair{{Name: proto.String("mode"), Value: proto.String("idle")}, {Name: proto.String("cpu"), Value: proto.String("cpu0")}},
+				Counter: &dto.Counter{Value: proto.Float64(42)},
+			}},
+		},
+		{
+			Name: proto.String("node_exporter_scrape_duration_seconds"),
+			Type: dto.MetricType_SUMMARY.Enum(),
+			Metric: []*dto.Metric{{
+				Summary: &dto.Summary{
+					SampleCount: proto.Uint64(2),
+					SampleSum:   proto.Float64(0.25),
+					Quantile:    []*dto.Quantile{{Quantile: proto.Float64(0.5), Value: proto.Float64(0.1)}},
+				},
+			}},
+		},
+	}
+	want := []string{
+		"{__name__=node_load1} 0.5@1500000000000",
+		"{__name__=node_cpu,cpu=cpu0,mode=idle} 42@1500000000000",
+		"{__name__=node_exporter_scrape_duration_seconds,quantile=0.5} 0.1@1500000000000",
+		"{__name__=node_exporter_scrape_duration_seconds_sum} 0.25@1500000000000",
+		"{__name__=node_exporter_scrape_duration_seconds_count} 2@1500000000000",
+	}
+	body, err := encodeWriteRequest(familiesToSeries(mfs), 1500000000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := decodeWriteRequest(t, body)
+	if strings.Join(want, "\n") != strings.Join(got, "\n") {
+		t.Errorf("want series\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+
+	// Series of a scrape repeat most of their labels.
+	var many []*dto.Metric
+	for i := 0; i < 1000; i++ {
+		many = append(many, &dto.Metric{
+			Label:   []*dto.LabelPair{{Name: proto.String("cpu"), Value: proto.String(fmt.Sprintf("cpu%d", i))}},
+			Counter: &dto.Counter{Value: proto.Float64(float64(i))},
+		})
+	}
+	series := familiesToSeries([]*dto.MetricFamily{{Name: proto.String("node_cpu"), Type: dto.MetricType_COUNTER.Enum(), Metric: many}})
+	body, err = encodeWriteRequest(series, 1500000000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := snappy.Decode(nil, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) >= len(raw)/2 {
+		t.Errorf("want request compressed, got %d of %d bytes", len(body), len(raw))
+	}
+}
+
+func TestPusherSpool(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		mtx      sync.Mutex
+		status   = http.StatusServiceUnavailable
+		received []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		if r.Header.Get("Content-Encoding") != "snappy" {
+			t.Errorf("unexpected encoding %q", r.Header.Get("Content-Encoding"))
+		}
+		if status != http.StatusOK {
+			http.Error(w, "unavailable", status)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		received = append(received, decodeWriteRequest(t, body)...)
+	}))
+	defer server.Close()
+
+	s, err := newSpool(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1500000000, 0)
+	p := &pusher{
+		url:    server.URL,
+		client: http.DefaultClient,
+		spool:  s,
+		gather: func() ([]*dto.MetricFamily, error) {
+			return []*dto.MetricFamily{{
+				Name:   proto.String("node_load1"),
+				Type:   dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(1)}}},
+			}}, nil
+		},
+		now: func() time.Time { return now },
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := p.push(); err == nil {
+			t.Fatal("expected error while server is unavailable")
+		}
+		now = now.Add(time.Second)
+	}
+	if files, _ := s.files(); len(files) != 2 {
+		t.Fatalf("want 2 spooled requests, got %d", len(files))
+	}
+
+	// The spool survives restarts and is replayed in order.
+	if s, err = newSpool(dir, 1<<20); err != nil {
+		t.Fatal(err)
+	}
+	p.spool = s
+	status = http.StatusOK
+	if err := p.push(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"{__name__=node_load1} 1@1500000000000",
+		"{__name__=node_load1} 1@1500000001000",
+		"{__name__=node_load1} 1@1500000002000",
+	}
+	if strings.Join(want, "\n") != strings.Join(received, "\n") {
+		t.Errorf("want series\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(received, "\n"))
+	}
+	if files, _ := s.files(); len(files) != 0 {
+		t.Errorf("want empty spool, got %d requests", len(files))
+	}
+
+	// Requests rejected by the server aren't spooled.
+	status = http.StatusBadRequest
+	if err := p.push(); err == nil {
+		t.Fatal("expected error for rejected request")
+	}
+	if files, _ := s.files(); len(files) != 0 {
+		t.Errorf("want empty spool, got %d requests", len(files))
+	}
+}
+
+func TestSpoolMaxBytes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := newSpool(dir, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := s.add([]byte(fmt.Sprintf("request %d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var got []string
+	if err := s.replay(func(b []byte) error {
+		got = append(got, string(b))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if want := "request 3,request 4"; strings.Join(got, ",") != want {
+		t.Errorf("want %s, got %s", want, strings.Join(got, ","))
+	}
+}
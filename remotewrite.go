@@ -0,0 +1,113 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/node_exporter/prompb"
+)
+
+// labelPair is a label of a remote write series.
+type labelPair struct {
+	name, value string
+}
+
+type labelPairs []labelPair
+
+func (l labelPairs) Len() int           { return len(l) }
+func (l labelPairs) Less(i, j int) bool { return l[i].name < l[j].name }
+func (l labelPairs) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+
+// timeSeries is one sample of a remote write series.
+type timeSeries struct {
+	labels labelPairs
+	value  float64
+}
+
+// familiesToSeries flattens the metric families into series, with the
+// _sum, _count, _bucket and quantile series of summaries and histograms
+// written out like Prometheus does when scraping them.
+func familiesToSeries(mfs []*dto.MetricFamily) []timeSeries {
+	var series []timeSeries
+	for _, mf := range mfs {
+		name := mf.GetName()
+		for _, m := range mf.Metric {
+			add := func(name string, value float64, extra ...labelPair) {
+				labels := make(labelPairs, 0, len(m.Label)+len(extra)+1)
+				labels = append(labels, labelPair{model.MetricNameLabel, name})
+				for _, l := range m.Label {
+					labels = append(labels, labelPair{l.GetName(), l.GetValue()})
+				}
+				labels = append(labels, extra...)
+				sort.Sort(labels)
+				series = append(series, timeSeries{labels: labels, value: value})
+			}
+			switch mf.GetType() {
+			case dto.MetricType_COUNTER:
+				add(name, m.Counter.GetValue())
+			case dto.MetricType_GAUGE:
+				add(name, m.Gauge.GetValue())
+			case dto.MetricType_UNTYPED:
+				add(name, m.Untyped.GetValue())
+			case dto.MetricType_SUMMARY:
+				for _, q := range m.Summary.Quantile {
+					add(name, q.GetValue(), labelPair{model.QuantileLabel, fmt.Sprint(q.GetQuantile())})
+				}
+				add(name+"_sum", m.Summary.GetSampleSum())
+				add(name+"_count", float64(m.Summary.GetSampleCount()))
+			case dto.MetricType_HISTOGRAM:
+				inf := false
+				for _, b := range m.Histogram.Bucket {
+					add(name+"_bucket", float64(b.GetCumulativeCount()), labelPair{model.BucketLabel, fmt.Sprint(b.GetUpperBound())})
+					inf = math.IsInf(b.GetUpperBound(), 1)
+				}
+				if !inf {
+					add(name+"_bucket", float64(m.Histogram.GetSampleCount()), labelPair{model.BucketLabel, "+Inf"})
+				}
+				add(name+"_sum", m.Histogram.GetSampleSum())
+				add(name+"_count", float64(m.Histogram.GetSampleCount()))
+			}
+		}
+	}
+	return series
+}
+
+// encodeWriteRequest returns the snappy compressed protobuf of a remote
+// write request with the series at timestamp ms, in milliseconds since the
+// epoch.
+func encodeWriteRequest(series []timeSeries, ms int64) ([]byte, error) {
+	req := &prompb.WriteRequest{Timeseries: make([]*prompb.TimeSeries, 0, len(series))}
+	for _, s := range series {
+		ts := &prompb.TimeSeries{
+			Labels:  make([]*prompb.Label, 0, len(s.labels)),
+			Samples: []*prompb.Sample{{Value: s.value, Timestamp: ms}},
+		}
+		for _, l := range s.labels {
+			ts.Labels = append(ts.Labels, &prompb.Label{Name: l.name, Value: l.value})
+		}
+		req.Timeseries = append(req.Timeseries, ts)
+	}
+	buf, err := proto.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	return snappy.Encode(nil, buf), nil
+}
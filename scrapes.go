@@ -0,0 +1,104 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// scrapeSummary is the outcome of one scrape of the metrics endpoint.
+type scrapeSummary struct {
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration_seconds"`
+	// Errors holds the errors of the failed collectors by name.
+	Errors map[string]string `json:"errors,omitempty"`
+	// Error is set if gathering the metrics failed as a whole.
+	Error string `json:"error,omitempty"`
+}
+
+// scrapeErrors collects the errors of the collectors run by one scrape.
+type scrapeErrors struct {
+	mtx    sync.Mutex
+	errors map[string]string
+}
+
+func (e *scrapeErrors) add(name string, err error) {
+	if e == nil || err == nil {
+		return
+	}
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	if e.errors == nil {
+		e.errors = map[string]string{}
+	}
+	e.errors[name] = err.Error()
+}
+
+// scrapeHistory keeps the summaries of the most recent scrapes in a ring
+// buffer and serves them at /debug/scrapes, newest first.
+type scrapeHistory struct {
+	mtx     sync.Mutex
+	scrapes []scrapeSummary
+	next    int
+	full    bool
+}
+
+// newScrapeHistory returns a scrapeHistory keeping size scrapes, or nil if
+// size is not positive.
+func newScrapeHistory(size int) *scrapeHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &scrapeHistory{scrapes: make([]scrapeSummary, size)}
+}
+
+func (h *scrapeHistory) add(s scrapeSummary) {
+	if h == nil {
+		return
+	}
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.scrapes[h.next] = s
+	h.next = (h.next + 1) % len(h.scrapes)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// recent returns the recorded scrapes, newest first.
+func (h *scrapeHistory) recent() []scrapeSummary {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.scrapes)
+	}
+	scrapes := make([]scrapeSummary, 0, n)
+	for i := 1; i <= n; i++ {
+		scrapes = append(scrapes, h.scrapes[(h.next-i+len(h.scrapes))%len(h.scrapes)])
+	}
+	return scrapes
+}
+
+func (h *scrapeHistory) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(h.recent()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+type failingCollector struct{}
+
+func (failingCollector) Update(ch chan<- prometheus.Metric) error {
+	return errors.New("broken")
+}
+
+func TestScrapeHistory(t *testing.T) {
+	if h := newScrapeHistory(0); h != nil {
+		t.Fatal("want no history for size 0")
+	}
+
+	h := newScrapeHistory(3)
+	if got := h.recent(); len(got) != 0 {
+		t.Fatalf("want no scrapes, got %v", got)
+	}
+	start := time.Unix(1500000000, 0)
+	for i := 0; i < 5; i++ {
+		h.add(scrapeSummary{Time: start.Add(time.Duration(i) * time.Second)})
+	}
+	got := h.recent()
+	if len(got) != 3 {
+		t.Fatalf("want 3 scrapes, got %d", len(got))
+	}
+	for i, s := range got {
+		if want := start.Add(time.Duration(4-i) * time.Second); !s.Time.Equal(want) {
+			t.Errorf("scrape %d: want time %s, got %s", i, want, s.Time)
+		}
+	}
+}
+
+func TestScrapeHistoryHandler(t *testing.T) {
+	history := newScrapeHistory(10)
+	h := metricsHandler{collectors: map[string]collector.Collector{
+		"fake":    fakeCollector{},
+		"failing": failingCollector{},
+	}, history: history}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics?collect[]=fake", nil))
+
+	w := httptest.NewRecorder()
+	history.ServeHTTP(w, httptest.NewRequest("GET", "/debug/scrapes", nil))
+	var scrapes []scrapeSummary
+	if err := json.Unmarshal(w.Body.Bytes(), &scrapes); err != nil {
+		t.Fatal(err)
+	}
+	if len(scrapes) != 2 {
+		t.Fatalf("want 2 scrapes, got %d", len(scrapes))
+	}
+	if len(scrapes[0].Errors) != 0 {
+		t.Errorf("want no errors in the latest scrape, got %v", scrapes[0].Errors)
+	}
+	if want, got := "broken", scrapes[1].Errors["failing"]; want != got {
+		t.Errorf("want error %q for failing collector, got %q", want, got)
+	}
+	if _, ok := scrapes[1].Errors["fake"]; ok {
+		t.Errorf("unexpected error for fake collector")
+	}
+}
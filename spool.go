@@ -0,0 +1,148 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/common/log"
+)
+
+const spoolSuffix = ".snappy"
+
+// spool keeps the remote write requests that couldn't be sent in files in a
+// directory, one per request, so they survive restarts. Once the files
+// exceed maxBytes the oldest are dropped.
+type spool struct {
+	dir      string
+	maxBytes int64
+
+	mtx sync.Mutex
+	seq uint64
+}
+
+func newSpool(dir string, maxBytes int64) (*spool, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	s := &spool{dir: dir, maxBytes: maxBytes}
+	files, err := s.files()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		s.seq = spoolSeq(files[len(files)-1])
+	}
+	return s, nil
+}
+
+// files returns the spooled files, oldest first.
+func (s *spool) files() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*"+spoolSuffix))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func spoolSeq(file string) uint64 {
+	seq, _ := strconv.ParseUint(strings.TrimSuffix(filepath.Base(file), spoolSuffix), 10, 64)
+	return seq
+}
+
+// add writes the request to the spool and drops the oldest requests if it
+// grew beyond its size.
+func (s *spool) add(body []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.seq++
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d%s", s.seq, spoolSuffix))
+	tmp, err := ioutil.TempFile(s.dir, "tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return s.trim()
+}
+
+func (s *spool) trim() error {
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	var size int64
+	sizes := make([]int64, len(files))
+	for i, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		sizes[i] = fi.Size()
+		size += sizes[i]
+	}
+	for i := 0; size > s.maxBytes && i < len(files); i++ {
+		log.Warnf("Push spool full, dropping %s", files[i])
+		if err := os.Remove(files[i]); err != nil {
+			return err
+		}
+		size -= sizes[i]
+	}
+	return nil
+}
+
+// replay sends the spooled requests, oldest first, and removes them once
+// sent. It stops at the first request send fails to deliver.
+func (s *spool) replay(send func([]byte) error) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	files, err := s.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		body, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		if err := send(body); err != nil {
+			return err
+		}
+		if err := os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
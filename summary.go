@@ -0,0 +1,141 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+// summaryCollectors are the collectors run for the summary, if enabled.
+var summaryCollectors = []string{"loadavg", "meminfo", "filesystem", "netdev"}
+
+// summaryMetrics are the metric families of the summary.
+var summaryMetrics = map[string]bool{
+	"node_load1":                  true,
+	"node_load5":                  true,
+	"node_load15":                 true,
+	"node_memory_MemTotal":        true,
+	"node_memory_MemFree":         true,
+	"node_memory_MemAvailable":    true,
+	"node_memory_Buffers":         true,
+	"node_memory_Cached":          true,
+	"node_memory_SwapTotal":       true,
+	"node_memory_SwapFree":        true,
+	"node_filesystem_size":        true,
+	"node_filesystem_free":        true,
+	"node_filesystem_avail":       true,
+	"node_filesystem_readonly":    true,
+	"node_network_receive_bytes":  true,
+	"node_network_transmit_bytes": true,
+	"node_network_receive_errs":   true,
+	"node_network_transmit_errs":  true,
+}
+
+// summaryHandler serves a small subset of the metrics for scrapers on
+// constrained uplinks: the load, memory, the filesystem of one mount point
+// and the traffic of one network device.
+type summaryHandler struct {
+	metrics    *metricsHandler
+	mountpoint string
+	// device is the network device, the one that received the most bytes
+	// if empty.
+	device string
+}
+
+func (h *summaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		mfs []*dto.MetricFamily
+		err error
+	)
+	h.metrics.withCollectors(func(enabled map[string]collector.Collector) {
+		collectors := map[string]collector.Collector{}
+		for _, name := range summaryCollectors {
+			if c, ok := enabled[name]; ok {
+				collectors[name] = c
+			}
+		}
+		mfs, err = gather(collectors, nil)
+	})
+	if err != nil {
+		http.Error(w, "An error has occurred during metrics collection:\n\n"+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeMetrics(w, r, summarize(mfs, h.mountpoint, h.device))
+}
+
+// summarize returns the summary metrics of the metric families.
+func summarize(mfs []*dto.MetricFamily, mountpoint, device string) []*dto.MetricFamily {
+	if device == "" {
+		device = busiestDevice(mfs)
+	}
+	var summary []*dto.MetricFamily
+	for _, mf := range mfs {
+		name := mf.GetName()
+		if !summaryMetrics[name] {
+			continue
+		}
+		var metrics []*dto.Metric
+		for _, m := range mf.Metric {
+			if strings.HasPrefix(name, "node_filesystem_") && labelValue(m, "mountpoint") != mountpoint {
+				continue
+			}
+			if strings.HasPrefix(name, "node_network_") && labelValue(m, "device") != device {
+				continue
+			}
+			metrics = append(metrics, m)
+		}
+		if len(metrics) > 0 {
+			mf.Metric = metrics
+			summary = append(summary, mf)
+		}
+	}
+	return summary
+}
+
+// busiestDevice returns the network device other than loopback that
+// received the most bytes.
+func busiestDevice(mfs []*dto.MetricFamily) string {
+	var (
+		device string
+		max    float64 = -1
+	)
+	for _, mf := range mfs {
+		if mf.GetName() != "node_network_receive_bytes" {
+			continue
+		}
+		for _, m := range mf.Metric {
+			d := labelValue(m, "device")
+			if d == "lo" || d == "lo0" {
+				continue
+			}
+			if v := m.GetGauge().GetValue(); v > max {
+				device, max = d, v
+			}
+		}
+	}
+	return device
+}
+
+func labelValue(m *dto.Metric, name string) string {
+	for _, l := range m.Label {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/node_exporter/collector"
+)
+
+type summaryTestCollector struct{}
+
+func (summaryTestCollector) Update(ch chan<- prometheus.Metric) error {
+	load := prometheus.NewDesc("node_load1", "1m load average.", nil, nil)
+	ch <- prometheus.MustNewConstMetric(load, prometheus.GaugeValue, 0.5)
+	other := prometheus.NewDesc("node_load_other", "Other metric.", nil, nil)
+	ch <- prometheus.MustNewConstMetric(other, prometheus.GaugeValue, 1)
+	fs := prometheus.NewDesc("node_filesystem_avail", "Filesystem space available.", []string{"mountpoint"}, nil)
+	ch <- prometheus.MustNewConstMetric(fs, prometheus.GaugeValue, 100, "/")
+	ch <- prometheus.MustNewConstMetric(fs, prometheus.GaugeValue, 200, "/home")
+	rx := prometheus.NewDesc("node_network_receive_bytes", "Network device statistic receive_bytes.", []string{"device"}, nil)
+	ch <- prometheus.MustNewConstMetric(rx, prometheus.GaugeValue, 9000, "lo")
+	ch <- prometheus.MustNewConstMetric(rx, prometheus.GaugeValue, 10, "eth0")
+	ch <- prometheus.MustNewConstMetric(rx, prometheus.GaugeValue, 500, "eth1")
+	return nil
+}
+
+func TestSummaryHandler(t *testing.T) {
+	metrics := &metricsHandler{collectors: map[string]collector.Collector{
+		"loadavg": summaryTestCollector{},
+		"fake":    fakeCollector{},
+	}}
+	for _, c := range []struct {
+		device        string
+		want, notWant []string
+	}{
+		{
+			"",
+			[]string{"node_load1 0.5", `node_filesystem_avail{mountpoint="/"} 100`, `node_network_receive_bytes{device="eth1"} 500`},
+			[]string{"node_load_other", "/home", `device="lo"`, `device="eth0"`, "node_fake", "node_scrape_collector", "go_"},
+		},
+		{
+			"eth0",
+			[]string{`node_network_receive_bytes{device="eth0"} 10`},
+			[]string{`device="eth1"`},
+		},
+	} {
+		h := &summaryHandler{metrics: metrics, mountpoint: "/", device: c.device}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/metrics/summary", nil))
+		body := w.Body.String()
+		for _, s := range c.want {
+			if !strings.Contains(body, s) {
+				t.Errorf("device %q: expected %q in output", c.device, s)
+			}
+		}
+		for _, s := range c.notWant {
+			if strings.Contains(body, s) {
+				t.Errorf("device %q: unexpected %q in output", c.device, s)
+			}
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/log"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+// webConfig is the content of the file given with -web.config.
+type webConfig struct {
+	TLSConfig struct {
+		CertFile     string `yaml:"cert_file"`
+		KeyFile      string `yaml:"key_file"`
+		ClientCAFile string `yaml:"client_ca_file"`
+		// ClientAuthType defaults to RequireAndVerifyClientCert if a
+		// client CA is given.
+		ClientAuthType string `yaml:"client_auth_type"`
+	} `yaml:"tls_server_config"`
+	// BasicAuthUsers maps user names to bcrypt hashed passwords.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+}
+
+func loadWebConfig(filename string) (*webConfig, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c := &webConfig{}
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		return nil, fmt.Errorf("couldn't parse %s: %s", filename, err)
+	}
+
+	t := &c.TLSConfig
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, fmt.Errorf("cert_file and key_file must be given together in %s", filename)
+	}
+	if t.CertFile == "" && (t.ClientCAFile != "" || t.ClientAuthType != "") {
+		return nil, fmt.Errorf("client certificates need cert_file and key_file in %s", filename)
+	}
+	if t.ClientAuthType == "" && t.ClientCAFile != "" {
+		t.ClientAuthType = "RequireAndVerifyClientCert"
+	}
+	if _, ok := clientAuthTypes[t.ClientAuthType]; t.ClientAuthType != "" && !ok {
+		return nil, fmt.Errorf("unknown client_auth_type '%s' in %s", t.ClientAuthType, filename)
+	}
+	for user, hash := range c.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("invalid password hash for user '%s' in %s: %s", user, filename, err)
+		}
+	}
+	return c, nil
+}
+
+// listenAndServe serves handler on addr, over TLS and behind basic auth if
+// the web config file asks for it.
+func listenAndServe(addr string, handler http.Handler, webConfigFile string) error {
+	server := &http.Server{Addr: addr, Handler: handler}
+	if webConfigFile == "" {
+		return server.ListenAndServe()
+	}
+	c, err := loadWebConfig(webConfigFile)
+	if err != nil {
+		return err
+	}
+	if len(c.BasicAuthUsers) > 0 {
+		server.Handler = newBasicAuthHandler(c.BasicAuthUsers, handler)
+	}
+	if c.TLSConfig.CertFile == "" {
+		return server.ListenAndServe()
+	}
+
+	r := &tlsReloader{
+		certFile:   c.TLSConfig.CertFile,
+		keyFile:    c.TLSConfig.KeyFile,
+		caFile:     c.TLSConfig.ClientCAFile,
+		clientAuth: clientAuthTypes[c.TLSConfig.ClientAuthType],
+	}
+	if _, err := r.getConfig(); err != nil {
+		return err
+	}
+	server.TLSConfig = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return r.getConfig()
+		},
+	}
+	return server.ListenAndServeTLS(c.TLSConfig.CertFile, c.TLSConfig.KeyFile)
+}
+
+// tlsReloader builds the TLS config of the server and rebuilds it when the
+// certificate, key or client CA file changes, so rotated certificates are
+// picked up without a restart.
+type tlsReloader struct {
+	certFile, keyFile, caFile string
+	clientAuth                tls.ClientAuthType
+
+	mtx      sync.Mutex
+	modTimes []time.Time
+	config   *tls.Config
+}
+
+func (r *tlsReloader) getConfig() (*tls.Config, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	files := []string{r.certFile, r.keyFile}
+	if r.caFile != "" {
+		files = append(files, r.caFile)
+	}
+	modTimes := make([]time.Time, 0, len(files))
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return r.keep(err)
+		}
+		modTimes = append(modTimes, fi.ModTime())
+	}
+	if r.config != nil && timesEqual(r.modTimes, modTimes) {
+		return r.config, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return r.keep(err)
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   r.clientAuth,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if r.caFile != "" {
+		pem, err := ioutil.ReadFile(r.caFile)
+		if err != nil {
+			return r.keep(err)
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(pem) {
+			return r.keep(fmt.Errorf("no certificates found in %s", r.caFile))
+		}
+	}
+	if r.config != nil {
+		log.Infoln("Reloaded TLS certificates from", r.certFile)
+	}
+	r.modTimes, r.config = modTimes, config
+	return config, nil
+}
+
+// keep returns the last good config while files are half way through being
+// rotated, and the error if there is none yet.
+func (r *tlsReloader) keep(err error) (*tls.Config, error) {
+	if r.config == nil {
+		return nil, fmt.Errorf("couldn't load TLS config: %s", err)
+	}
+	log.Errorf("Couldn't reload TLS config, keeping the previous one: %s", err)
+	return r.config, nil
+}
+
+func timesEqual(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// basicAuthHandler requires one of the configured users on every request.
+// Credentials that passed the bcrypt check are cached, as it is
+// deliberately slow.
+type basicAuthHandler struct {
+	users   map[string]string
+	handler http.Handler
+
+	mtx   sync.Mutex
+	valid map[[sha256.Size]byte]bool
+}
+
+func newBasicAuthHandler(users map[string]string, handler http.Handler) *basicAuthHandler {
+	return &basicAuthHandler{
+		users:   users,
+		handler: handler,
+		valid:   map[[sha256.Size]byte]bool{},
+	}
+}
+
+func (h *basicAuthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, password, ok := r.BasicAuth()
+	if !ok || !h.authenticate(user, password) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="node_exporter"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+func (h *basicAuthHandler) authenticate(user, password string) bool {
+	hash, ok := h.users[user]
+	key := sha256.Sum256([]byte(user + "\x00" + password + "\x00" + hash))
+
+	h.mtx.Lock()
+	cached := h.valid[key]
+	h.mtx.Unlock()
+	if ok && cached {
+		return true
+	}
+	if !ok {
+		// Take as long for unknown users as for wrong passwords.
+		hash = "$2y$10$QOauhQNbBCuQDKes6eFzPeMqBSjb7Mr5DUmpZ/VcEd00UAV/LDeSi"
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil || !ok {
+		return false
+	}
+
+	h.mtx.Lock()
+	h.valid[key] = true
+	h.mtx.Unlock()
+	return true
+}
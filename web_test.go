@@ -0,0 +1,177 @@
+// Copyright 2017 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func writeCert(t *testing.T, dir, name string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := ioutil.WriteFile(filepath.Join(dir, "cert.pem"), certPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "key.pem"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTLSReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &tlsReloader{
+		certFile: filepath.Join(dir, "cert.pem"),
+		keyFile:  filepath.Join(dir, "key.pem"),
+	}
+	if _, err := r.getConfig(); err == nil {
+		t.Fatal("expected error for missing certificate")
+	}
+
+	subject := func() string {
+		c, err := r.getConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(c.Certificates[0].Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.Subject.CommonName
+	}
+	writeCert(t, dir, "first")
+	if want, got := "first", subject(); want != got {
+		t.Errorf("want certificate %s, got %s", want, got)
+	}
+
+	writeCert(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	for _, f := range []string{r.certFile, r.keyFile} {
+		if err := os.Chtimes(f, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want, got := "second", subject(); want != got {
+		t.Errorf("want rotated certificate %s, got %s", want, got)
+	}
+
+	// A broken rotation keeps serving the last good certificate.
+	if err := ioutil.WriteFile(r.keyFile, []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := "second", subject(); want != got {
+		t.Errorf("want previous certificate %s, got %s", want, got)
+	}
+}
+
+func TestBasicAuthHandler(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newBasicAuthHandler(map[string]string{"alice": string(hash)}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	for _, c := range []struct {
+		user, password string
+		status         int
+	}{
+		{"alice", "secret", http.StatusOK},
+		{"alice", "secret", http.StatusOK},
+		{"alice", "wrong", http.StatusUnauthorized},
+		{"bob", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if c.user != "" {
+			req.SetBasicAuth(c.user, c.password)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if want, got := c.status, w.Code; want != got {
+			t.Errorf("%s/%s: want status %d, got %d", c.user, c.password, want, got)
+		}
+	}
+}
+
+func TestLoadWebConfig(t *testing.T) {
+	f, err := ioutil.TempFile("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	for _, c := range []struct {
+		config string
+		ok     bool
+	}{
+		{"tls_server_config:\n  cert_file: a.pem\n  key_file: a.key\n  client_ca_file: ca.pem\n", true},
+		{"basic_auth_users:\n  alice: $2y$10$QOauhQNbBCuQDKes6eFzPeMqBSjb7Mr5DUmpZ/VcEd00UAV/LDeSi\n", true},
+		{"basic_auth_users:\n  alice: secret\n", false},
+		{"tls_server_config:\n  cert_file: a.pem\n", false},
+		{"tls_server_config:\n  client_ca_file: ca.pem\n", false},
+		{"tls_server_config:\n  cert_file: a.pem\n  key_file: a.key\n  client_auth_type: Always\n", false},
+		{"tls_config:\n  cert_file: a.pem\n", false},
+	} {
+		if err := ioutil.WriteFile(f.Name(), []byte(c.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		wc, err := loadWebConfig(f.Name())
+		if c.ok != (err == nil) {
+			t.Errorf("%q: unexpected error %v", c.config, err)
+		}
+		if wc != nil && wc.TLSConfig.ClientCAFile != "" && wc.TLSConfig.ClientAuthType != "RequireAndVerifyClientCert" {
+			t.Errorf("%q: want client certificates verified by default, got %s", c.config, wc.TLSConfig.ClientAuthType)
+		}
+	}
+}